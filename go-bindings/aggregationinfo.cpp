@@ -40,7 +40,7 @@ CAggregationInfo CAggregationInfoFromMsgHash(CPublicKey pkPtr, void *hash) {
 CAggregationInfo CAggregationInfoFromVectors(void **publicKeys,
     size_t numPublicKeys, void **messageHashes, size_t numMessageHashes,
     void **exponents, size_t numExponents, size_t *sizesExponents,
-    bool *didErr) {
+    char **errMsg) {
 
     // build the pubkey vector
     std::vector<bls::PublicKey> vecPubKeys;
@@ -59,14 +59,14 @@ CAggregationInfo CAggregationInfoFromVectors(void **publicKeys,
     // build the exponents vector
     std::vector<bn_t*> vecExponents;
     for (int i = 0; i < numExponents; i++) {
-        bn_t *exp;
+        bn_t *exp = new bn_t[1];
         bn_new(*exp);
         bn_read_bin(*exp, static_cast<uint8_t*>(exponents[i]),
             sizesExponents[i]);
         vecExponents.push_back(exp);
     }
 
-    bls::AggregationInfo* ai;
+    bls::AggregationInfo* ai = nullptr;
     try {
         ai = new bls::AggregationInfo(
             bls::AggregationInfo::FromVectors(vecPubKeys, vecHashes,
@@ -74,9 +74,13 @@ CAggregationInfo CAggregationInfoFromVectors(void **publicKeys,
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
-        return nullptr;
+        SetErrorMsg(errMsg, ex);
+    }
+
+    // FromVectors copies the exponents
+    for (bn_t *exp : vecExponents) {
+        bn_free(*exp);
+        delete[] exp;
     }
 
     return ai;
@@ -103,7 +107,7 @@ CAggregationInfo MergeAggregationInfos(void **agginfos, size_t len) {
 }
 
 void CAggregationInfoRemoveEntries(CAggregationInfo inPtr, void **messages,
-    size_t numMessages, void **publicKeys, size_t numPublicKeys, bool *didErr) {
+    size_t numMessages, void **publicKeys, size_t numPublicKeys, char **errMsg) {
     bls::AggregationInfo* ai = (bls::AggregationInfo*)inPtr;
 
     // build the message vector
@@ -124,8 +128,7 @@ void CAggregationInfoRemoveEntries(CAggregationInfo inPtr, void **messages,
         ai->RemoveEntries(vecMessages, vecPubKeys);
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
     }
 
     return;
@@ -150,16 +153,16 @@ bool CAggregationInfoEmpty(CAggregationInfo inPtr) {
     return ai->Empty();
 }
 
-uint8_t* CAggregationInfoGetPubKeys(CAggregationInfo inPtr,
+void **CAggregationInfoGetPubKeys(CAggregationInfo inPtr,
     size_t *retNumKeys) {
     bls::AggregationInfo *ai = (bls::AggregationInfo*)inPtr;
     std::vector<bls::PublicKey> keys = ai->GetPubKeys();
     *retNumKeys = keys.size();
 
-    uint8_t *buffer = static_cast<uint8_t*>(
-        malloc(bls::PublicKey::PUBLIC_KEY_SIZE * (*retNumKeys)));
-    for (int i = 0; i < *retNumKeys; ++i) {
-        keys[i].Serialize(&buffer[i * bls::PublicKey::PUBLIC_KEY_SIZE]);
+    // caller to free the array, and each key with CPublicKeyFree
+    void **buffer = static_cast<void**>(malloc(sizeof(void*) * keys.size()));
+    for (size_t i = 0; i < keys.size(); ++i) {
+        buffer[i] = new bls::PublicKey(keys[i]);
     }
 
     return buffer;
@@ -189,15 +192,19 @@ void **CAggregationInfoGetExponents(CAggregationInfo inPtr,
     std::vector<uint8_t*> hashes = ai->GetMessageHashes();
 
     auto len = pubKeys.size();
-    void **buffer = static_cast<void**>(malloc(sizeof(bn_t) * len));
+    // caller to free each exponent and the array
+    void **buffer = static_cast<void**>(malloc(sizeof(void*) * len));
 
+    bn_t exponent;
+    bn_new(exponent);
     for (int i = 0; i < len; ++i) {
-        bn_t *exponent;
-        ai->GetExponent(exponent, hashes[i], pubKeys[i]);
-        size_t szBn = bn_size_bin(*exponent);
+        ai->GetExponent(&exponent, hashes[i], pubKeys[i]);
+        size_t szBn = bn_size_bin(exponent);
         sizesExponents[i] = szBn;
-        bn_write_bin(static_cast<uint8_t*>(buffer[i]), szBn, *exponent);
+        buffer[i] = malloc(szBn);
+        bn_write_bin(static_cast<uint8_t*>(buffer[i]), szBn, exponent);
     }
+    bn_free(exponent);
 
     return buffer;
 }
@@ -7,47 +7,89 @@ package blschia
 // #include "blschia.h"
 import "C"
 import (
-	"errors"
-	"math/big"
+	"bytes"
+	"encoding/binary"
+	"fmt"
 	"runtime"
 	"unsafe"
 )
 
+// aggregationInfoVersion is the version byte of the format written by
+// AggregationInfo.Serialize
+const aggregationInfoVersion = 1
+
 // AggregationInfo represents information about how aggregation was performed,
 // or how a signature was generated (pks, messageHashes, etc).
 type AggregationInfo struct {
+	*aggregationInfo
+}
+
+// aggregationInfo owns the C object. It is shared by all copies of the
+// AggregationInfo, so the finalizer only runs once none of them is reachable.
+type aggregationInfo struct {
 	ai C.CAggregationInfo
 }
 
+func newAggregationInfo(ptr C.CAggregationInfo) AggregationInfo {
+	ai := &aggregationInfo{ai: ptr}
+	runtime.SetFinalizer(ai, (*aggregationInfo).free)
+	return AggregationInfo{ai}
+}
+
+func (ai *aggregationInfo) free() {
+	if ai.ai != nil {
+		C.CAggregationInfoFree(ai.ai)
+		ai.ai = nil
+	}
+}
+
 // AggregationInfoFromMsg creates an AggregationInfo object given a PublicKey
 // and a message payload.
 func AggregationInfoFromMsg(pk PublicKey, message []byte) AggregationInfo {
+	defer runtime.KeepAlive(pk)
+
 	// Get a C pointer to bytes
 	cMessagePtr := C.CBytes(message)
 	defer C.free(cMessagePtr)
 
-	var ai AggregationInfo
-	ai.ai = C.CAggregationInfoFromMsg(pk.pk, cMessagePtr, C.size_t(len(message)))
-	runtime.SetFinalizer(&ai, func(p *AggregationInfo) { p.Free() })
-	return ai
+	return newAggregationInfo(C.CAggregationInfoFromMsg(pk.pk, cMessagePtr, C.size_t(len(message))))
 }
 
 // AggregationInfoFromMsgHash creates an AggregationInfo object given a
 // PublicKey and a pre-hashed message payload.
 func AggregationInfoFromMsgHash(pk PublicKey, hash []byte) AggregationInfo {
+	defer runtime.KeepAlive(pk)
+
 	// Get a C pointer to bytes
 	cMessagePtr := C.CBytes(hash)
 	defer C.free(cMessagePtr)
 
-	var ai AggregationInfo
-	ai.ai = C.CAggregationInfoFromMsgHash(pk.pk, cMessagePtr)
-	runtime.SetFinalizer(&ai, func(p *AggregationInfo) { p.Free() })
-	return ai
+	return newAggregationInfo(C.CAggregationInfoFromMsgHash(pk.pk, cMessagePtr))
 }
 
 // AggregationInfoFromSlices creates an AggregationInfo object given a list of
-// public keys, a list of message hashes and a list of exponents
-func AggregationInfoFromSlices(publicKeys []PublicKey, messageHashes [][]byte, exponents []*big.Int) (AggregationInfo, error) {
+// public keys, a list of message hashes and a list of exponents. Exponents
+// must be non-zero, or the entry would have no effect on verification.
+func AggregationInfoFromSlices(publicKeys []PublicKey, messageHashes [][]byte, exponents []Fr) (AggregationInfo, error) {
+	defer runtime.KeepAlive(publicKeys)
+
+	if len(publicKeys) != len(messageHashes) || len(messageHashes) != len(exponents) {
+		return AggregationInfo{}, &Error{
+			Err: ErrLengthMismatch,
+			Msg: "public keys, message hashes and exponents must have the same length",
+		}
+	}
+	for _, hash := range messageHashes {
+		if err := checkLength("message hash", hash, int(C.CBLSMessageHashLen())); err != nil {
+			return AggregationInfo{}, err
+		}
+	}
+	for i, exp := range exponents {
+		if exp.IsZero() {
+			return AggregationInfo{}, errAggregationInfo("entry %d has a zero exponent", i)
+		}
+	}
+
 	// Get a C pointer to an array of public keys
 	cNumPublicKeys := C.size_t(len(publicKeys))
 	cPublicKeysPtr := C.AllocPtrArray(cNumPublicKeys)
@@ -74,42 +116,45 @@ func AggregationInfoFromSlices(publicKeys []PublicKey, messageHashes [][]byte, e
 	cNumExponents := C.size_t(len(exponents))
 	cExponentsArrayPtr := C.AllocPtrArray(cNumExponents)
 	defer C.FreePtrArray(cExponentsArrayPtr)
-	// Get a C size_t pointer for (variable) sizes of exponents
+	// Get a C size_t pointer for the sizes of exponents, which are all FrSize
 	sizesPtr := C.AllocIntPtr(cNumExponents)
 	defer C.FreeIntPtr(sizesPtr)
 	// Loop thru each exponent and add the bytes C ptr to the array of ptrs at
 	// index
-	for i, bn := range exponents {
+	for i, exp := range exponents {
 		// Get a C pointer to bytes
-		bnBytes := bn.Bytes()
-		C.SetIntPtrVal(sizesPtr, C.size_t(len(bnBytes)), C.int(i))
-		cBNBytesPtr := C.CBytes(bnBytes)
-		defer C.free(cBNBytesPtr)
-		C.SetPtrArray(cExponentsArrayPtr, cBNBytesPtr, C.int(i))
+		C.SetIntPtrVal(sizesPtr, C.size_t(FrSize), C.int(i))
+		cExpBytesPtr := C.CBytes(exp.value[:])
+		defer C.free(cExpBytesPtr)
+		C.SetPtrArray(cExponentsArrayPtr, cExpBytesPtr, C.int(i))
 	}
 
-	var ai AggregationInfo
-	var cDidErr C.bool
-	ai.ai = C.CAggregationInfoFromVectors(cPublicKeysPtr, cNumPublicKeys,
+	var cErrMsg *C.char
+	cAi := C.CAggregationInfoFromVectors(cPublicKeysPtr, cNumPublicKeys,
 		cHashArrayPtr, cNumHashes, cExponentsArrayPtr, cNumExponents, sizesPtr,
-		&cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return AggregationInfo{}, err
+		&cErrMsg)
+	if cErrMsg != nil {
+		return AggregationInfo{}, errFromC(ErrLengthMismatch, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&ai, func(p *AggregationInfo) { p.Free() })
-	return ai, nil
+	return newAggregationInfo(cAi), nil
 }
 
-// Free releases memory allocated by the AggregationInfo object
+// Free releases memory allocated by the AggregationInfo object. All copies of
+// the AggregationInfo object share that memory, so none of them may be used
+// afterwards. Calling Free again is a no-op.
 func (ai AggregationInfo) Free() {
-	C.CAggregationInfoFree(ai.ai)
+	if ai.aggregationInfo == nil {
+		return
+	}
+	runtime.SetFinalizer(ai.aggregationInfo, nil)
+	ai.free()
 }
 
 // MergeAggregationInfos merges multiple AggregationInfo objects into one.
 func MergeAggregationInfos(AIs []AggregationInfo) AggregationInfo {
+	defer runtime.KeepAlive(AIs)
+
 	// Get a C pointer to an array of aggregation info objects
 	cAIsPtr := C.AllocPtrArray(C.size_t(len(AIs)))
 	defer C.FreePtrArray(cAIsPtr)
@@ -119,15 +164,21 @@ func MergeAggregationInfos(AIs []AggregationInfo) AggregationInfo {
 		C.SetPtrArray(cAIsPtr, unsafe.Pointer(aggInfo.ai), C.int(i))
 	}
 
-	var ai AggregationInfo
-	ai.ai = C.MergeAggregationInfos(cAIsPtr, C.size_t(len(AIs)))
-	runtime.SetFinalizer(&ai, func(p *AggregationInfo) { p.Free() })
-
-	return ai
+	return newAggregationInfo(C.MergeAggregationInfos(cAIsPtr, C.size_t(len(AIs))))
 }
 
 // RemoveEntries removes the messages and pubkeys from the tree
 func (ai *AggregationInfo) RemoveEntries(messages [][]byte, publicKeys []PublicKey) error {
+	defer runtime.KeepAlive(ai)
+	defer runtime.KeepAlive(publicKeys)
+
+	if len(messages) != len(publicKeys) {
+		return &Error{
+			Err: ErrLengthMismatch,
+			Msg: "messages and public keys must have the same length",
+		}
+	}
+
 	// Get a C pointer to an array of messages
 	cNumMessages := C.size_t(len(messages))
 	cMessageArrayPtr := C.AllocPtrArray(cNumMessages)
@@ -151,13 +202,11 @@ func (ai *AggregationInfo) RemoveEntries(messages [][]byte, publicKeys []PublicK
 		C.SetPtrArray(cPublicKeysPtr, unsafe.Pointer(key.pk), C.int(i))
 	}
 
-	var cDidErr C.bool
+	var cErrMsg *C.char
 	C.CAggregationInfoRemoveEntries(ai.ai, cMessageArrayPtr, cNumMessages,
-		cPublicKeysPtr, cNumPublicKeys, &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return err
+		cPublicKeysPtr, cNumPublicKeys, &cErrMsg)
+	if cErrMsg != nil {
+		return errFromC(ErrLengthMismatch, cErrMsg)
 	}
 
 	return nil
@@ -165,32 +214,38 @@ func (ai *AggregationInfo) RemoveEntries(messages [][]byte, publicKeys []PublicK
 
 // Equal tests if two AggregationInfo objects are equal
 func (ai AggregationInfo) Equal(other AggregationInfo) bool {
+	defer runtime.KeepAlive(ai)
+	defer runtime.KeepAlive(other)
 	return bool(C.CAggregationInfoIsEqual(ai.ai, other.ai))
 }
 
 // Less tests if one AggregationInfo object is less than the other
 func (ai AggregationInfo) Less(other AggregationInfo) bool {
+	defer runtime.KeepAlive(ai)
+	defer runtime.KeepAlive(other)
 	return bool(C.CAggregationInfoIsLess(ai.ai, other.ai))
 }
 
 // Empty tests whether an AggregationInfo object is empty
 func (ai AggregationInfo) Empty() bool {
+	defer runtime.KeepAlive(ai)
 	return bool(C.CAggregationInfoEmpty(ai.ai))
 }
 
 // GetPubKeys returns the PublicKeys referenced by the AggregationInfo object
 func (ai AggregationInfo) GetPubKeys() []PublicKey {
-	// Get a C pointer to an array of bytes
+	defer runtime.KeepAlive(ai)
+
+	// Get a C pointer to an array of public keys, which are copies of the
+	// stored keys rather than serialized bytes to parse again
 	var cNumKeys C.size_t
 	cPubKeysPtr := C.CAggregationInfoGetPubKeys(ai.ai, &cNumKeys)
-	defer C.free(unsafe.Pointer(cPubKeysPtr))
+	defer C.FreePtrArray(cPubKeysPtr)
 
 	numKeys := int(cNumKeys)
 	keys := make([]PublicKey, numKeys)
 	for i := 0; i < numKeys; i++ {
-		keyPtr := C.GetAddressAtIndex(cPubKeysPtr, C.int(i))
-		pkBytes := C.GoBytes(unsafe.Pointer(keyPtr), C.CPublicKeySizeBytes())
-		keys[i], _ = PublicKeyFromBytes(pkBytes)
+		keys[i] = newPublicKey(C.CPublicKey(C.GetPtrAtIndex(cPubKeysPtr, C.int(i))))
 	}
 
 	return keys
@@ -199,6 +254,8 @@ func (ai AggregationInfo) GetPubKeys() []PublicKey {
 // GetMessageHashes returns the message hashes referenced by the
 // AggregationInfo object
 func (ai AggregationInfo) GetMessageHashes() [][]byte {
+	defer runtime.KeepAlive(ai)
+
 	// Get a C pointer to an array of message hashes
 	var cNumHashes C.size_t
 	hashPtr := C.CAggregationInfoGetMessageHashes(ai.ai, &cNumHashes)
@@ -208,7 +265,7 @@ func (ai AggregationInfo) GetMessageHashes() [][]byte {
 	hashes := make([][]byte, numHashes)
 	for i := 0; i < numHashes; i++ {
 		// get the singular pointer at index
-		hashPtr := C.GetAddressAtIndex(hashPtr, C.int(i))
+		hashPtr := C.GetAddressAtIndex(hashPtr, C.int(i*int(C.CBLSMessageHashLen())))
 		hashes[i] = C.GoBytes(hashPtr, C.CBLSMessageHashLen())
 	}
 
@@ -216,7 +273,8 @@ func (ai AggregationInfo) GetMessageHashes() [][]byte {
 }
 
 // GetExponents returns the exponents from the AggregationInfo object
-func (ai AggregationInfo) GetExponents() []*big.Int {
+func (ai AggregationInfo) GetExponents() []Fr {
+	defer runtime.KeepAlive(ai)
 	cNumExponents := C.CAggregationInfoGetLength(ai.ai)
 	numExponents := int(cNumExponents)
 
@@ -228,13 +286,114 @@ func (ai AggregationInfo) GetExponents() []*big.Int {
 	cExpPtr := C.CAggregationInfoGetExponents(ai.ai, sizesPtr)
 	defer C.FreePtrArray(cExpPtr)
 
-	exponents := make([]*big.Int, numExponents)
+	exponents := make([]Fr, numExponents)
 	for i := 0; i < numExponents; i++ {
 		ptr := C.GetPtrAtIndex(cExpPtr, C.int(i))
+		defer C.free(ptr)
 		cSizePtr := C.GetIntPtrVal(sizesPtr, C.int(i))
-		expBytes := C.GoBytes(ptr, C.int(cSizePtr))
-		exponents[i] = new(big.Int).SetBytes(expBytes)
+		// The exponents are kept reduced, so this only pads them to FrSize
+		exponents[i] = frFromWideBytes(C.GoBytes(ptr, C.int(cSizePtr)))
 	}
 
 	return exponents
 }
+
+// Serialize returns the byte representation of the AggregationInfo object,
+// which can be parsed with AggregationInfoFromBytes.
+//
+// The format is a version byte, followed by the number of entries as a big
+// endian uint32. Each entry is then written in sorted order as the 32 byte
+// message hash, the 48 byte public key, one byte holding the length of the
+// exponent, and the exponent as a minimal big endian integer.
+func (ai AggregationInfo) Serialize() []byte {
+	pks := ai.GetPubKeys()
+	hashes := ai.GetMessageHashes()
+	exponents := ai.GetExponents()
+
+	buf := make([]byte, 5)
+	buf[0] = aggregationInfoVersion
+	binary.BigEndian.PutUint32(buf[1:], uint32(len(pks)))
+	for i, pk := range pks {
+		exp := bytes.TrimLeft(exponents[i].value[:], "\x00")
+		buf = append(buf, hashes[i]...)
+		buf = append(buf, pk.Serialize()...)
+		buf = append(buf, byte(len(exp)))
+		buf = append(buf, exp...)
+	}
+
+	return buf
+}
+
+// AggregationInfoFromBytes parses an AggregationInfo object written by
+// AggregationInfo.Serialize. Only the canonical encoding is accepted: entries
+// must be sorted and unique, public keys must be valid, and exponents must be
+// minimally encoded, non-zero and smaller than the group order.
+func AggregationInfoFromBytes(data []byte) (AggregationInfo, error) {
+	hashLen := int(C.CBLSMessageHashLen())
+	pkLen := int(C.CPublicKeySizeBytes())
+	keyLen := hashLen + pkLen
+
+	if len(data) < 5 {
+		return AggregationInfo{}, errAggregationInfo("input is too short")
+	}
+	if data[0] != aggregationInfoVersion {
+		return AggregationInfo{}, errAggregationInfo("unknown version %d", data[0])
+	}
+	numEntries := binary.BigEndian.Uint32(data[1:])
+	data = data[5:]
+	// Each entry takes at least keyLen + 2 bytes, so reject counts which can't
+	// fit before allocating anything
+	if uint64(numEntries) > uint64(len(data)/(keyLen+2)) {
+		return AggregationInfo{}, errAggregationInfo("%d entries don't fit in %d bytes", numEntries, len(data))
+	}
+
+	pks := make([]PublicKey, numEntries)
+	hashes := make([][]byte, numEntries)
+	exponents := make([]Fr, numEntries)
+	var prevKey []byte
+	for i := range pks {
+		if len(data) < keyLen+1 {
+			return AggregationInfo{}, errAggregationInfo("entry %d is truncated", i)
+		}
+		key := data[:keyLen]
+		if prevKey != nil && bytes.Compare(prevKey, key) >= 0 {
+			return AggregationInfo{}, errAggregationInfo("entry %d is not sorted or not unique", i)
+		}
+		prevKey = key
+
+		pk, err := PublicKeyFromBytes(key[hashLen:])
+		if err != nil {
+			return AggregationInfo{}, err
+		}
+		pks[i] = pk
+		hashes[i] = key[:hashLen]
+
+		expLen := int(data[keyLen])
+		data = data[keyLen+1:]
+		if expLen == 0 || expLen > FrSize || expLen > len(data) {
+			return AggregationInfo{}, errAggregationInfo("entry %d has an invalid exponent length %d", i, expLen)
+		}
+		if data[0] == 0 {
+			return AggregationInfo{}, errAggregationInfo("entry %d has a non-minimal exponent", i)
+		}
+		var padded [FrSize]byte
+		copy(padded[FrSize-expLen:], data[:expLen])
+		exponents[i], err = FrFromBytes(padded[:])
+		if err != nil {
+			return AggregationInfo{}, errAggregationInfo("entry %d has an exponent not smaller than the group order", i)
+		}
+		data = data[expLen:]
+	}
+	if len(data) != 0 {
+		return AggregationInfo{}, errAggregationInfo("%d trailing bytes", len(data))
+	}
+
+	return AggregationInfoFromSlices(pks, hashes, exponents)
+}
+
+func errAggregationInfo(format string, a ...interface{}) error {
+	return &Error{
+		Err: ErrInvalidAggregationInfo,
+		Msg: fmt.Sprintf(format, a...),
+	}
+}
@@ -32,16 +32,16 @@ CAggregationInfo CAggregationInfoFromMsgHash(CPublicKey pkPtr, void *hash);
 CAggregationInfo CAggregationInfoFromVectors(void **publicKeys,
     size_t numPublicKeys, void **messageHashes, size_t numMessageHashes,
     void **exponents, size_t numExponents, size_t *sizesExponents,
-    bool *didErr);
+    char **errMsg);
 
 CAggregationInfo MergeAggregationInfos(void **agginfos, size_t len);
 
 void CAggregationInfoFree(CAggregationInfo inPtr);
 
 void CAggregationInfoRemoveEntries(CAggregationInfo inPtr, void **messages,
-    size_t numMessages, void **publicKeys, size_t numPublicKeys, bool *didErr);
+    size_t numMessages, void **publicKeys, size_t numPublicKeys, char **errMsg);
 
-uint8_t* CAggregationInfoGetPubKeys(CAggregationInfo inPtr,
+void **CAggregationInfoGetPubKeys(CAggregationInfo inPtr,
     size_t *retNumKeys);
 
 uint8_t* CAggregationInfoGetMessageHashes(CAggregationInfo inPtr,
@@ -2,6 +2,7 @@ package blschia_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"testing"
 
 	bls "github.com/nmarley/bls-signatures/go-bindings"
@@ -80,3 +81,167 @@ func TestAggregationInfo(t *testing.T) {
 		t.Error("ai1 should NOT be equal to ai2")
 	}
 }
+
+func TestAggregationInfoSerialize(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, false)
+	m1 := []byte{1, 2, 3}
+	m2 := []byte{4, 5, 6}
+
+	// Signing the same message twice makes the aggregation secure, so that
+	// the exponents are not all 1
+	sig, _ := bls.SignatureAggregate([]bls.Signature{
+		sk1.Sign(m1), sk2.Sign(m1), sk1.Sign(m2),
+	})
+	ai := sig.GetAggregationInfo()
+
+	pks := ai.GetPubKeys()
+	hashes := ai.GetMessageHashes()
+	exponents := ai.GetExponents()
+	if len(pks) != 3 || len(hashes) != 3 || len(exponents) != 3 {
+		t.Fatalf("got %d/%d/%d entries, expected 3", len(pks), len(hashes), len(exponents))
+	}
+	for i := range pks {
+		if i > 0 && bytes.Compare(hashes[i-1], hashes[i]) > 0 {
+			t.Error("message hashes should be sorted")
+		}
+		if exponents[i].IsZero() {
+			t.Error("got exponent 0, expected a non-zero exponent")
+		}
+	}
+	one := bls.FrFromUint64(1)
+	if exponents[0] == one && exponents[1] == one {
+		t.Error("expected secure aggregation exponents")
+	}
+
+	aiBytes := ai.Serialize()
+	ai2, err := bls.AggregationInfoFromBytes(aiBytes)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !ai.Equal(ai2) {
+		t.Error("ai should be equal to ai2")
+	}
+	if got := ai2.Serialize(); !bytes.Equal(got, aiBytes) {
+		t.Errorf("got %x, expected %x", got, aiBytes)
+	}
+
+	sigBytes := sig.SerializeWithAggregationInfo()
+	if !bytes.Equal(sigBytes[:96], sig.Serialize()) || !bytes.Equal(sigBytes[96:], aiBytes) {
+		t.Error("expected the signature followed by the aggregation info")
+	}
+	sig2, err := bls.SignatureFromBytesWithEmbeddedAggregationInfo(sigBytes)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !sig2.Equal(sig) {
+		t.Error("sig2 should be equal to sig")
+	}
+	if !sig2.Verify() {
+		t.Error("sig2 should verify")
+	}
+
+	// A signature without aggregation info round trips too
+	sig3, _ := bls.SignatureFromBytes(sig1Bytes)
+	sig3Bytes := sig3.SerializeWithAggregationInfo()
+	if len(sig3Bytes) != 96+5 {
+		t.Errorf("got %d bytes, expected %d", len(sig3Bytes), 96+5)
+	}
+	sig4, err := bls.SignatureFromBytesWithEmbeddedAggregationInfo(sig3Bytes)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !sig4.Equal(sig3) {
+		t.Error("sig4 should be equal to sig3")
+	}
+
+	sig4.Free()
+	sig3.Free()
+	sig2.Free()
+	ai2.Free()
+	ai.Free()
+	sig.Free()
+	sk2.Free()
+	sk1.Free()
+}
+
+func TestAggregationInfoFromBytesInvalid(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, false)
+	sig, _ := bls.SignatureAggregate([]bls.Signature{
+		sk1.Sign(payload), sk2.Sign(payload),
+	})
+	ai := sig.GetAggregationInfo()
+	valid := ai.Serialize()
+
+	const entryOffset = 5
+	const keyLen = 32 + 48
+	firstLen := keyLen + 1 + int(valid[entryOffset+keyLen])
+
+	modify := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte{}, valid...))
+	}
+	order, _ := hex.DecodeString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+
+	tests := []struct {
+		name   string
+		data   []byte
+		target error
+	}{
+		{"empty", nil, bls.ErrInvalidAggregationInfo},
+		{"version", modify(func(b []byte) []byte { b[0] = 2; return b }), bls.ErrInvalidAggregationInfo},
+		{"count too large", modify(func(b []byte) []byte { b[1] = 0xff; return b }), bls.ErrInvalidAggregationInfo},
+		{"count too small", modify(func(b []byte) []byte { b[4] = 1; return b }), bls.ErrInvalidAggregationInfo},
+		{"truncated", valid[:len(valid)-1], bls.ErrInvalidAggregationInfo},
+		{"trailing bytes", append(append([]byte{}, valid...), 0), bls.ErrInvalidAggregationInfo},
+		{"unsorted", modify(func(b []byte) []byte {
+			first := append([]byte{}, b[entryOffset:entryOffset+firstLen]...)
+			rest := append([]byte{}, b[entryOffset+firstLen:]...)
+			return append(append(b[:entryOffset], rest...), first...)
+		}), bls.ErrInvalidAggregationInfo},
+		{"duplicate", modify(func(b []byte) []byte {
+			first := append([]byte{}, b[entryOffset:entryOffset+firstLen]...)
+			return append(b[:entryOffset+firstLen], first...)
+		}), bls.ErrInvalidAggregationInfo},
+		{"zero length exponent", modify(func(b []byte) []byte {
+			b[4] = 1
+			b[entryOffset+keyLen] = 0
+			return append(b[:entryOffset+keyLen+1], 1)
+		}), bls.ErrInvalidAggregationInfo},
+		{"non-minimal exponent", modify(func(b []byte) []byte {
+			b[4] = 1
+			b[entryOffset+keyLen] = 2
+			return append(b[:entryOffset+keyLen+1], 0, 1)
+		}), bls.ErrInvalidAggregationInfo},
+		{"exponent too large", modify(func(b []byte) []byte {
+			b[4] = 1
+			b[entryOffset+keyLen] = 32
+			return append(b[:entryOffset+keyLen+1], order...)
+		}), bls.ErrInvalidAggregationInfo},
+		{"exponent too long", modify(func(b []byte) []byte {
+			b[4] = 1
+			b[entryOffset+keyLen] = 33
+			return append(append(b[:entryOffset+keyLen+1], 1), make([]byte, 32)...)
+		}), bls.ErrInvalidAggregationInfo},
+		{"invalid public key", modify(func(b []byte) []byte {
+			copy(b[entryOffset+32:], make([]byte, 48))
+			return b
+		}), bls.ErrKeyNotInSubgroup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := bls.AggregationInfoFromBytes(tt.data)
+			expectError(t, err, tt.target)
+		})
+	}
+
+	_, err := bls.SignatureFromBytesWithEmbeddedAggregationInfo(sig1Bytes[:95])
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.SignatureFromBytesWithEmbeddedAggregationInfo(sig1Bytes)
+	expectError(t, err, bls.ErrInvalidAggregationInfo)
+
+	ai.Free()
+	sig.Free()
+	sk2.Free()
+	sk1.Free()
+}
@@ -0,0 +1,305 @@
+package blschia
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// ExtendedKeyVersion is the version field of extended keys serialized by the
+// library. The Base58Check encoding replaces it with a Network's prefix.
+const ExtendedKeyVersion = 1
+
+// Network holds the version prefixes which identify the extended keys of a
+// network in their Base58Check encoding, like the xprv and xpub prefixes of
+// Bitcoin.
+type Network struct {
+	// Name identifies the network in error messages
+	Name string
+	// PrivateVersion is the prefix of extended private keys
+	PrivateVersion uint32
+	// PublicVersion is the prefix of extended public keys
+	PublicVersion uint32
+}
+
+var (
+	// Mainnet extended private keys start with "bprv" and extended public
+	// keys with "bpub" when Base58Check encoded.
+	Mainnet = Network{Name: "mainnet", PrivateVersion: 0x0c7bdb95, PublicVersion: 0x16e7ba2c}
+
+	// Testnet extended private keys start with "tprv" and extended public
+	// keys with "tpub" when Base58Check encoded.
+	Testnet = Network{Name: "testnet", PrivateVersion: 0x1293ec78, PublicVersion: 0x22160e19}
+)
+
+var (
+	networksMu sync.RWMutex
+	networks   = []Network{Mainnet, Testnet}
+)
+
+// RegisterNetwork registers the prefixes of a network, so that its extended
+// keys can be decoded. It returns ErrUnknownNetwork if either prefix is
+// already used by a registered network.
+func RegisterNetwork(net Network) error {
+	networksMu.Lock()
+	defer networksMu.Unlock()
+
+	if net.PrivateVersion == net.PublicVersion {
+		return &Error{
+			Err: ErrUnknownNetwork,
+			Msg: fmt.Sprintf("%s uses the same prefix for private and public keys", net.Name),
+		}
+	}
+	for _, other := range networks {
+		for _, version := range []uint32{net.PrivateVersion, net.PublicVersion} {
+			if version == other.PrivateVersion || version == other.PublicVersion {
+				return &Error{
+					Err: ErrUnknownNetwork,
+					Msg: fmt.Sprintf("prefix %#08x of %s is already used by %s", version, net.Name, other.Name),
+				}
+			}
+		}
+	}
+	networks = append(networks, net)
+	return nil
+}
+
+// lookupNetwork returns the registered network using version as a prefix,
+// and whether it is the prefix of private keys
+func lookupNetwork(version uint32) (Network, bool, error) {
+	networksMu.RLock()
+	defer networksMu.RUnlock()
+
+	for _, net := range networks {
+		switch version {
+		case net.PrivateVersion:
+			return net, true, nil
+		case net.PublicVersion:
+			return net, false, nil
+		}
+	}
+	return Network{}, false, &Error{
+		Err: ErrUnknownNetwork,
+		Msg: fmt.Sprintf("no network uses the prefix %#08x", version),
+	}
+}
+
+// EncodeBase58 returns the Base58Check encoding of key, with its version field
+// replaced by the network's PrivateVersion. It returns an error if key does
+// not have version ExtendedKeyVersion, as the version would be lost.
+func (key ExtendedPrivateKey) EncodeBase58(net Network) (string, error) {
+	var buf [ExtendedPrivateKeySize]byte
+	defer Wipe(buf[:])
+	key.SerializeInto(buf[:])
+	return encodeExtendedKey(buf[:], net.PrivateVersion, ErrInvalidPrivateKey)
+}
+
+// ExtendedPrivateKeyFromBase58 decodes an extended private key encoded with
+// EncodeBase58, and returns it along with its network. It returns
+// ErrInvalidBase58, ErrChecksumMismatch or ErrUnknownNetwork for strings
+// which are not a validly encoded key of a registered network, and
+// ErrInvalidPrivateKey for an extended public key.
+func ExtendedPrivateKeyFromBase58(s string) (ExtendedPrivateKey, Network, error) {
+	data, net, private, err := decodeExtendedKey(s)
+	defer Wipe(data)
+	if err != nil {
+		return ExtendedPrivateKey{}, Network{}, err
+	}
+	if !private {
+		return ExtendedPrivateKey{}, Network{}, &Error{
+			Err: ErrInvalidPrivateKey,
+			Msg: fmt.Sprintf("got a %s extended public key", net.Name),
+		}
+	}
+
+	key, err := ExtendedPrivateKeyFromBytes(data)
+	if err != nil {
+		return ExtendedPrivateKey{}, Network{}, err
+	}
+	return key, net, nil
+}
+
+// EncodeBase58 returns the Base58Check encoding of key, with its version field
+// replaced by the network's PublicVersion. It returns an error if key does not
+// have version ExtendedKeyVersion, as the version would be lost.
+func (key ExtendedPublicKey) EncodeBase58(net Network) (string, error) {
+	return encodeExtendedKey(key.Serialize(), net.PublicVersion, ErrInvalidPublicKey)
+}
+
+// ExtendedPublicKeyFromBase58 decodes an extended public key encoded with
+// EncodeBase58, and returns it along with its network. It returns
+// ErrInvalidBase58, ErrChecksumMismatch or ErrUnknownNetwork for strings
+// which are not a validly encoded key of a registered network, and
+// ErrInvalidPublicKey for an extended private key.
+func ExtendedPublicKeyFromBase58(s string) (ExtendedPublicKey, Network, error) {
+	data, net, private, err := decodeExtendedKey(s)
+	defer Wipe(data)
+	if err != nil {
+		return ExtendedPublicKey{}, Network{}, err
+	}
+	if private {
+		return ExtendedPublicKey{}, Network{}, &Error{
+			Err: ErrInvalidPublicKey,
+			Msg: fmt.Sprintf("got a %s extended private key", net.Name),
+		}
+	}
+
+	key, err := ExtendedPublicKeyFromBytes(data)
+	if err != nil {
+		return ExtendedPublicKey{}, Network{}, err
+	}
+	return key, net, nil
+}
+
+// encodeExtendedKey replaces the version field of a serialized extended key
+// with prefix, and Base58Check encodes the result. data is modified. kind is
+// the error returned for keys of another version.
+func encodeExtendedKey(data []byte, prefix uint32, kind error) (string, error) {
+	if version := binary.BigEndian.Uint32(data); version != ExtendedKeyVersion {
+		return "", &Error{
+			Err: kind,
+			Msg: fmt.Sprintf("only version %d extended keys can be encoded, got %d", ExtendedKeyVersion, version),
+		}
+	}
+	binary.BigEndian.PutUint32(data, prefix)
+
+	checked := make([]byte, len(data)+4)
+	defer Wipe(checked)
+	copy(checked, data)
+	copy(checked[len(data):], base58Checksum(data))
+	return base58Encode(checked), nil
+}
+
+// decodeExtendedKey decodes a Base58Check encoded extended key, and returns
+// it serialized with version ExtendedKeyVersion, along with its network and
+// whether it is private. The returned data should be wiped.
+func decodeExtendedKey(s string) ([]byte, Network, bool, error) {
+	checked, err := base58Decode(s)
+	defer Wipe(checked)
+	if err != nil {
+		return nil, Network{}, false, err
+	}
+	if len(checked) < 8 {
+		return nil, Network{}, false, &Error{
+			Err: ErrInvalidBase58,
+			Msg: fmt.Sprintf("got %d bytes, which is too short for a prefix and checksum", len(checked)),
+		}
+	}
+
+	data := append([]byte{}, checked[:len(checked)-4]...)
+	if !bytes.Equal(base58Checksum(data), checked[len(data):]) {
+		Wipe(data)
+		return nil, Network{}, false, &Error{
+			Err: ErrChecksumMismatch,
+			Msg: "the string is corrupted or mistyped",
+		}
+	}
+
+	net, private, err := lookupNetwork(binary.BigEndian.Uint32(data))
+	if err != nil {
+		Wipe(data)
+		return nil, Network{}, false, err
+	}
+	size := ExtendedPublicKeySize
+	if private {
+		size = ExtendedPrivateKeySize
+	}
+	if err := checkLength("decoded "+net.Name+" extended key", data, size); err != nil {
+		Wipe(data)
+		return nil, Network{}, false, err
+	}
+
+	binary.BigEndian.PutUint32(data, ExtendedKeyVersion)
+	return data, net, private, nil
+}
+
+// base58Checksum returns the first four bytes of the double SHA-256 of data
+func base58Checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes data in Base58, with a leading 1 for each leading zero
+// byte. It works on byte slices rather than a big.Int so that the
+// intermediate values can be wiped.
+func base58Encode(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	// Each byte takes log(256)/log(58) ~ 1.37 digits
+	digits := make([]byte, (len(data)-zeros)*138/100+1)
+	defer Wipe(digits)
+	length := 0
+	for _, b := range data[zeros:] {
+		carry := int(b)
+		i := 0
+		for j := len(digits) - 1; (carry != 0 || i < length) && j >= 0; j-- {
+			carry += 256 * int(digits[j])
+			digits[j] = byte(carry % 58)
+			carry /= 58
+			i++
+		}
+		length = i
+	}
+
+	out := make([]byte, zeros+length)
+	defer Wipe(out)
+	for i := 0; i < zeros; i++ {
+		out[i] = base58Alphabet[0]
+	}
+	for i, d := range digits[len(digits)-length:] {
+		out[zeros+i] = base58Alphabet[d]
+	}
+	return string(out)
+}
+
+// base58Decode decodes a Base58 string encoded by base58Encode
+func base58Decode(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	// Each digit takes log(58)/log(256) ~ 0.733 bytes
+	buf := make([]byte, (len(s)-zeros)*733/1000+1)
+	defer Wipe(buf)
+	length := 0
+	for i := zeros; i < len(s); i++ {
+		carry := indexBase58(s[i])
+		if carry < 0 {
+			return nil, &Error{
+				Err: ErrInvalidBase58,
+				Msg: fmt.Sprintf("invalid character %q at offset %d", s[i], i),
+			}
+		}
+		n := 0
+		for j := len(buf) - 1; (carry != 0 || n < length) && j >= 0; j-- {
+			carry += 58 * int(buf[j])
+			buf[j] = byte(carry)
+			carry >>= 8
+			n++
+		}
+		length = n
+	}
+
+	out := make([]byte, zeros+length)
+	copy(out[zeros:], buf[len(buf)-length:])
+	return out, nil
+}
+
+// indexBase58 returns the value of a Base58 digit, or -1 if c is not one
+func indexBase58(c byte) int {
+	for i := 0; i < len(base58Alphabet); i++ {
+		if base58Alphabet[i] == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,139 @@
+package blschia_test
+
+import (
+	"strings"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestExtendedPublicKeyBase58(t *testing.T) {
+	xpub, _ := bls.ExtendedPublicKeyFromBytes(xpubBytes)
+
+	tests := []struct {
+		net      bls.Network
+		expected string
+	}{
+		{bls.Mainnet, "bpub1LdAJKTFWJgi5YFks4jZjvwj85jvckhUHDxkh12HmaJ71jBUaX9JuDNDVh9y7yh3QM8NT66xRcnoPRSWDmovDjADcZhMJyQii8Jj7xZjdNFYuxsGfpmB4HiEdRgEYKkj"},
+		{bls.Testnet, "tpub1Y53i8qjDhpztHaZrZDSGXY8S11au4Vq1C7Wnf6VeVySEQbncYpztSgjv37Gm7yTz6cVWK1nro5qeWiprnwCu786iyGAsv4xV2XKi7vMBJxBLqQuFnSsQ42SCSExt2XE"},
+	}
+	for _, tt := range tests {
+		s, err := xpub.EncodeBase58(tt.net)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.net.Name, err)
+		}
+		if s != tt.expected {
+			t.Errorf("got %s, expected %s", s, tt.expected)
+		}
+
+		decoded, net, err := bls.ExtendedPublicKeyFromBase58(s)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.net.Name, err)
+		}
+		if net != tt.net {
+			t.Errorf("got network %v, expected %v", net, tt.net)
+		}
+		if !decoded.Equal(xpub) {
+			t.Errorf("%s: decoded key should equal the original", tt.net.Name)
+		}
+	}
+}
+
+func TestExtendedPrivateKeyBase58(t *testing.T) {
+	xprv := bls.ExtendedPrivateKeyFromSeed(xprvSeed)
+	child := xprv.PrivateChild(bls.HardenedOffset + 12381)
+
+	for _, key := range []bls.ExtendedPrivateKey{xprv, child} {
+		for _, tt := range []struct {
+			net    bls.Network
+			prefix string
+		}{{bls.Mainnet, "bprv"}, {bls.Testnet, "tprv"}} {
+			s, err := key.EncodeBase58(tt.net)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(s, tt.prefix) || len(s) != 110 {
+				t.Errorf("got %s, expected 110 characters starting with %s", s, tt.prefix)
+			}
+
+			decoded, net, err := bls.ExtendedPrivateKeyFromBase58(s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if net != tt.net {
+				t.Errorf("got network %v, expected %v", net, tt.net)
+			}
+			if !decoded.Equal(key) {
+				t.Error("decoded key should equal the original")
+			}
+			decoded.Free()
+		}
+	}
+
+	// Private and public keys can't be mixed up
+	s, _ := xprv.EncodeBase58(bls.Mainnet)
+	_, _, err := bls.ExtendedPublicKeyFromBase58(s)
+	expectError(t, err, bls.ErrInvalidPublicKey)
+	s, _ = xprv.GetExtendedPublicKey().EncodeBase58(bls.Mainnet)
+	_, _, err = bls.ExtendedPrivateKeyFromBase58(s)
+	expectError(t, err, bls.ErrInvalidPrivateKey)
+
+	child.Free()
+	xprv.Free()
+}
+
+func TestBase58Invalid(t *testing.T) {
+	xpub, _ := bls.ExtendedPublicKeyFromBytes(xpubBytes)
+	s, _ := xpub.EncodeBase58(bls.Mainnet)
+
+	// A typo in any character is caught by the checksum
+	for _, i := range []int{4, 60, len(s) - 1} {
+		typo := []byte(s)
+		if typo[i] == 'z' {
+			typo[i] = 'y'
+		} else {
+			typo[i] = 'z'
+		}
+		_, _, err := bls.ExtendedPublicKeyFromBase58(string(typo))
+		expectError(t, err, bls.ErrChecksumMismatch)
+	}
+
+	for _, invalid := range []string{"", "bpub", s[:10] + "0" + s[11:], s[:10] + "l" + s[11:], s + " "} {
+		_, _, err := bls.ExtendedPublicKeyFromBase58(invalid)
+		expectError(t, err, bls.ErrInvalidBase58)
+	}
+
+	// A valid encoding, but for a network which was never registered
+	other := bls.Network{Name: "other", PrivateVersion: 0xdeadbeef, PublicVersion: 0xfeedface}
+	s, _ = xpub.EncodeBase58(other)
+	_, _, err := bls.ExtendedPublicKeyFromBase58(s)
+	expectError(t, err, bls.ErrUnknownNetwork)
+}
+
+func TestRegisterNetwork(t *testing.T) {
+	xpub, _ := bls.ExtendedPublicKeyFromBytes(xpubBytes)
+
+	expectError(t, bls.RegisterNetwork(bls.Network{Name: "dup", PrivateVersion: bls.Mainnet.PublicVersion, PublicVersion: 1}), bls.ErrUnknownNetwork)
+	expectError(t, bls.RegisterNetwork(bls.Network{Name: "same", PrivateVersion: 2, PublicVersion: 2}), bls.ErrUnknownNetwork)
+
+	regtest := bls.Network{Name: "regtest", PrivateVersion: 0x01020304, PublicVersion: 0x05060708}
+	if err := bls.RegisterNetwork(regtest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, _ := xpub.EncodeBase58(regtest)
+	decoded, net, err := bls.ExtendedPublicKeyFromBase58(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if net != regtest || !decoded.Equal(xpub) {
+		t.Errorf("got %v on %v, expected the original key on %v", decoded, net, regtest)
+	}
+
+	// The version field is replaced by the prefix, so other versions can't
+	// be encoded
+	other := append([]byte{}, xpubBytes...)
+	other[3] = 2
+	xpub2, _ := bls.ExtendedPublicKeyFromBytes(other)
+	_, err = xpub2.EncodeBase58(bls.Mainnet)
+	expectError(t, err, bls.ErrInvalidPublicKey)
+}
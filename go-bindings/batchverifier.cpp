@@ -0,0 +1,121 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+#include "batchverifier.h"
+#include <map>
+#include <stdexcept>
+#include <vector>
+#include "bls.hpp"
+#include "curve.h"
+
+// CBatchVerify checks all signatures against their aggregation infos at once.
+// Each signature is multiplied by its random scalar r, and so is every
+// exponent of its aggregation info, so that a single multi-pairing checks
+//   e(g1, sum r*sig) == prod e(sum r*exponent*pk, H(msg))
+// Public keys of identical messages are summed first, so there is one pairing
+// per distinct message plus one. Every signature and public key is checked to
+// be in its subgroup first, since a point of small order could otherwise be
+// cancelled by the scalars.
+bool CBatchVerify(void **signatures, void **aggregationInfos, size_t len,
+    uint8_t *scalars, size_t scalarLen) {
+    // Find the distinct messages
+    std::map<const uint8_t*, size_t, bls::Util::BytesCompare32> hashIndex;
+    for (size_t i = 0; i < len; i++) {
+        bls::AggregationInfo* ai = (bls::AggregationInfo*)aggregationInfos[i];
+        if (ai->Empty()) {
+            return false;
+        }
+        for (const uint8_t* hash : ai->GetMessageHashes()) {
+            hashIndex.emplace(hash, hashIndex.size() + 1);
+        }
+    }
+
+    size_t numPairings = hashIndex.size() + 1;
+    g1_t *pubKeys = new g1_t[numPairings];
+    g2_t *mappedHashes = new g2_t[numPairings];
+    for (size_t k = 0; k < numPairings; k++) {
+        g1_set_infty(pubKeys[k]);
+        g2_set_infty(mappedHashes[k]);
+    }
+
+    bn_t ord, r, exponent;
+    bn_new(ord);
+    bn_new(r);
+    bn_new(exponent);
+    g1_get_ord(ord);
+    g1_t pk;
+    g2_t sig;
+
+    bool result = false;
+    try {
+        for (size_t i = 0; i < len; i++) {
+            bls::InsecureSignature* insecureSig =
+                (bls::InsecureSignature*)signatures[i];
+            bls::AggregationInfo* ai =
+                (bls::AggregationInfo*)aggregationInfos[i];
+
+            bn_read_bin(r, scalars + i * scalarLen, scalarLen);
+            ReadInsecureSignature(sig, *insecureSig);
+            if (!G2InSubgroup(sig)) {
+                throw std::invalid_argument("signature not in subgroup");
+            }
+            g2_mul(sig, sig, r);
+            g2_add(mappedHashes[0], mappedHashes[0], sig);
+
+            std::vector<bls::PublicKey> keys = ai->GetPubKeys();
+            std::vector<uint8_t*> hashes = ai->GetMessageHashes();
+            for (size_t j = 0; j < keys.size(); j++) {
+                ai->GetExponent(&exponent, hashes[j], keys[j]);
+                bn_mul(exponent, exponent, r);
+                bn_mod(exponent, exponent, ord);
+                ReadPublicKey(pk, keys[j]);
+                if (!G1InSubgroup(pk)) {
+                    throw std::invalid_argument("public key not in subgroup");
+                }
+                g1_mul(pk, pk, exponent);
+                size_t k = hashIndex.at(hashes[j]);
+                g1_add(pubKeys[k], pubKeys[k], pk);
+            }
+        }
+
+        for (const auto &kv : hashIndex) {
+            g2_map(mappedHashes[kv.second], kv.first,
+                bls::BLS::MESSAGE_HASH_LEN, 0);
+        }
+
+        // e(-g1, sum r*sig) * prod e(sum r*exponent*pk, H(msg)) =? 1
+        g1_get_gen(pubKeys[0]);
+        g1_neg(pubKeys[0], pubKeys[0]);
+        g1_norm(pubKeys[0], pubKeys[0]);
+        for (size_t k = 1; k < numPairings; k++) {
+            g1_norm(pubKeys[k], pubKeys[k]);
+        }
+        g2_norm(mappedHashes[0], mappedHashes[0]);
+
+        gt_t candidate;
+        pc_map_sim(candidate, pubKeys, mappedHashes, numPairings);
+        result = gt_is_unity(candidate) && core_get()->code == STS_OK;
+    } catch (const std::exception&) {
+        // a malformed entry makes the batch invalid
+        result = false;
+    }
+    core_get()->code = STS_OK;
+
+    bn_free(exponent);
+    bn_free(r);
+    bn_free(ord);
+    delete[] pubKeys;
+    delete[] mappedHashes;
+
+    return result;
+}
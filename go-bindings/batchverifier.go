@@ -0,0 +1,158 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdbool.h>
+// #include <stdlib.h>
+// #include "batchverifier.h"
+// #include "blschia.h"
+import "C"
+import (
+	"crypto/rand"
+	"runtime"
+	"unsafe"
+)
+
+// batchScalarSize is the size in bytes of the random scalars used to combine
+// signatures. A forged signature passes a batch with probability 2^-128.
+const batchScalarSize = 16
+
+// BatchVerifier verifies many independent signatures at once. Rather than
+// doing two pairings per signature, it combines all of them with random
+// scalars and checks the result with a single multi-pairing, doing one
+// pairing per distinct message plus one.
+//
+// If the combined check fails, the entries are bisected to find exactly which
+// of them are invalid. A BatchVerifier must not be used concurrently.
+type BatchVerifier struct {
+	sigs []InsecureSignature
+	ais  []AggregationInfo
+}
+
+// NewBatchVerifier returns an empty BatchVerifier
+func NewBatchVerifier() *BatchVerifier {
+	return &BatchVerifier{}
+}
+
+// Add queues a signature to be checked against its own aggregation info, and
+// returns the index identifying it in the result of Verify.
+func (bv *BatchVerifier) Add(sig Signature) int {
+	return bv.AddWithAggregationInfo(sig, sig.GetAggregationInfo())
+}
+
+// AddWithAggregationInfo queues a signature to be checked against ai, e.g.
+// when the two were received separately, and returns the index identifying it
+// in the result of Verify.
+func (bv *BatchVerifier) AddWithAggregationInfo(sig Signature, ai AggregationInfo) int {
+	bv.sigs = append(bv.sigs, sig.GetInsecureSig())
+	bv.ais = append(bv.ais, ai)
+	return len(bv.sigs) - 1
+}
+
+// Len returns the number of queued signatures
+func (bv *BatchVerifier) Len() int {
+	return len(bv.sigs)
+}
+
+// Verify checks all queued signatures. It returns true if all of them are
+// valid, and otherwise the indices of the invalid ones in increasing order.
+// Signatures with an empty aggregation info, and signatures or public keys
+// outside their subgroup, are invalid. An error is only returned if reading
+// the random scalars fails.
+func (bv *BatchVerifier) Verify() (bool, []int, error) {
+	if len(bv.sigs) == 0 {
+		return true, nil, nil
+	}
+	invalid, err := bv.bisect(0, len(bv.sigs), false, nil)
+	if err != nil {
+		return false, nil, err
+	}
+	return len(invalid) == 0, invalid, nil
+}
+
+// bisect appends the indices of the invalid entries in [lo, hi) to invalid.
+// If failed is set, the range is already known to contain an invalid entry.
+func (bv *BatchVerifier) bisect(lo, hi int, failed bool, invalid []int) ([]int, error) {
+	if !failed {
+		ok, err := bv.verifyRange(lo, hi)
+		if err != nil || ok {
+			return invalid, err
+		}
+	}
+	if hi-lo == 1 {
+		return append(invalid, lo), nil
+	}
+
+	mid := lo + (hi-lo)/2
+	n := len(invalid)
+	invalid, err := bv.bisect(lo, mid, false, invalid)
+	if err != nil {
+		return nil, err
+	}
+	// The range failed, so if the first half passed the second half must
+	// contain an invalid entry, and there is no need to check it as a whole
+	return bv.bisect(mid, hi, len(invalid) == n, invalid)
+}
+
+// verifyRange checks the entries in [lo, hi) with fresh random scalars
+func (bv *BatchVerifier) verifyRange(lo, hi int) (bool, error) {
+	defer runtime.KeepAlive(bv)
+
+	sigs := bv.sigs[lo:hi]
+	ais := bv.ais[lo:hi]
+
+	// Get a C pointer to an array of signatures
+	cNumSigs := C.size_t(len(sigs))
+	cSigsPtr := C.AllocPtrArray(cNumSigs)
+	defer C.FreePtrArray(cSigsPtr)
+	// Loop thru each sig and add the sig C ptr to the array of ptrs at index
+	for i, sig := range sigs {
+		C.SetPtrArray(cSigsPtr, unsafe.Pointer(sig.sig), C.int(i))
+	}
+
+	// Get a C pointer to an array of aggregation infos
+	cAIsPtr := C.AllocPtrArray(cNumSigs)
+	defer C.FreePtrArray(cAIsPtr)
+	// Loop thru each AggInfo and add the C ptr to the array of ptrs at index
+	for i, ai := range ais {
+		C.SetPtrArray(cAIsPtr, unsafe.Pointer(ai.ai), C.int(i))
+	}
+
+	// Get a C pointer to the random scalars
+	scalars, err := randomScalars(len(sigs))
+	if err != nil {
+		return false, err
+	}
+	cScalarsPtr := C.CBytes(scalars)
+	defer C.free(cScalarsPtr)
+
+	return bool(C.CBatchVerify(cSigsPtr, cAIsPtr, cNumSigs,
+		(*C.uint8_t)(cScalarsPtr), C.size_t(batchScalarSize))), nil
+}
+
+// randomScalars returns n non-zero random scalars of batchScalarSize bytes,
+// concatenated
+func randomScalars(n int) ([]byte, error) {
+	scalars := make([]byte, n*batchScalarSize)
+	if _, err := rand.Read(scalars); err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		scalar := scalars[i*batchScalarSize : (i+1)*batchScalarSize]
+		for isZero(scalar) {
+			if _, err := rand.Read(scalar); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return scalars, nil
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package blschia_test
+
+import (
+	"reflect"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestBatchVerifier(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, false)
+	m1 := []byte{1, 2, 3}
+	m2 := []byte{4, 5, 6}
+
+	bv := bls.NewBatchVerifier()
+	if ok, invalid, err := bv.Verify(); err != nil || !ok || invalid != nil {
+		t.Errorf("got %v %v %v, expected an empty batch to verify", ok, invalid, err)
+	}
+
+	aggSig, _ := bls.SignatureAggregate([]bls.Signature{
+		sk1.Sign(m1), sk2.Sign(m1), sk2.Sign(m2),
+	})
+	sigs := []bls.Signature{
+		sk1.Sign(m1),
+		sk2.Sign(m1),
+		aggSig,
+		sk1.Sign(m2),
+		sk1.Sign(payload),
+	}
+	for i, sig := range sigs {
+		if idx := bv.Add(sig); idx != i {
+			t.Errorf("got index %d, expected %d", idx, i)
+		}
+	}
+
+	// A signature received without its aggregation info
+	sig, _ := bls.SignatureFromBytes(sig1Bytes)
+	bv.AddWithAggregationInfo(sig, bls.AggregationInfoFromMsg(sk1.PublicKey(), payload))
+
+	if bv.Len() != 6 {
+		t.Errorf("got %d entries, expected 6", bv.Len())
+	}
+	if ok, invalid, err := bv.Verify(); err != nil || !ok || invalid != nil {
+		t.Errorf("got %v %v %v, expected the batch to verify", ok, invalid, err)
+	}
+
+	// Signed by the wrong key
+	bv.AddWithAggregationInfo(sk1.Sign(m2), bls.AggregationInfoFromMsg(sk2.PublicKey(), m2))
+	bv.Add(sk1.Sign(m1))
+	// Signed over the wrong message
+	bv.AddWithAggregationInfo(sk2.Sign(m1), bls.AggregationInfoFromMsg(sk2.PublicKey(), m2))
+	// No aggregation info
+	bv.Add(sig)
+	bv.Add(sk2.Sign(payload))
+
+	ok, invalid, err := bv.Verify()
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("expected the batch to fail")
+	}
+	expected := []int{6, 8, 9}
+	if !reflect.DeepEqual(invalid, expected) {
+		t.Errorf("got %v, expected %v", invalid, expected)
+	}
+
+	sig.Free()
+	aggSig.Free()
+	sk2.Free()
+	sk1.Free()
+}
+
+// Swapping the signatures of two entries keeps the sum of the signatures the
+// same, which must not fool the random linear combination.
+func TestBatchVerifierSwapped(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, false)
+
+	sig1 := sk1.Sign(payload)
+	sig2 := sk2.Sign(payload)
+
+	bv := bls.NewBatchVerifier()
+	bv.AddWithAggregationInfo(sig1, sig2.GetAggregationInfo())
+	bv.AddWithAggregationInfo(sig2, sig1.GetAggregationInfo())
+
+	ok, invalid, err := bv.Verify()
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("expected the batch to fail")
+	}
+	expected := []int{0, 1}
+	if !reflect.DeepEqual(invalid, expected) {
+		t.Errorf("got %v, expected %v", invalid, expected)
+	}
+
+	sig2.Free()
+	sig1.Free()
+	sk2.Free()
+	sk1.Free()
+}
+
+// A signature outside the G2 subgroup must be reported as invalid, even
+// though it parses as a Signature.
+func TestBatchVerifierNotInSubgroup(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+
+	// On the curve, but not in the subgroup
+	data := make([]byte, bls.G2ElementSize)
+	data[0] = 0x80
+	data[len(data)-1] = 4
+	badSig, err := bls.SignatureFromBytes(data)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	sig := sk1.Sign(payload)
+
+	bv := bls.NewBatchVerifier()
+	bv.Add(sig)
+	bv.AddWithAggregationInfo(badSig, bls.AggregationInfoFromMsg(sk1.PublicKey(), payload))
+
+	ok, invalid, err := bv.Verify()
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("expected the batch to fail")
+	}
+	expected := []int{1}
+	if !reflect.DeepEqual(invalid, expected) {
+		t.Errorf("got %v, expected %v", invalid, expected)
+	}
+
+	sig.Free()
+	badSig.Free()
+	sk1.Free()
+}
+
+func BenchmarkBatchVerifier(b *testing.B) {
+	const numSigs = 64
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	bv := bls.NewBatchVerifier()
+	sigs := make([]bls.Signature, numSigs)
+	for i := range sigs {
+		sigs[i] = sk1.Sign([]byte{byte(i)})
+		bv.Add(sigs[i])
+	}
+
+	b.Run("Batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := bv.Verify(); err != nil {
+				b.Fatalf("got unexpected error: %v", err)
+			}
+		}
+	})
+	b.Run("Individual", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, sig := range sigs {
+				sig.Verify()
+			}
+		}
+	})
+}
@@ -13,16 +13,22 @@
 // limitations under the License.
 
 #include "blschia.h"
-#include <string>
+#include <cstring>
 #include "bls.hpp"
 #include "error.h"
 
-std::string gErrMsg;
-
 void SecFree(void *p) {
     bls::Util::SecFree(p);
 }
 
+void SecWipe(void *p, size_t len) {
+    // volatile, so that the wipe isn't optimized away
+    volatile uint8_t *bytes = static_cast<uint8_t*>(p);
+    for (size_t i = 0; i < len; i++) {
+        bytes[i] = 0;
+    }
+}
+
 void** AllocPtrArray(size_t len) {
     // caller to free
     return static_cast<void**>(malloc(sizeof(void*) * len));
@@ -44,6 +50,12 @@ void* GetAddressAtIndex(uint8_t *ptr, int index) {
     return static_cast<void*>(&ptr[index]);
 }
 
-const char* GetLastErrorMsg() {
-    return gErrMsg.c_str();
+void SetErrorMsg(char **errMsg, const std::exception& ex) {
+    // caller to free
+    *errMsg = strdup(ex.what());
+}
+
+void SetErrorMsg(char **errMsg, const std::string& ex) {
+    // caller to free
+    *errMsg = strdup(ex.c_str());
 }
@@ -16,11 +16,18 @@
 #define GO_BINDINGS_BLSCHIA_H_
 #include <stdbool.h>
 #include "aggregationinfo.h"
+#include "batchverifier.h"
 #include "chaincode.h"
+#include "element.h"
 #include "extendedprivatekey.h"
 #include "extendedpublickey.h"
+#include "hashtocurve.h"
+#include "msm.h"
+#include "pairing.h"
 #include "publickey.h"
 #include "privatekey.h"
+#include "scheme.h"
+#include "securealloc.h"
 #include "signature.h"
 #include "threshold.h"
 
@@ -31,6 +38,9 @@ extern "C" {
 // Export the BLS SecFree method
 void SecFree(void *p);
 
+// Overwrite memory which held secret data with zeros
+void SecWipe(void *p, size_t len);
+
 // Additional C++ helper funcs for allocations
 void** AllocPtrArray(size_t len);
 void SetPtrArray(void **arrPtr, void *elemPtr, int index);
@@ -39,8 +49,6 @@ void* GetPtrAtIndex(void **arrPtr, int index);
 
 void* GetAddressAtIndex(uint8_t *ptr, int index);
 
-const char* GetLastErrorMsg();
-
 #ifdef __cplusplus
 }
 #endif
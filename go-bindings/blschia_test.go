@@ -1,9 +1,22 @@
 package blschia_test
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+	"runtime"
+	"time"
+)
 
 // Sha256 is a test helper method for getting the sha256 hash of a byte slice
 func Sha256(payload []byte) []byte {
 	temp := sha256.Sum256(payload)
 	return temp[:]
 }
+
+// collectGarbage runs the garbage collector a few times, giving finalizers of
+// unreachable objects the chance to run
+func collectGarbage() {
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
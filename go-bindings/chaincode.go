@@ -10,35 +10,122 @@ import "runtime"
 
 // ChainCode is used in extended keys to derive child keys
 type ChainCode struct {
+	*chainCode
+}
+
+// chainCode owns the C object. It is shared by all copies of the ChainCode, so
+// the finalizer only runs once none of them is reachable.
+type chainCode struct {
 	cc C.CChainCode
 }
 
+func newChainCode(ptr C.CChainCode) ChainCode {
+	cc := &chainCode{cc: ptr}
+	runtime.SetFinalizer(cc, (*chainCode).free)
+	return ChainCode{cc}
+}
+
+func (cc *chainCode) free() {
+	if cc.cc != nil {
+		C.CChainCodeFree(cc.cc)
+		cc.cc = nil
+	}
+}
+
 // ChainCodeFromBytes creates an ChainCode object given a byte slice
-func ChainCodeFromBytes(data []byte) ChainCode {
+func ChainCodeFromBytes(data []byte) (ChainCode, error) {
+	if err := checkLength("chain code", data, int(C.CChainCodeSizeBytes())); err != nil {
+		return ChainCode{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
 	defer C.free(cBytesPtr)
 
-	var cc ChainCode
-	cc.cc = C.CChainCodeFromBytes(cBytesPtr)
-	runtime.SetFinalizer(&cc, func(p *ChainCode) { p.Free() })
-
-	return cc
+	return newChainCode(C.CChainCodeFromBytes(cBytesPtr)), nil
 }
 
 // Serialize returns the serialized byte representation of the ChainCode object
 func (cc ChainCode) Serialize() []byte {
+	defer runtime.KeepAlive(cc)
 	ptr := C.CChainCodeSerialize(cc.cc)
 	defer C.free(ptr)
 	return C.GoBytes(ptr, C.CChainCodeSizeBytes())
 }
 
-// Free releases memory allocated by the ChainCode object
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize. The zero value can't be encoded, so it returns an
+// ErrZeroValue error.
+func (cc ChainCode) MarshalBinary() ([]byte, error) {
+	if cc.chainCode == nil {
+		return nil, errZeroValue("ChainCode")
+	}
+	return cc.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by ChainCodeFromBytes.
+func (cc *ChainCode) UnmarshalBinary(data []byte) error {
+	parsed, err := ChainCodeFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*cc = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the chain code as hex.
+func (cc ChainCode) MarshalText() ([]byte, error) {
+	data, err := cc.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return marshalHex(data), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// chain code.
+func (cc *ChainCode) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return cc.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the chain code as a hex string.
+// The zero value is encoded as null.
+func (cc ChainCode) MarshalJSON() ([]byte, error) {
+	if cc.chainCode == nil {
+		return jsonNull, nil
+	}
+	return marshalJSON(cc.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the chain code unchanged.
+func (cc *ChainCode) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return cc.UnmarshalText(text)
+}
+
+// Free releases memory allocated by the ChainCode object. All copies of the
+// ChainCode object share that memory, so none of them may be used afterwards.
+// Calling Free again is a no-op.
 func (cc ChainCode) Free() {
-	C.CChainCodeFree(cc.cc)
+	if cc.chainCode == nil {
+		return
+	}
+	runtime.SetFinalizer(cc.chainCode, nil)
+	cc.free()
 }
 
 // Equal tests if one ChainCode object is equal to another
 func (cc ChainCode) Equal(other ChainCode) bool {
+	defer runtime.KeepAlive(cc)
+	defer runtime.KeepAlive(other)
 	return bool(C.CChainCodeIsEqual(cc.cc, other.cc))
 }
@@ -0,0 +1,380 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+#include "curve.h"
+#include <cstring>
+#include <stdexcept>
+
+namespace {
+
+const uint8_t COMPRESSION_FLAG = 0x80;
+const uint8_t INFINITY_FLAG = 0x40;
+const uint8_t SIGN_FLAG = 0x20;
+const uint8_t FLAGS = COMPRESSION_FLAG | INFINITY_FLAG | SIGN_FLAG;
+
+bool IsZeroBytes(const uint8_t *in, size_t len) {
+    uint8_t acc = 0;
+    for (size_t i = 0; i < len; i++) {
+        acc |= in[i];
+    }
+    return acc == 0;
+}
+
+// ReadFp reads a big-endian field element, which must be smaller than p.
+// fp_read_bin would silently reduce it, accepting several encodings of the
+// same point.
+void ReadFp(fp_t a, const uint8_t *in) {
+    bn_t value, prime;
+    bn_new(value);
+    bn_new(prime);
+    bn_read_bin(value, in, FP_BYTES);
+    bn_read_raw(prime, fp_prime_get(), FP_DIGS);
+    bool canonical = bn_cmp(value, prime) == CMP_LT;
+    bn_free(prime);
+    bn_free(value);
+    if (!canonical) {
+        throw std::invalid_argument("coordinate is not smaller than p");
+    }
+    fp_read_bin(a, in, FP_BYTES);
+}
+
+// DecodeFlags checks the flag bits of an encoding of len bytes, where size is
+// the length of the compressed form. It returns true for the point at
+// infinity.
+bool DecodeFlags(const uint8_t *in, size_t len, size_t size) {
+    if (len != size && len != 2 * size) {
+        throw std::invalid_argument("encoding has the wrong length");
+    }
+    bool compressed = (in[0] & COMPRESSION_FLAG) != 0;
+    if (compressed != (len == size)) {
+        throw std::invalid_argument("compression flag does not match length");
+    }
+    if (in[0] & INFINITY_FLAG) {
+        if (in[0] & ~(COMPRESSION_FLAG | INFINITY_FLAG) ||
+            !IsZeroBytes(in + 1, len - 1)) {
+            throw std::invalid_argument("point at infinity is not all zeros");
+        }
+        return true;
+    }
+    if (!compressed && (in[0] & SIGN_FLAG)) {
+        throw std::invalid_argument("sign flag set on uncompressed point");
+    }
+    return false;
+}
+
+}  // namespace
+
+bool FpSqrt(fp_t c, const fp_t a) {
+    // fp_srt checks that the result squares to a
+    return fp_srt(c, a) != 0;
+}
+
+bool Fp2Sqrt(fp2_t c, fp2_t a) {
+    if (fp_is_zero(a[1])) {
+        // fp2_srt divides by zero here when a[0] is not a square in Fp. Then
+        // -a[0] is, since p = 3 mod 4, and its root times i is the answer.
+        fp_t t;
+        if (FpSqrt(t, a[0])) {
+            fp_copy(c[0], t);
+            fp_zero(c[1]);
+            return true;
+        }
+        fp_neg(t, a[0]);
+        if (!FpSqrt(t, t)) {
+            return false;
+        }
+        fp_zero(c[0]);
+        fp_copy(c[1], t);
+        return true;
+    }
+
+    fp2_t root, check;
+    if (!fp2_srt(root, a)) {
+        return false;
+    }
+    fp2_sqr(check, root);
+    if (fp2_cmp(check, a) != CMP_EQ) {
+        return false;
+    }
+    fp2_copy(c, root);
+    return true;
+}
+
+bool FpIsLarge(const fp_t a) {
+    fp_t neg;
+    bn_t value, negValue;
+    bn_new(value);
+    bn_new(negValue);
+    fp_neg(neg, a);
+    fp_prime_back(value, a);
+    fp_prime_back(negValue, neg);
+    bool large = bn_cmp(value, negValue) == CMP_GT;
+    bn_free(negValue);
+    bn_free(value);
+    return large;
+}
+
+bool Fp2IsLarge(fp2_t a) {
+    if (fp_is_zero(a[1])) {
+        return FpIsLarge(a[0]);
+    }
+    return FpIsLarge(a[1]);
+}
+
+void ReadG1Element(g1_t point, const uint8_t *in) {
+    if (IsZeroBytes(in, G1_ELEMENT_SIZE)) {
+        g1_set_infty(point);
+        return;
+    }
+    fp_read_bin(point->x, in, FP_BYTES);
+    fp_read_bin(point->y, in + FP_BYTES, FP_BYTES);
+    fp_set_dig(point->z, 1);
+    point->norm = 1;
+}
+
+void WriteG1Element(uint8_t *out, g1_t point) {
+    if (g1_is_infty(point)) {
+        std::memset(out, 0, G1_ELEMENT_SIZE);
+        return;
+    }
+    g1_t norm;
+    g1_norm(norm, point);
+    fp_write_bin(out, FP_BYTES, norm->x);
+    fp_write_bin(out + FP_BYTES, FP_BYTES, norm->y);
+}
+
+void ReadG2Element(g2_t point, const uint8_t *in) {
+    if (IsZeroBytes(in, G2_ELEMENT_SIZE)) {
+        g2_set_infty(point);
+        return;
+    }
+    fp_read_bin(point->x[1], in, FP_BYTES);
+    fp_read_bin(point->x[0], in + FP_BYTES, FP_BYTES);
+    fp_read_bin(point->y[1], in + 2 * FP_BYTES, FP_BYTES);
+    fp_read_bin(point->y[0], in + 3 * FP_BYTES, FP_BYTES);
+    fp2_set_dig(point->z, 1);
+    point->norm = 1;
+}
+
+void WriteG2Element(uint8_t *out, g2_t point) {
+    if (g2_is_infty(point)) {
+        std::memset(out, 0, G2_ELEMENT_SIZE);
+        return;
+    }
+    g2_t norm;
+    g2_norm(norm, point);
+    fp_write_bin(out, FP_BYTES, norm->x[1]);
+    fp_write_bin(out + FP_BYTES, FP_BYTES, norm->x[0]);
+    fp_write_bin(out + 2 * FP_BYTES, FP_BYTES, norm->y[1]);
+    fp_write_bin(out + 3 * FP_BYTES, FP_BYTES, norm->y[0]);
+}
+
+void ReadGTElement(gt_t a, const uint8_t *in) {
+    if (IsZeroBytes(in, GT_ELEMENT_SIZE)) {
+        gt_set_unity(a);
+        return;
+    }
+    gt_read_bin(a, const_cast<uint8_t*>(in), GT_ELEMENT_SIZE);
+}
+
+void WriteGTElement(uint8_t *out, gt_t a) {
+    if (gt_is_unity(a)) {
+        std::memset(out, 0, GT_ELEMENT_SIZE);
+        return;
+    }
+    gt_write_bin(out, GT_ELEMENT_SIZE, a, 0);
+}
+
+void ReadPublicKey(g1_t point, const bls::PublicKey& key) {
+    g1_t infinity;
+    g1_set_infty(infinity);
+    if (key == bls::PublicKey::FromG1(&infinity)) {
+        // The library can't serialize the point at infinity
+        g1_set_infty(point);
+        return;
+    }
+    uint8_t uncompressed[bls::PublicKey::PUBLIC_KEY_SIZE + 1];
+    key.Serialize(uncompressed + 1);
+    if (uncompressed[1] & 0x80) {
+        uncompressed[0] = 0x03;
+        uncompressed[1] &= 0x7f;
+    } else {
+        uncompressed[0] = 0x02;
+    }
+    g1_read_bin(point, uncompressed, bls::PublicKey::PUBLIC_KEY_SIZE + 1);
+}
+
+void ReadInsecureSignature(g2_t point, const bls::InsecureSignature& sig) {
+    g2_t infinity;
+    g2_set_infty(infinity);
+    if (sig == bls::InsecureSignature::FromG2(&infinity)) {
+        g2_set_infty(point);
+        return;
+    }
+    uint8_t uncompressed[bls::InsecureSignature::SIGNATURE_SIZE + 1];
+    sig.Serialize(uncompressed + 1);
+    if (uncompressed[1] & 0x80) {
+        uncompressed[0] = 0x03;
+        uncompressed[1] &= 0x7f;
+    } else {
+        uncompressed[0] = 0x02;
+    }
+    g2_read_bin(point, uncompressed,
+        bls::InsecureSignature::SIGNATURE_SIZE + 1);
+}
+
+void DecodeG1(g1_t point, const uint8_t *in, size_t len) {
+    if (DecodeFlags(in, len, FP_BYTES)) {
+        g1_set_infty(point);
+        return;
+    }
+    uint8_t buf[FP_BYTES];
+    std::memcpy(buf, in, FP_BYTES);
+    buf[0] &= ~FLAGS;
+    ReadFp(point->x, buf);
+    fp_set_dig(point->z, 1);
+    point->norm = 1;
+
+    fp_t rhs, y2;
+    ep_rhs(rhs, point);
+    if (len == FP_BYTES) {
+        if (!FpSqrt(point->y, rhs)) {
+            throw std::invalid_argument("point is not on the curve");
+        }
+        if (FpIsLarge(point->y) != ((in[0] & SIGN_FLAG) != 0)) {
+            fp_neg(point->y, point->y);
+        }
+        return;
+    }
+    ReadFp(point->y, in + FP_BYTES);
+    fp_sqr(y2, point->y);
+    if (fp_cmp(y2, rhs) != CMP_EQ) {
+        throw std::invalid_argument("point is not on the curve");
+    }
+}
+
+void DecodeG2(g2_t point, const uint8_t *in, size_t len) {
+    if (DecodeFlags(in, len, 2 * FP_BYTES)) {
+        g2_set_infty(point);
+        return;
+    }
+    uint8_t buf[FP_BYTES];
+    std::memcpy(buf, in, FP_BYTES);
+    buf[0] &= ~FLAGS;
+    ReadFp(point->x[1], buf);
+    ReadFp(point->x[0], in + FP_BYTES);
+    fp2_set_dig(point->z, 1);
+    point->norm = 1;
+
+    fp2_t rhs, y2;
+    ep2_rhs(rhs, point);
+    if (len == 2 * FP_BYTES) {
+        if (!Fp2Sqrt(point->y, rhs)) {
+            throw std::invalid_argument("point is not on the curve");
+        }
+        if (Fp2IsLarge(point->y) != ((in[0] & SIGN_FLAG) != 0)) {
+            fp2_neg(point->y, point->y);
+        }
+        return;
+    }
+    ReadFp(point->y[1], in + 2 * FP_BYTES);
+    ReadFp(point->y[0], in + 3 * FP_BYTES);
+    fp2_sqr(y2, point->y);
+    if (fp2_cmp(y2, rhs) != CMP_EQ) {
+        throw std::invalid_argument("point is not on the curve");
+    }
+}
+
+void DecodeGT(gt_t a, const uint8_t *in) {
+    // Check that every coefficient is canonical
+    fp_t coeff;
+    for (size_t i = 0; i < GT_ELEMENT_SIZE; i += FP_BYTES) {
+        ReadFp(coeff, in + i);
+    }
+    gt_read_bin(a, const_cast<uint8_t*>(in), GT_ELEMENT_SIZE);
+    if (!GTInGroup(a)) {
+        throw std::invalid_argument("value is not an element of GT");
+    }
+}
+
+void CompressG1(uint8_t *out, g1_t point) {
+    if (g1_is_infty(point)) {
+        std::memset(out, 0, FP_BYTES);
+        out[0] = COMPRESSION_FLAG | INFINITY_FLAG;
+        return;
+    }
+    g1_t norm;
+    g1_norm(norm, point);
+    fp_write_bin(out, FP_BYTES, norm->x);
+    out[0] |= COMPRESSION_FLAG;
+    if (FpIsLarge(norm->y)) {
+        out[0] |= SIGN_FLAG;
+    }
+}
+
+void CompressG2(uint8_t *out, g2_t point) {
+    if (g2_is_infty(point)) {
+        std::memset(out, 0, 2 * FP_BYTES);
+        out[0] = COMPRESSION_FLAG | INFINITY_FLAG;
+        return;
+    }
+    g2_t norm;
+    g2_norm(norm, point);
+    fp_write_bin(out, FP_BYTES, norm->x[1]);
+    fp_write_bin(out + FP_BYTES, FP_BYTES, norm->x[0]);
+    out[0] |= COMPRESSION_FLAG;
+    if (Fp2IsLarge(norm->y)) {
+        out[0] |= SIGN_FLAG;
+    }
+}
+
+bool G1InSubgroup(g1_t point) {
+    g1_t check;
+    bn_t ord;
+    bn_new(ord);
+    g1_get_ord(ord);
+    ep_mul_basic(check, point, ord);
+    bn_free(ord);
+    return g1_is_infty(check);
+}
+
+bool G2InSubgroup(g2_t point) {
+    g2_t check;
+    bn_t ord;
+    bn_new(ord);
+    g2_get_ord(ord);
+    ep2_mul_basic(check, point, ord);
+    bn_free(ord);
+    return g2_is_infty(check);
+}
+
+// gt_exp reduces the exponent mod the group order and fp12_exp may use the
+// cyclotomic endomorphism, so the order check uses plain square-and-multiply
+bool GTInGroup(gt_t a) {
+    if (fp12_cmp_dig(a, 0) == CMP_EQ) {
+        return false;
+    }
+    bn_t ord;
+    bn_new(ord);
+    gt_get_ord(ord);
+    fp12_t check;
+    fp12_copy(check, a);
+    for (int i = bn_bits(ord) - 2; i >= 0; i--) {
+        fp12_sqr(check, check);
+        if (bn_get_bit(ord, i)) {
+            fp12_mul(check, check, a);
+        }
+    }
+    bn_free(ord);
+    return fp12_cmp_dig(check, 1) == CMP_EQ;
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#ifndef GO_BINDINGS_CURVE_H_
+#define GO_BINDINGS_CURVE_H_
+#include <stddef.h>
+#include <stdint.h>
+#include "bls.hpp"
+
+// Helpers shared by the glue for the IETF signature schemes, which works with
+// relic points directly rather than with the library's key and signature
+// classes.
+//
+// G1Element and G2Element values hold the uncompressed encoding of a point
+// without any flag bits, i.e. x || y for G1 and x_c1 || x_c0 || y_c1 || y_c0
+// for G2, with all zeros standing for the point at infinity. Those bytes were
+// validated when the value was created, so they are read back without checks.
+const size_t G1_ELEMENT_SIZE = 96;
+const size_t G2_ELEMENT_SIZE = 192;
+
+// GTElement values hold the Fp12 coefficients as written by gt_write_bin,
+// with all zeros standing for the identity, which is not in GT otherwise
+const size_t GT_ELEMENT_SIZE = 576;
+
+// Scalars are big-endian and smaller than the group order
+const size_t SCALAR_SIZE = 32;
+
+void ReadG1Element(g1_t point, const uint8_t *in);
+void WriteG1Element(uint8_t *out, g1_t point);
+void ReadG2Element(g2_t point, const uint8_t *in);
+void WriteG2Element(uint8_t *out, g2_t point);
+void ReadGTElement(gt_t a, const uint8_t *in);
+void WriteGTElement(uint8_t *out, gt_t a);
+
+// PublicKey and InsecureSignature don't expose their points, so these
+// decompress the serialized values the same way their FromBytes methods do
+void ReadPublicKey(g1_t point, const bls::PublicKey& key);
+void ReadInsecureSignature(g2_t point, const bls::InsecureSignature& sig);
+
+// Decode the compressed or uncompressed encoding of the IETF draft and the
+// ZCash spec. They throw std::invalid_argument if the bytes are malformed or
+// the point is not on the curve, but don't check the subgroup.
+void DecodeG1(g1_t point, const uint8_t *in, size_t len);
+void DecodeG2(g2_t point, const uint8_t *in, size_t len);
+
+// DecodeGT reads GT_ELEMENT_SIZE bytes written by gt_write_bin. It throws
+// std::invalid_argument unless the value is an element of GT.
+void DecodeGT(gt_t a, const uint8_t *in);
+
+// Encode a point in compressed form
+void CompressG1(uint8_t *out, g1_t point);
+void CompressG2(uint8_t *out, g2_t point);
+
+// The endomorphism based multiplication assumes points are in the subgroup,
+// so these use plain double-and-add
+bool G1InSubgroup(g1_t point);
+bool G2InSubgroup(g2_t point);
+bool GTInGroup(gt_t a);
+
+// Multi-scalar multiplication, sum scalars[i] * points[i], where scalars holds
+// len scalars of SCALAR_SIZE bytes
+void G1MultiScalarMul(g1_t out, g1_t *points, const uint8_t *scalars,
+    size_t len);
+void G2MultiScalarMul(g2_t out, g2_t *points, const uint8_t *scalars,
+    size_t len);
+
+// MultiPairing computes prod e(p[i], q[i]) with a single final
+// exponentiation. Pairs with the point at infinity are skipped.
+void MultiPairing(gt_t r, g1_t *p, g2_t *q, size_t len);
+
+// Square roots return false if a is not a square
+bool FpSqrt(fp_t c, const fp_t a);
+bool Fp2Sqrt(fp2_t c, fp2_t a);
+
+// Whether a is larger than -a, which selects the root in compressed points
+bool FpIsLarge(const fp_t a);
+bool Fp2IsLarge(fp2_t a);
+
+// hash_to_curve and encode_to_curve of RFC 9380 for the suites
+// BLS12381G1_XMD:SHA-256_SSWU_RO_ and BLS12381G2_XMD:SHA-256_SSWU_RO_, and
+// their NU_ variants
+void HashToG1(g1_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen);
+void EncodeToG1(g1_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen);
+void HashToG2(g2_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen);
+void EncodeToG2(g2_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen);
+
+#endif  // GO_BINDINGS_CURVE_H_
@@ -0,0 +1,453 @@
+// Package dkg implements the Joint-Feldman distributed key generation
+// protocol on top of the BLS bindings.
+//
+// Each of the N participants deals a random polynomial of degree T-1 with
+// Feldman commitments, and checks the shares it receives from the others.
+// Participants complain about dealers whose shares are missing or invalid,
+// and a dealer stays qualified only if it publicly justifies every complaint
+// with a valid share. The qualified polynomials are summed, so that any T
+// participants can sign for the group public key with the threshold
+// functions of the bindings, while no T-1 of them learn anything about the
+// group secret key.
+//
+// Participants are indexed from 1 to N, like the players of
+// blschia.ThresholdSignWithCoefficient.
+package dkg
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+var (
+	// ErrWrongRound is returned when the steps of a Participant are called
+	// out of order.
+	ErrWrongRound = errors.New("step called out of order")
+
+	// ErrNoValidShare is returned by Finish when a qualified dealer gave this
+	// participant no valid share, which can only happen if the transport
+	// doesn't deliver the same broadcasts to every participant.
+	ErrNoValidShare = errors.New("no valid share from a qualified dealer")
+)
+
+// step is the state of a Participant, i.e. the next step it expects
+type step int
+
+const (
+	stepDeal step = iota
+	stepComplain
+	stepJustify
+	stepFinish
+	stepDone
+)
+
+// Config holds the parameters of a Participant
+type Config struct {
+	// Index is the index of the participant, from 1 to N
+	Index int
+	// T is the threshold, the number of shares needed to sign
+	T int
+	// N is the number of participants
+	N int
+	// Rand is the source of the secret polynomial. It defaults to
+	// crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// Participant runs the protocol for one participant. The steps Deal,
+// Complain, Justify and Finish must be called in that order, with the
+// messages received in the previous round, or Run can drive them through a
+// Transport.
+type Participant struct {
+	index, t, n int
+	rand        io.Reader
+	step        step
+
+	// poly holds the coefficients of this dealer's polynomial
+	poly []bls.Fr
+	// commitments holds the commitments of the dealers which are not
+	// disqualified, by dealer index
+	commitments map[int][]bls.G1Element
+	// shares holds the valid shares received, by dealer index
+	shares map[int]bls.Fr
+	// complainers holds the participants who complained about each dealer
+	complainers map[int]map[int]bool
+	// disqualified holds the dealers which are publicly known to be faulty
+	disqualified map[int]bool
+	qualified    []int
+}
+
+// NewParticipant returns a participant which hasn't dealt yet
+func NewParticipant(cfg Config) (*Participant, error) {
+	if cfg.T < 1 || cfg.T > cfg.N {
+		return nil, &bls.Error{
+			Err: bls.ErrInvalidThreshold,
+			Msg: fmt.Sprintf("T must be between 1 and N, got T=%d, N=%d", cfg.T, cfg.N),
+		}
+	}
+	if cfg.Index < 1 || cfg.Index > cfg.N {
+		return nil, &bls.Error{
+			Err: bls.ErrInvalidPlayers,
+			Msg: fmt.Sprintf("index must be between 1 and N, got %d, N=%d", cfg.Index, cfg.N),
+		}
+	}
+	rnd := cfg.Rand
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+
+	return &Participant{
+		index:        cfg.Index,
+		t:            cfg.T,
+		n:            cfg.N,
+		rand:         rnd,
+		commitments:  make(map[int][]bls.G1Element),
+		shares:       make(map[int]bls.Fr),
+		complainers:  make(map[int]map[int]bool),
+		disqualified: make(map[int]bool),
+	}, nil
+}
+
+// Index returns the index of the participant
+func (p *Participant) Index() int {
+	return p.index
+}
+
+// Qualified returns the sorted indices of the qualified dealers, whose
+// polynomials make up the group key. It is nil until Finish succeeds.
+func (p *Participant) Qualified() []int {
+	return append([]int(nil), p.qualified...)
+}
+
+// Deal picks the secret polynomial of this participant. It returns the
+// commitments to broadcast and the shares to send to each other participant.
+func (p *Participant) Deal() (*DealMessage, []*ShareMessage, error) {
+	if err := p.advance(stepDeal, "Deal"); err != nil {
+		return nil, nil, err
+	}
+
+	p.poly = make([]bls.Fr, p.t)
+	commitments := make([]bls.G1Element, p.t)
+	g := bls.G1Generator()
+	for i := range p.poly {
+		coeff, err := bls.FrRandom(p.rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.poly[i] = coeff
+		commitments[i] = g.Mul(coeff)
+	}
+	p.commitments[p.index] = commitments
+	p.shares[p.index] = p.eval(p.index)
+
+	shares := make([]*ShareMessage, 0, p.n-1)
+	for j := 1; j <= p.n; j++ {
+		if j != p.index {
+			shares = append(shares, &ShareMessage{Dealer: p.index, Recipient: j, Share: p.eval(j)})
+		}
+	}
+	return &DealMessage{Dealer: p.index, Commitments: commitments}, shares, nil
+}
+
+// Complain processes the messages of RoundDeal. Dealers who didn't broadcast
+// exactly one deal of T commitments are disqualified. It returns a complaint
+// against the other dealers whose share is missing or doesn't match their
+// commitments, or nil if there is none.
+func (p *Participant) Complain(deals []*DealMessage, shares []*ShareMessage) (*ComplaintMessage, error) {
+	if err := p.advance(stepComplain, "Complain"); err != nil {
+		return nil, err
+	}
+
+	for _, m := range deals {
+		d := m.Dealer
+		if !p.isOther(d) || p.disqualified[d] {
+			continue
+		}
+		if _, ok := p.commitments[d]; ok || len(m.Commitments) != p.t {
+			p.disqualify(d)
+			continue
+		}
+		p.commitments[d] = m.Commitments
+	}
+
+	received := make(map[int][]bls.Fr)
+	for _, m := range shares {
+		if m.Recipient == p.index && p.isOther(m.Dealer) {
+			received[m.Dealer] = append(received[m.Dealer], m.Share)
+		}
+	}
+
+	var accused []int
+	for d := 1; d <= p.n; d++ {
+		if d == p.index {
+			continue
+		}
+		if _, ok := p.commitments[d]; !ok {
+			// Nothing was dealt, which everyone saw
+			p.disqualify(d)
+			continue
+		}
+		if s := received[d]; len(s) == 1 && p.verify(d, p.index, s[0]) {
+			p.shares[d] = s[0]
+			continue
+		}
+		accused = append(accused, d)
+		p.complain(p.index, d)
+	}
+
+	if len(accused) == 0 {
+		return nil, nil
+	}
+	return &ComplaintMessage{Complainer: p.index, Dealers: accused}, nil
+}
+
+// Justify processes the messages of RoundComplaint. If other participants
+// complained about this one, it returns the justification revealing their
+// shares, or nil otherwise.
+func (p *Participant) Justify(complaints []*ComplaintMessage) (*JustificationMessage, error) {
+	if err := p.advance(stepJustify, "Justify"); err != nil {
+		return nil, err
+	}
+
+	for _, m := range complaints {
+		if !p.isOther(m.Complainer) {
+			continue
+		}
+		for _, d := range m.Dealers {
+			if d >= 1 && d <= p.n && d != m.Complainer {
+				p.complain(m.Complainer, d)
+			}
+		}
+	}
+
+	if len(p.complainers[p.index]) == 0 {
+		return nil, nil
+	}
+	shares := make(map[int]bls.Fr, len(p.complainers[p.index]))
+	for c := range p.complainers[p.index] {
+		shares[c] = p.eval(c)
+	}
+	return &JustificationMessage{Dealer: p.index, Shares: shares}, nil
+}
+
+// Finish processes the messages of RoundJustification. Dealers who didn't
+// answer every complaint with a valid share are disqualified, and the
+// polynomials of the remaining ones are summed. It returns the secret share
+// of this participant, the group public key, and the public key shares of
+// all participants, with the share of participant i at index i-1.
+func (p *Participant) Finish(justifications []*JustificationMessage) (bls.PrivateKey, bls.PublicKey, []bls.PublicKey, error) {
+	if err := p.advance(stepFinish, "Finish"); err != nil {
+		return bls.PrivateKey{}, bls.PublicKey{}, nil, err
+	}
+	defer p.wipe()
+
+	received := make(map[int][]*JustificationMessage)
+	for _, m := range justifications {
+		if p.isOther(m.Dealer) {
+			received[m.Dealer] = append(received[m.Dealer], m)
+		}
+	}
+
+	for d, complainers := range p.complainers {
+		if p.disqualified[d] || d == p.index {
+			continue
+		}
+		if len(received[d]) != 1 {
+			p.disqualify(d)
+			continue
+		}
+		revealed := received[d][0].Shares
+		for c := range complainers {
+			share, ok := revealed[c]
+			if !ok || !p.verify(d, c, share) {
+				p.disqualify(d)
+				break
+			}
+			if c == p.index {
+				p.shares[d] = share
+			}
+		}
+	}
+
+	// Sum the shares and the commitments of the qualified dealers
+	var secret bls.Fr
+	var qualified []int
+	sums := make([]bls.G1Element, p.t)
+	for d := 1; d <= p.n; d++ {
+		if p.disqualified[d] {
+			continue
+		}
+		share, ok := p.shares[d]
+		if !ok {
+			return bls.PrivateKey{}, bls.PublicKey{}, nil, &bls.Error{
+				Err: ErrNoValidShare,
+				Msg: fmt.Sprintf("dealer %d", d),
+			}
+		}
+		secret = secret.Add(share)
+		for k, c := range p.commitments[d] {
+			sums[k] = sums[k].Add(c)
+		}
+		qualified = append(qualified, d)
+	}
+	p.qualified = qualified
+
+	publicKeyShares := make([]bls.PublicKey, p.n)
+	for j := 1; j <= p.n; j++ {
+		publicKeyShares[j-1] = evalCommitments(sums, j).PublicKey()
+	}
+	return bls.PrivateKeyFromFr(secret), sums[0].PublicKey(), publicKeyShares, nil
+}
+
+// Run drives all the steps of the protocol through t and returns the result
+// of Finish
+func (p *Participant) Run(t Transport) (bls.PrivateKey, bls.PublicKey, []bls.PublicKey, error) {
+	fail := func(err error) (bls.PrivateKey, bls.PublicKey, []bls.PublicKey, error) {
+		return bls.PrivateKey{}, bls.PublicKey{}, nil, err
+	}
+
+	deal, shares, err := p.Deal()
+	if err != nil {
+		return fail(err)
+	}
+	if err := t.Broadcast(deal); err != nil {
+		return fail(err)
+	}
+	for _, m := range shares {
+		if err := t.Send(m.Recipient, m); err != nil {
+			return fail(err)
+		}
+	}
+	msgs, err := t.Receive(RoundDeal)
+	if err != nil {
+		return fail(err)
+	}
+	var deals []*DealMessage
+	var received []*ShareMessage
+	for _, m := range msgs {
+		switch m := m.(type) {
+		case *DealMessage:
+			deals = append(deals, m)
+		case *ShareMessage:
+			received = append(received, m)
+		}
+	}
+
+	complaint, err := p.Complain(deals, received)
+	if err != nil {
+		return fail(err)
+	}
+	if complaint != nil {
+		if err := t.Broadcast(complaint); err != nil {
+			return fail(err)
+		}
+	}
+	msgs, err = t.Receive(RoundComplaint)
+	if err != nil {
+		return fail(err)
+	}
+	var complaints []*ComplaintMessage
+	for _, m := range msgs {
+		if m, ok := m.(*ComplaintMessage); ok {
+			complaints = append(complaints, m)
+		}
+	}
+
+	justification, err := p.Justify(complaints)
+	if err != nil {
+		return fail(err)
+	}
+	if justification != nil {
+		if err := t.Broadcast(justification); err != nil {
+			return fail(err)
+		}
+	}
+	msgs, err = t.Receive(RoundJustification)
+	if err != nil {
+		return fail(err)
+	}
+	var justifications []*JustificationMessage
+	for _, m := range msgs {
+		if m, ok := m.(*JustificationMessage); ok {
+			justifications = append(justifications, m)
+		}
+	}
+
+	return p.Finish(justifications)
+}
+
+// advance moves to the step after s, or fails if s is not the next step
+func (p *Participant) advance(s step, name string) error {
+	if p.step != s {
+		return &bls.Error{
+			Err: ErrWrongRound,
+			Msg: name + " is not the next step",
+		}
+	}
+	p.step++
+	return nil
+}
+
+// isOther reports whether i is the index of another participant
+func (p *Participant) isOther(i int) bool {
+	return i >= 1 && i <= p.n && i != p.index
+}
+
+func (p *Participant) disqualify(d int) {
+	p.disqualified[d] = true
+	delete(p.commitments, d)
+	delete(p.shares, d)
+}
+
+func (p *Participant) complain(complainer, dealer int) {
+	if p.complainers[dealer] == nil {
+		p.complainers[dealer] = make(map[int]bool)
+	}
+	p.complainers[dealer][complainer] = true
+}
+
+// eval evaluates the polynomial of this dealer at x
+func (p *Participant) eval(x int) bls.Fr {
+	fx := bls.FrFromUint64(uint64(x))
+	var res bls.Fr
+	for i := len(p.poly) - 1; i >= 0; i-- {
+		res = res.Mul(fx).Add(p.poly[i])
+	}
+	return res
+}
+
+// verify reports whether share is the evaluation at x of the polynomial
+// committed to by dealer
+func (p *Participant) verify(dealer, x int, share bls.Fr) bool {
+	expected := evalCommitments(p.commitments[dealer], x)
+	return bls.G1Generator().Mul(share).Equal(expected)
+}
+
+// wipe clears the secrets which are no longer needed
+func (p *Participant) wipe() {
+	for i := range p.poly {
+		p.poly[i] = bls.Fr{}
+	}
+	for d := range p.shares {
+		delete(p.shares, d)
+	}
+}
+
+// evalCommitments evaluates the polynomial committed to by commitments at x
+// in the exponent
+func evalCommitments(commitments []bls.G1Element, x int) bls.G1Element {
+	powers := make([]bls.Fr, len(commitments))
+	fx := bls.FrFromUint64(uint64(x))
+	power := bls.FrFromUint64(1)
+	for i := range powers {
+		powers[i] = power
+		power = power.Mul(fx)
+	}
+	// The lengths always match
+	res, _ := bls.G1MultiScalarMul(commitments, powers)
+	return res
+}
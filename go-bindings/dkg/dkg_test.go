@@ -0,0 +1,307 @@
+package dkg_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+	"github.com/nmarley/bls-signatures/go-bindings/dkg"
+)
+
+// network is an in-memory network. Receive waits until every participant is
+// done sending in the round, so it behaves as a synchronous broadcast
+// channel.
+type network struct {
+	n     int
+	mu    sync.Mutex
+	cond  *sync.Cond
+	inbox map[dkg.Round]map[int][]dkg.Message
+	done  map[dkg.Round]int
+}
+
+func newNetwork(n int) *network {
+	net := &network{
+		n:     n,
+		inbox: make(map[dkg.Round]map[int][]dkg.Message),
+		done:  make(map[dkg.Round]int),
+	}
+	net.cond = sync.NewCond(&net.mu)
+	return net
+}
+
+func (net *network) deliver(to int, msg dkg.Message) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	if net.inbox[msg.Round()] == nil {
+		net.inbox[msg.Round()] = make(map[int][]dkg.Message)
+	}
+	net.inbox[msg.Round()][to] = append(net.inbox[msg.Round()][to], msg)
+}
+
+// transport connects a participant to the network. A malicious participant
+// can rewrite or drop (by returning nil) the messages it sends, with to set
+// to 0 for broadcasts, and broadcast extra messages.
+type transport struct {
+	net    *network
+	index  int
+	tamper func(to int, msg dkg.Message) dkg.Message
+	extra  []dkg.Message
+}
+
+func (t *transport) Broadcast(msg dkg.Message) error {
+	if t.tamper != nil {
+		if msg = t.tamper(0, msg); msg == nil {
+			return nil
+		}
+	}
+	for j := 1; j <= t.net.n; j++ {
+		if j != t.index {
+			t.net.deliver(j, msg)
+		}
+	}
+	return nil
+}
+
+func (t *transport) Send(to int, msg dkg.Message) error {
+	if t.tamper != nil {
+		if msg = t.tamper(to, msg); msg == nil {
+			return nil
+		}
+	}
+	t.net.deliver(to, msg)
+	return nil
+}
+
+func (t *transport) Receive(round dkg.Round) ([]dkg.Message, error) {
+	for _, msg := range t.extra {
+		if msg.Round() == round {
+			t.Broadcast(msg)
+		}
+	}
+
+	net := t.net
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.done[round]++
+	net.cond.Broadcast()
+	for net.done[round] < net.n {
+		net.cond.Wait()
+	}
+	return net.inbox[round][t.index], nil
+}
+
+// malice describes how a participant misbehaves
+type malice struct {
+	tamper func(to int, msg dkg.Message) dkg.Message
+	extra  []dkg.Message
+}
+
+type result struct {
+	sk        bls.PrivateKey
+	groupPK   bls.PublicKey
+	pkShares  []bls.PublicKey
+	qualified []int
+	err       error
+}
+
+// runDKG runs the protocol with every participant in its own goroutine
+func runDKG(t *testing.T, T, N int, malicious map[int]malice) []result {
+	net := newNetwork(N)
+	results := make([]result, N)
+	var wg sync.WaitGroup
+	for i := 1; i <= N; i++ {
+		p, err := dkg.NewParticipant(dkg.Config{Index: i, T: T, N: N})
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		tr := &transport{net: net, index: i, tamper: malicious[i].tamper, extra: malicious[i].extra}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := &results[i-1]
+			r.sk, r.groupPK, r.pkShares, r.err = p.Run(tr)
+			r.qualified = p.Qualified()
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// checkResults makes sure the honest participants agree on the qualified
+// dealers and on the keys, and that T of them can sign for the group key
+func checkResults(t *testing.T, T int, results []result, honest []int, qualified []int) {
+	first := results[honest[0]-1]
+	for _, i := range honest {
+		r := results[i-1]
+		if r.err != nil {
+			t.Fatalf("participant %d: got unexpected error: %v", i, r.err)
+		}
+		if !reflect.DeepEqual(r.qualified, qualified) {
+			t.Errorf("participant %d: got qualified %v, expected %v", i, r.qualified, qualified)
+		}
+		if !r.groupPK.Equal(first.groupPK) {
+			t.Errorf("participant %d: group public keys differ", i)
+		}
+		for j := range r.pkShares {
+			if !r.pkShares[j].Equal(first.pkShares[j]) {
+				t.Errorf("participant %d: public key shares of %d differ", i, j+1)
+			}
+		}
+		pk := r.sk.PublicKey()
+		if !pk.Equal(r.pkShares[i-1]) {
+			t.Errorf("participant %d: secret share doesn't match its public key share", i)
+		}
+	}
+
+	// The group secret is never computed, but the last T honest participants
+	// can sign for it
+	players := honest[len(honest)-T:]
+	msg := []byte{1, 2, 3}
+	sigs := make([]bls.InsecureSignature, T)
+	for k, i := range players {
+		sig, err := bls.ThresholdSignWithCoefficient(results[i-1].sk, msg, i, players, T)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		sigs[k] = sig
+	}
+	sig, err := bls.InsecureSignatureAggregate(sigs)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	hash := sha256.Sum256(msg)
+	if !sig.Verify([][]byte{hash[:]}, []bls.PublicKey{first.groupPK}) {
+		t.Error("threshold signature did not verify")
+	}
+}
+
+func TestDKG(t *testing.T) {
+	results := runDKG(t, 3, 5, nil)
+	checkResults(t, 3, results, []int{1, 2, 3, 4, 5}, []int{1, 2, 3, 4, 5})
+}
+
+func TestDKGMaliciousDealers(t *testing.T) {
+	badShare := func(dealer, victim int) func(int, dkg.Message) dkg.Message {
+		return func(to int, msg dkg.Message) dkg.Message {
+			if m, ok := msg.(*dkg.ShareMessage); ok && to == victim {
+				return &dkg.ShareMessage{Dealer: dealer, Recipient: to, Share: m.Share.Add(bls.FrFromUint64(1))}
+			}
+			return msg
+		}
+	}
+
+	tests := []struct {
+		name      string
+		malicious map[int]malice
+		honest    []int
+		qualified []int
+	}{
+		{
+			name:      "bad share justified",
+			malicious: map[int]malice{2: {tamper: badShare(2, 4)}},
+			honest:    []int{1, 3, 4, 5},
+			qualified: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name: "bad share not justified",
+			malicious: map[int]malice{2: {tamper: func(to int, msg dkg.Message) dkg.Message {
+				if _, ok := msg.(*dkg.JustificationMessage); ok {
+					return nil
+				}
+				return badShare(2, 4)(to, msg)
+			}}},
+			honest:    []int{1, 3, 4, 5},
+			qualified: []int{1, 3, 4, 5},
+		},
+		{
+			name: "bad share in justification",
+			malicious: map[int]malice{2: {tamper: func(to int, msg dkg.Message) dkg.Message {
+				if m, ok := msg.(*dkg.JustificationMessage); ok {
+					return &dkg.JustificationMessage{
+						Dealer: 2,
+						Shares: map[int]bls.Fr{4: m.Shares[4].Add(bls.FrFromUint64(1))},
+					}
+				}
+				return badShare(2, 4)(to, msg)
+			}}},
+			honest:    []int{1, 3, 4, 5},
+			qualified: []int{1, 3, 4, 5},
+		},
+		{
+			name: "missing share",
+			malicious: map[int]malice{3: {tamper: func(to int, msg dkg.Message) dkg.Message {
+				if _, ok := msg.(*dkg.ShareMessage); ok && to == 1 {
+					return nil
+				}
+				return msg
+			}}},
+			honest:    []int{1, 2, 4, 5},
+			qualified: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name: "wrong number of commitments",
+			malicious: map[int]malice{5: {tamper: func(to int, msg dkg.Message) dkg.Message {
+				if m, ok := msg.(*dkg.DealMessage); ok {
+					return &dkg.DealMessage{Dealer: 5, Commitments: m.Commitments[:2]}
+				}
+				return msg
+			}}},
+			honest:    []int{1, 2, 3, 4},
+			qualified: []int{1, 2, 3, 4},
+		},
+		{
+			name: "duplicate deal",
+			malicious: map[int]malice{5: {extra: []dkg.Message{
+				&dkg.DealMessage{Dealer: 5, Commitments: make([]bls.G1Element, 3)},
+			}}},
+			honest:    []int{1, 2, 3, 4},
+			qualified: []int{1, 2, 3, 4},
+		},
+		{
+			name: "silent dealer and false complaint",
+			malicious: map[int]malice{
+				1: {tamper: func(to int, msg dkg.Message) dkg.Message {
+					if msg.Round() == dkg.RoundDeal {
+						return nil
+					}
+					return msg
+				}},
+				// 3 is honest and has to justify its share for 5
+				5: {extra: []dkg.Message{&dkg.ComplaintMessage{Complainer: 5, Dealers: []int{3}}}},
+			},
+			honest:    []int{2, 3, 4},
+			qualified: []int{2, 3, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := runDKG(t, 3, 5, tt.malicious)
+			checkResults(t, 3, results, tt.honest, tt.qualified)
+		})
+	}
+}
+
+func TestParticipantInvalid(t *testing.T) {
+	_, err := dkg.NewParticipant(dkg.Config{Index: 1, T: 4, N: 3})
+	if !errors.Is(err, bls.ErrInvalidThreshold) {
+		t.Errorf("got %v, expected %v", err, bls.ErrInvalidThreshold)
+	}
+	_, err = dkg.NewParticipant(dkg.Config{Index: 4, T: 2, N: 3})
+	if !errors.Is(err, bls.ErrInvalidPlayers) {
+		t.Errorf("got %v, expected %v", err, bls.ErrInvalidPlayers)
+	}
+
+	p, _ := dkg.NewParticipant(dkg.Config{Index: 1, T: 2, N: 3})
+	if _, err := p.Justify(nil); !errors.Is(err, dkg.ErrWrongRound) {
+		t.Errorf("got %v, expected %v", err, dkg.ErrWrongRound)
+	}
+	if _, _, err := p.Deal(); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if _, _, err := p.Deal(); !errors.Is(err, dkg.ErrWrongRound) {
+		t.Errorf("got %v, expected %v", err, dkg.ErrWrongRound)
+	}
+}
@@ -0,0 +1,114 @@
+package dkg
+
+import (
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+// Round identifies a round of the protocol
+type Round int
+
+const (
+	// RoundDeal is the round in which every dealer broadcasts a DealMessage
+	// and sends a ShareMessage to every other participant
+	RoundDeal Round = iota + 1
+	// RoundComplaint is the round in which participants broadcast a
+	// ComplaintMessage against the dealers whose shares were missing or
+	// invalid
+	RoundComplaint
+	// RoundJustification is the round in which dealers who got complaints
+	// broadcast a JustificationMessage revealing the disputed shares
+	RoundJustification
+)
+
+func (r Round) String() string {
+	switch r {
+	case RoundDeal:
+		return "deal"
+	case RoundComplaint:
+		return "complaint"
+	case RoundJustification:
+		return "justification"
+	}
+	return "unknown"
+}
+
+// Message is a message of the protocol. The concrete types are
+// *DealMessage, *ShareMessage, *ComplaintMessage and *JustificationMessage.
+// Their fields are exported and their values implement json.Marshaler, so a
+// Transport can encode them as it likes.
+type Message interface {
+	// Round returns the round in which the message is sent
+	Round() Round
+	// Sender returns the index of the participant sending the message
+	Sender() int
+}
+
+// DealMessage is broadcast by a dealer in RoundDeal. It holds the commitments
+// to the coefficients of the dealer's secret polynomial, constant term first.
+type DealMessage struct {
+	Dealer      int
+	Commitments []bls.G1Element
+}
+
+// Round implements Message
+func (m *DealMessage) Round() Round { return RoundDeal }
+
+// Sender implements Message
+func (m *DealMessage) Sender() int { return m.Dealer }
+
+// ShareMessage is sent privately by a dealer to each other participant in
+// RoundDeal. It holds the dealer's polynomial evaluated at the index of the
+// recipient, which is secret.
+type ShareMessage struct {
+	Dealer    int
+	Recipient int
+	Share     bls.Fr
+}
+
+// Round implements Message
+func (m *ShareMessage) Round() Round { return RoundDeal }
+
+// Sender implements Message
+func (m *ShareMessage) Sender() int { return m.Dealer }
+
+// ComplaintMessage is broadcast in RoundComplaint by a participant who didn't
+// get a valid share from some dealers.
+type ComplaintMessage struct {
+	Complainer int
+	Dealers    []int
+}
+
+// Round implements Message
+func (m *ComplaintMessage) Round() Round { return RoundComplaint }
+
+// Sender implements Message
+func (m *ComplaintMessage) Sender() int { return m.Complainer }
+
+// JustificationMessage is broadcast in RoundJustification by a dealer who got
+// complaints. It maps the index of each complainer to their share, which
+// every participant can check against the dealer's commitments.
+type JustificationMessage struct {
+	Dealer int
+	Shares map[int]bls.Fr
+}
+
+// Round implements Message
+func (m *JustificationMessage) Round() Round { return RoundJustification }
+
+// Sender implements Message
+func (m *JustificationMessage) Sender() int { return m.Dealer }
+
+// Transport delivers messages between the participants. Joint-Feldman
+// assumes authenticated private channels and a reliable broadcast channel, so
+// implementations must make sure the sender of a message is genuine, that
+// Send can only be read by the recipient, and that every participant receives
+// the same broadcasts.
+type Transport interface {
+	// Broadcast sends msg to every other participant
+	Broadcast(msg Message) error
+	// Send sends msg to participant to only
+	Send(to int, msg Message) error
+	// Receive waits for the end of round and returns the messages sent to
+	// this participant in it. Messages of the wrong type are ignored.
+	Receive(round Round) ([]Message, error)
+}
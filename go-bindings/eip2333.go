@@ -0,0 +1,129 @@
+package blschia
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// EIP-2333 derives BLS12-381 keys with HKDF-mod-r, hardening every child
+// through a Lamport one-time key. It is unrelated to the BIP-32 style scheme
+// of ExtendedPrivateKey, and the two give different keys for the same seed.
+// See https://eips.ethereum.org/EIPS/eip-2333.
+
+const (
+	// eip2333MinSeedSize is the minimum size in bytes of an EIP-2333 seed
+	eip2333MinSeedSize = 32
+	// eip2333OKMSize is the size in bytes of the HKDF output reduced mod r,
+	// which is large enough to make the bias negligible
+	eip2333OKMSize = 48
+	// lamportChunks is the number of 32 byte chunks of a Lamport key
+	lamportChunks = 255
+)
+
+// EIP2333MasterKey derives the master key from seed as specified by EIP-2333.
+// seed must be at least 32 bytes, e.g. from MnemonicToSeed.
+func EIP2333MasterKey(seed []byte) (PrivateKey, error) {
+	if len(seed) < eip2333MinSeedSize {
+		return PrivateKey{}, &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("seed must be at least %d bytes, got %d", eip2333MinSeedSize, len(seed)),
+		}
+	}
+	return eip2333KeyFromIKM(seed), nil
+}
+
+// EIP2333ChildKey derives the child of parent with the given index as
+// specified by EIP-2333. All EIP-2333 children are hardened, so any index may
+// be used and the child's public key can't be derived from parent's.
+func EIP2333ChildKey(parent PrivateKey, index uint32) PrivateKey {
+	var parentBytes [PrivateKeySize]byte
+	defer Wipe(parentBytes[:])
+	parent.SerializeInto(parentBytes[:])
+
+	lamportPK := eip2333LamportPK(parentBytes[:], index)
+	return eip2333KeyFromIKM(lamportPK)
+}
+
+// EIP2333DerivePath parses path with ParsePath and derives it from the master
+// key for seed, e.g. "m/12381/3600/0/0/0" for the first validator signing key
+// of EIP-2334.
+func EIP2333DerivePath(seed []byte, path string) (PrivateKey, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return EIP2333Derive(seed, p)
+}
+
+// EIP2333Derive derives the children in path in turn from the master key for
+// seed. Indices marked as hardened are used as is, i.e. with HardenedOffset
+// added.
+func EIP2333Derive(seed []byte, path Path) (PrivateKey, error) {
+	sk, err := EIP2333MasterKey(seed)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	for _, index := range path {
+		child := EIP2333ChildKey(sk, index)
+		// Free the intermediate keys now rather than leaving them to the GC
+		sk.Free()
+		sk = child
+	}
+	return sk, nil
+}
+
+// eip2333KeyFromIKM implements HKDF_mod_r with an empty key_info
+func eip2333KeyFromIKM(ikm []byte) PrivateKey {
+	input := make([]byte, len(ikm)+1)
+	defer Wipe(input)
+	copy(input, ikm)
+
+	var key Fr
+	defer Wipe(key.value[:])
+	info := []byte{0, eip2333OKMSize}
+	salt := []byte("BLS-SIG-KEYGEN-SALT-")
+	for {
+		hash := sha256.Sum256(salt)
+		salt = hash[:]
+
+		var okm [eip2333OKMSize]byte
+		hkdfSHA256(input, salt, info, okm[:])
+		key = frFromWideBytes(okm[:])
+		Wipe(okm[:])
+
+		// The key must not be zero, which happens with negligible probability
+		if !key.IsZero() {
+			break
+		}
+	}
+
+	return PrivateKeyFromFr(key)
+}
+
+// eip2333LamportPK implements parent_SK_to_lamport_PK, returning the
+// compressed Lamport public key used as the IKM of the child
+func eip2333LamportPK(parent []byte, index uint32) []byte {
+	var salt [4]byte
+	binary.BigEndian.PutUint32(salt[:], index)
+
+	notParent := make([]byte, len(parent))
+	defer Wipe(notParent)
+	for i := range parent {
+		notParent[i] = ^parent[i]
+	}
+
+	// The Lamport public key is the hashes of the chunks of both Lamport
+	// secret keys, and the compressed key is its hash
+	compressed := sha256.New()
+	for _, ikm := range [][]byte{parent, notParent} {
+		lamportSK := make([]byte, lamportChunks*sha256.Size)
+		hkdfSHA256(ikm, salt[:], nil, lamportSK)
+		for i := 0; i < lamportChunks; i++ {
+			chunk := sha256.Sum256(lamportSK[i*sha256.Size : (i+1)*sha256.Size])
+			compressed.Write(chunk[:])
+		}
+		Wipe(lamportSK)
+	}
+	return compressed.Sum(nil)
+}
@@ -0,0 +1,96 @@
+package blschia_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+// eip2333Vectors are the test cases of EIP-2333, with keys in decimal
+var eip2333Vectors = []struct {
+	seed       string
+	master     string
+	childIndex uint32
+	child      string
+}{
+	{
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		"6083874454709270928345386274498605044986640685124978867557563392430687146096",
+		0,
+		"20397789859736650942317412262472558107875392172444076792671091975210932703118",
+	},
+	{
+		"3141592653589793238462643383279502884197169399375105820974944592",
+		"29757020647961307431480504535336562678282505419141012933316116377660817309383",
+		3141592653,
+		"25457201688850691947727629385191704516744796114925897962676248250929345014287",
+	},
+	{
+		"0099ff991111002299dd7744ee3355bbdd8844115566cc55663355668888cc00",
+		"27580842291869792442942448775674722299803720648445448686099262467207037398656",
+		4294967295,
+		"29358610794459428860402234341874281240803786294062035874021252734817515685787",
+	},
+	{
+		"d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3",
+		"19022158461524446591288038168518313374041767046816487870552872741050760015818",
+		42,
+		"31372231650479070279774297061823572166496564838472787488249775572789064611981",
+	},
+}
+
+func TestEIP2333Vectors(t *testing.T) {
+	for _, v := range eip2333Vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		master, err := bls.EIP2333MasterKey(seed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := new(big.Int).SetBytes(master.Serialize()).String(); got != v.master {
+			t.Errorf("got master key %s, expected %s", got, v.master)
+		}
+
+		child := bls.EIP2333ChildKey(master, v.childIndex)
+		if got := new(big.Int).SetBytes(child.Serialize()).String(); got != v.child {
+			t.Errorf("got child key %s, expected %s", got, v.child)
+		}
+
+		child.Free()
+		master.Free()
+	}
+}
+
+func TestEIP2333DerivePath(t *testing.T) {
+	seed, _ := hex.DecodeString(eip2333Vectors[0].seed)
+	sk, err := bls.EIP2333DerivePath(seed, "m/12381/3600/0/0/0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected, _ := bls.EIP2333MasterKey(seed)
+	for _, index := range []uint32{12381, 3600, 0, 0, 0} {
+		expected = bls.EIP2333ChildKey(expected, index)
+	}
+	if !sk.Equal(expected) {
+		t.Error("EIP2333DerivePath should match deriving each child in turn")
+	}
+
+	// The EIP-2333 master key differs from the BIP-32 style one
+	master, _ := bls.EIP2333DerivePath(seed, "m")
+	xprv := bls.ExtendedPrivateKeyFromSeed(seed)
+	if master.Equal(xprv.GetPrivateKey()) {
+		t.Error("EIP-2333 and BIP-32 style keys should differ")
+	}
+
+	_, err = bls.EIP2333MasterKey(seed[:31])
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.EIP2333DerivePath(seed, "12381/3600")
+	expectError(t, err, bls.ErrInvalidPath)
+
+	xprv.Free()
+	master.Free()
+	expected.Free()
+	sk.Free()
+}
@@ -0,0 +1,205 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+#include "element.h"
+#include <cstring>
+#include <stdexcept>
+#include "curve.h"
+#include "error.h"
+
+// Uncompressed encodings have the same layout as G1Element and G2Element
+// values, plus the infinity flag
+static void SerializeUncompressed(uint8_t *out, const uint8_t *in,
+    size_t len) {
+    std::memcpy(out, in, len);
+    bool infinity = true;
+    for (size_t i = 0; i < len; i++) {
+        if (in[i] != 0) {
+            infinity = false;
+            break;
+        }
+    }
+    if (infinity) {
+        out[0] = 0x40;
+    }
+}
+
+void CG1ElementFromBytes(void *in, size_t len, void *out, char **errMsg) {
+    g1_t point;
+    try {
+        DecodeG1(point, static_cast<uint8_t*>(in), len);
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return;
+    }
+    WriteG1Element(static_cast<uint8_t*>(out), point);
+}
+
+bool CG1ElementIsInSubgroup(void *in) {
+    g1_t point;
+    ReadG1Element(point, static_cast<uint8_t*>(in));
+    return G1InSubgroup(point);
+}
+
+void CG1ElementSerialize(void *in, void *out) {
+    g1_t point;
+    ReadG1Element(point, static_cast<uint8_t*>(in));
+    CompressG1(static_cast<uint8_t*>(out), point);
+}
+
+void CG1ElementSerializeUncompressed(void *in, void *out) {
+    SerializeUncompressed(static_cast<uint8_t*>(out),
+        static_cast<uint8_t*>(in), G1_ELEMENT_SIZE);
+}
+
+void CG1ElementAggregate(void *elements, size_t len, void *out) {
+    uint8_t *in = static_cast<uint8_t*>(elements);
+    g1_t sum, point;
+    g1_set_infty(sum);
+    for (size_t i = 0; i < len; i++) {
+        ReadG1Element(point, in + i * G1_ELEMENT_SIZE);
+        g1_add(sum, sum, point);
+    }
+    WriteG1Element(static_cast<uint8_t*>(out), sum);
+}
+
+void CG1ElementGenerator(void *out) {
+    g1_t gen;
+    g1_get_gen(gen);
+    WriteG1Element(static_cast<uint8_t*>(out), gen);
+}
+
+void CG1ElementAdd(void *a, void *b, void *out) {
+    g1_t p, q;
+    ReadG1Element(p, static_cast<uint8_t*>(a));
+    ReadG1Element(q, static_cast<uint8_t*>(b));
+    g1_add(p, p, q);
+    WriteG1Element(static_cast<uint8_t*>(out), p);
+}
+
+void CG1ElementNeg(void *in, void *out) {
+    g1_t point;
+    ReadG1Element(point, static_cast<uint8_t*>(in));
+    g1_neg(point, point);
+    WriteG1Element(static_cast<uint8_t*>(out), point);
+}
+
+void CG1ElementMul(void *in, void *scalar, void *out) {
+    g1_t point;
+    ReadG1Element(point, static_cast<uint8_t*>(in));
+    bn_t k;
+    bn_new(k);
+    bn_read_bin(k, static_cast<uint8_t*>(scalar), SCALAR_SIZE);
+    g1_mul(point, point, k);
+    bn_free(k);
+    WriteG1Element(static_cast<uint8_t*>(out), point);
+}
+
+CPublicKey CG1ElementToPublicKey(void *in) {
+    g1_t point;
+    ReadG1Element(point, static_cast<uint8_t*>(in));
+    return new bls::PublicKey(bls::PublicKey::FromG1(&point));
+}
+
+void CG1ElementFromPublicKey(CPublicKey pk, void *out) {
+    bls::PublicKey* key = (bls::PublicKey*)pk;
+    g1_t point;
+    ReadPublicKey(point, *key);
+    WriteG1Element(static_cast<uint8_t*>(out), point);
+}
+
+void CG2ElementFromBytes(void *in, size_t len, void *out, char **errMsg) {
+    g2_t point;
+    try {
+        DecodeG2(point, static_cast<uint8_t*>(in), len);
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return;
+    }
+    WriteG2Element(static_cast<uint8_t*>(out), point);
+}
+
+bool CG2ElementIsInSubgroup(void *in) {
+    g2_t point;
+    ReadG2Element(point, static_cast<uint8_t*>(in));
+    return G2InSubgroup(point);
+}
+
+void CG2ElementSerialize(void *in, void *out) {
+    g2_t point;
+    ReadG2Element(point, static_cast<uint8_t*>(in));
+    CompressG2(static_cast<uint8_t*>(out), point);
+}
+
+void CG2ElementSerializeUncompressed(void *in, void *out) {
+    SerializeUncompressed(static_cast<uint8_t*>(out),
+        static_cast<uint8_t*>(in), G2_ELEMENT_SIZE);
+}
+
+void CG2ElementAggregate(void *elements, size_t len, void *out) {
+    uint8_t *in = static_cast<uint8_t*>(elements);
+    g2_t sum, point;
+    g2_set_infty(sum);
+    for (size_t i = 0; i < len; i++) {
+        ReadG2Element(point, in + i * G2_ELEMENT_SIZE);
+        g2_add(sum, sum, point);
+    }
+    WriteG2Element(static_cast<uint8_t*>(out), sum);
+}
+
+void CG2ElementGenerator(void *out) {
+    g2_t gen;
+    g2_get_gen(gen);
+    WriteG2Element(static_cast<uint8_t*>(out), gen);
+}
+
+void CG2ElementAdd(void *a, void *b, void *out) {
+    g2_t p, q;
+    ReadG2Element(p, static_cast<uint8_t*>(a));
+    ReadG2Element(q, static_cast<uint8_t*>(b));
+    g2_add(p, p, q);
+    WriteG2Element(static_cast<uint8_t*>(out), p);
+}
+
+void CG2ElementNeg(void *in, void *out) {
+    g2_t point;
+    ReadG2Element(point, static_cast<uint8_t*>(in));
+    g2_neg(point, point);
+    WriteG2Element(static_cast<uint8_t*>(out), point);
+}
+
+void CG2ElementMul(void *in, void *scalar, void *out) {
+    g2_t point;
+    ReadG2Element(point, static_cast<uint8_t*>(in));
+    bn_t k;
+    bn_new(k);
+    bn_read_bin(k, static_cast<uint8_t*>(scalar), SCALAR_SIZE);
+    g2_mul(point, point, k);
+    bn_free(k);
+    WriteG2Element(static_cast<uint8_t*>(out), point);
+}
+
+CInsecureSignature CG2ElementToInsecureSignature(void *in) {
+    g2_t point;
+    ReadG2Element(point, static_cast<uint8_t*>(in));
+    return new bls::InsecureSignature(bls::InsecureSignature::FromG2(&point));
+}
+
+void CG2ElementFromInsecureSignature(CInsecureSignature sig, void *out) {
+    bls::InsecureSignature* insecureSig = (bls::InsecureSignature*)sig;
+    g2_t point;
+    ReadInsecureSignature(point, *insecureSig);
+    WriteG2Element(static_cast<uint8_t*>(out), point);
+}
@@ -0,0 +1,374 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdbool.h>
+// #include <stdlib.h>
+// #include "element.h"
+// #include "blschia.h"
+import "C"
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// Sizes in bytes of the encodings of G1Element and G2Element
+const (
+	G1ElementSize             = 48
+	G1ElementUncompressedSize = 96
+	G2ElementSize             = 96
+	G2ElementUncompressedSize = 192
+)
+
+// G1Element is a point in the prime order subgroup of G1, such as a public
+// key of the IETF signature schemes. It is serialized as specified by the
+// IETF draft, the ZCash library and Ethereum, which differs from the format
+// of PublicKey.
+//
+// Unlike the key and signature types, a G1Element holds no C memory, so it
+// needs no Free and can be compared with ==. The zero value is the point at
+// infinity.
+type G1Element struct {
+	// point holds the uncompressed coordinates, with all zeros for the point
+	// at infinity
+	point [G1ElementUncompressedSize]byte
+}
+
+// G2Element is a point in the prime order subgroup of G2, such as a signature
+// of the IETF signature schemes. It is serialized like G1Element, which
+// differs from the format of InsecureSignature.
+//
+// A G2Element holds no C memory, so it needs no Free and can be compared with
+// ==. The zero value is the point at infinity.
+type G2Element struct {
+	// point holds the uncompressed coordinates, with all zeros for the point
+	// at infinity
+	point [G2ElementUncompressedSize]byte
+}
+
+// checkElementLength returns an ErrLengthMismatch error if data is neither
+// the compressed nor the uncompressed size
+func checkElementLength(what string, data []byte, size int) error {
+	if len(data) != size && len(data) != 2*size {
+		return &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("%s must be %d or %d bytes, got %d", what, size, 2*size, len(data)),
+		}
+	}
+	return nil
+}
+
+// G1ElementFromBytes decodes a compressed or uncompressed G1 element. Points
+// which are not on the curve or not in the G1 subgroup are rejected, with
+// ErrInvalidPublicKey and ErrKeyNotInSubgroup respectively.
+func G1ElementFromBytes(data []byte) (G1Element, error) {
+	if err := checkElementLength("G1 element", data, G1ElementSize); err != nil {
+		return G1Element{}, err
+	}
+
+	// Get a C pointer to bytes
+	cBytesPtr := C.CBytes(data)
+	defer C.free(cBytesPtr)
+
+	var p G1Element
+	var cErrMsg *C.char
+	C.CG1ElementFromBytes(cBytesPtr, C.size_t(len(data)), p.ptr(), &cErrMsg)
+	if cErrMsg != nil {
+		return G1Element{}, errFromC(ErrInvalidPublicKey, cErrMsg)
+	}
+	if !bool(C.CG1ElementIsInSubgroup(p.ptr())) {
+		return G1Element{}, errNotInSubgroup()
+	}
+	return p, nil
+}
+
+func (p *G1Element) ptr() unsafe.Pointer {
+	return unsafe.Pointer(&p.point[0])
+}
+
+// Serialize returns the compressed encoding of the element
+func (p G1Element) Serialize() []byte {
+	buf := make([]byte, G1ElementSize)
+	C.CG1ElementSerialize(p.ptr(), unsafe.Pointer(&buf[0]))
+	return buf
+}
+
+// SerializeUncompressed returns the uncompressed encoding of the element,
+// which is faster to decode
+func (p G1Element) SerializeUncompressed() []byte {
+	buf := make([]byte, G1ElementUncompressedSize)
+	C.CG1ElementSerializeUncompressed(p.ptr(), unsafe.Pointer(&buf[0]))
+	return buf
+}
+
+// IsIdentity reports whether the element is the point at infinity
+func (p G1Element) IsIdentity() bool {
+	return p == G1Element{}
+}
+
+// Equal tests if one G1Element is equal to another
+func (p G1Element) Equal(other G1Element) bool {
+	return p == other
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize.
+func (p G1Element) MarshalBinary() ([]byte, error) {
+	return p.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by G1ElementFromBytes.
+func (p *G1Element) UnmarshalBinary(data []byte) error {
+	parsed, err := G1ElementFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the element as hex.
+func (p G1Element) MarshalText() ([]byte, error) {
+	return marshalHex(p.Serialize()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// element.
+func (p *G1Element) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return p.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the element as a hex string.
+// The zero value is the point at infinity, so unlike for keys it is not
+// encoded as null.
+func (p G1Element) MarshalJSON() ([]byte, error) {
+	return marshalJSON(p.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the element unchanged.
+func (p *G1Element) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return p.UnmarshalText(text)
+}
+
+// G1Generator returns the generator of G1
+func G1Generator() G1Element {
+	var g G1Element
+	C.CG1ElementGenerator(g.ptr())
+	return g
+}
+
+// Add returns the sum of p and q
+func (p G1Element) Add(q G1Element) G1Element {
+	var sum G1Element
+	C.CG1ElementAdd(p.ptr(), q.ptr(), sum.ptr())
+	return sum
+}
+
+// Neg returns the negation of p, so that p.Add(p.Neg()) is the identity
+func (p G1Element) Neg() G1Element {
+	var neg G1Element
+	C.CG1ElementNeg(p.ptr(), neg.ptr())
+	return neg
+}
+
+// Mul returns p multiplied by the scalar s
+func (p G1Element) Mul(s Fr) G1Element {
+	var prod G1Element
+	C.CG1ElementMul(p.ptr(), s.ptr(), prod.ptr())
+	return prod
+}
+
+// PublicKey converts the element to a PublicKey, which holds the same point
+// but is serialized differently
+func (p G1Element) PublicKey() PublicKey {
+	return newPublicKey(C.CG1ElementToPublicKey(p.ptr()))
+}
+
+// G1Element converts the public key to a G1Element
+func (pk PublicKey) G1Element() G1Element {
+	defer runtime.KeepAlive(pk)
+	var p G1Element
+	C.CG1ElementFromPublicKey(pk.pk, p.ptr())
+	return p
+}
+
+// G2ElementFromBytes decodes a compressed or uncompressed G2 element. Points
+// which are not on the curve or not in the G2 subgroup are rejected with
+// ErrInvalidSignature.
+func G2ElementFromBytes(data []byte) (G2Element, error) {
+	if err := checkElementLength("G2 element", data, G2ElementSize); err != nil {
+		return G2Element{}, err
+	}
+
+	// Get a C pointer to bytes
+	cBytesPtr := C.CBytes(data)
+	defer C.free(cBytesPtr)
+
+	var p G2Element
+	var cErrMsg *C.char
+	C.CG2ElementFromBytes(cBytesPtr, C.size_t(len(data)), p.ptr(), &cErrMsg)
+	if cErrMsg != nil {
+		return G2Element{}, errFromC(ErrInvalidSignature, cErrMsg)
+	}
+	if !bool(C.CG2ElementIsInSubgroup(p.ptr())) {
+		return G2Element{}, errG2NotInSubgroup()
+	}
+	return p, nil
+}
+
+func (p *G2Element) ptr() unsafe.Pointer {
+	return unsafe.Pointer(&p.point[0])
+}
+
+// Serialize returns the compressed encoding of the element
+func (p G2Element) Serialize() []byte {
+	buf := make([]byte, G2ElementSize)
+	C.CG2ElementSerialize(p.ptr(), unsafe.Pointer(&buf[0]))
+	return buf
+}
+
+// SerializeUncompressed returns the uncompressed encoding of the element,
+// which is faster to decode
+func (p G2Element) SerializeUncompressed() []byte {
+	buf := make([]byte, G2ElementUncompressedSize)
+	C.CG2ElementSerializeUncompressed(p.ptr(), unsafe.Pointer(&buf[0]))
+	return buf
+}
+
+// IsIdentity reports whether the element is the point at infinity
+func (p G2Element) IsIdentity() bool {
+	return p == G2Element{}
+}
+
+// Equal tests if one G2Element is equal to another
+func (p G2Element) Equal(other G2Element) bool {
+	return p == other
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize.
+func (p G2Element) MarshalBinary() ([]byte, error) {
+	return p.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by G2ElementFromBytes.
+func (p *G2Element) UnmarshalBinary(data []byte) error {
+	parsed, err := G2ElementFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the element as hex.
+func (p G2Element) MarshalText() ([]byte, error) {
+	return marshalHex(p.Serialize()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// element.
+func (p *G2Element) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return p.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the element as a hex string.
+// The zero value is the point at infinity, so it is not encoded as null.
+func (p G2Element) MarshalJSON() ([]byte, error) {
+	return marshalJSON(p.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the element unchanged.
+func (p *G2Element) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return p.UnmarshalText(text)
+}
+
+// G2Generator returns the generator of G2
+func G2Generator() G2Element {
+	var g G2Element
+	C.CG2ElementGenerator(g.ptr())
+	return g
+}
+
+// Add returns the sum of p and q
+func (p G2Element) Add(q G2Element) G2Element {
+	var sum G2Element
+	C.CG2ElementAdd(p.ptr(), q.ptr(), sum.ptr())
+	return sum
+}
+
+// Neg returns the negation of p, so that p.Add(p.Neg()) is the identity
+func (p G2Element) Neg() G2Element {
+	var neg G2Element
+	C.CG2ElementNeg(p.ptr(), neg.ptr())
+	return neg
+}
+
+// Mul returns p multiplied by the scalar s
+func (p G2Element) Mul(s Fr) G2Element {
+	var prod G2Element
+	C.CG2ElementMul(p.ptr(), s.ptr(), prod.ptr())
+	return prod
+}
+
+// InsecureSignature converts the element to an InsecureSignature, which holds
+// the same point but is serialized differently
+func (p G2Element) InsecureSignature() InsecureSignature {
+	return newInsecureSignature(C.CG2ElementToInsecureSignature(p.ptr()))
+}
+
+// G2Element converts the signature to a G2Element. InsecureSignatureFromBytes
+// doesn't check that the point is in the G2 subgroup, so signatures outside it
+// are rejected here with ErrInvalidSignature.
+func (sig InsecureSignature) G2Element() (G2Element, error) {
+	defer runtime.KeepAlive(sig)
+	var p G2Element
+	C.CG2ElementFromInsecureSignature(sig.sig, p.ptr())
+	if !bool(C.CG2ElementIsInSubgroup(p.ptr())) {
+		return G2Element{}, errG2NotInSubgroup()
+	}
+	return p, nil
+}
+
+// aggregateG1 returns the sum of the elements
+func aggregateG1(elements []G1Element) G1Element {
+	var sum G1Element
+	if len(elements) == 0 {
+		return sum
+	}
+	C.CG1ElementAggregate(unsafe.Pointer(&elements[0].point[0]),
+		C.size_t(len(elements)), sum.ptr())
+	return sum
+}
+
+// aggregateG2 returns the sum of the elements
+func aggregateG2(elements []G2Element) G2Element {
+	var sum G2Element
+	if len(elements) == 0 {
+		return sum
+	}
+	C.CG2ElementAggregate(unsafe.Pointer(&elements[0].point[0]),
+		C.size_t(len(elements)), sum.ptr())
+	return sum
+}
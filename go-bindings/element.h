@@ -0,0 +1,56 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#ifndef GO_BINDINGS_ELEMENT_H_
+#define GO_BINDINGS_ELEMENT_H_
+#include <stdbool.h>
+#include <stddef.h>
+#include "publickey.h"
+#include "signature.h"
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// Elements are passed in the uncompressed form without flag bits, with all
+// zeros standing for the point at infinity. FromBytes decodes the compressed
+// or uncompressed encoding of the IETF draft into that form. Scalars are 32
+// bytes big-endian and smaller than the group order.
+void CG1ElementFromBytes(void *in, size_t len, void *out, char **errMsg);
+bool CG1ElementIsInSubgroup(void *in);
+void CG1ElementSerialize(void *in, void *out);
+void CG1ElementSerializeUncompressed(void *in, void *out);
+void CG1ElementAggregate(void *elements, size_t len, void *out);
+void CG1ElementGenerator(void *out);
+void CG1ElementAdd(void *a, void *b, void *out);
+void CG1ElementNeg(void *in, void *out);
+void CG1ElementMul(void *in, void *scalar, void *out);
+CPublicKey CG1ElementToPublicKey(void *in);
+void CG1ElementFromPublicKey(CPublicKey pk, void *out);
+
+void CG2ElementFromBytes(void *in, size_t len, void *out, char **errMsg);
+bool CG2ElementIsInSubgroup(void *in);
+void CG2ElementSerialize(void *in, void *out);
+void CG2ElementSerializeUncompressed(void *in, void *out);
+void CG2ElementAggregate(void *elements, size_t len, void *out);
+void CG2ElementGenerator(void *out);
+void CG2ElementAdd(void *a, void *b, void *out);
+void CG2ElementNeg(void *in, void *out);
+void CG2ElementMul(void *in, void *scalar, void *out);
+CInsecureSignature CG2ElementToInsecureSignature(void *in);
+void CG2ElementFromInsecureSignature(CInsecureSignature sig, void *out);
+
+#ifdef __cplusplus
+}
+#endif
+#endif  // GO_BINDINGS_ELEMENT_H_
@@ -0,0 +1,302 @@
+package blschia_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestG1Element(t *testing.T) {
+	// The key of the EIP-2335 test vectors, whose pubkey is given in the
+	// IETF format
+	secret, _ := hex.DecodeString(keystoreSecret)
+	sk, _ := bls.PrivateKeyFromBytes(secret, false)
+	pk := sk.G1Element()
+	expected := "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07"
+	if got := hex.EncodeToString(pk.Serialize()); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+
+	// The same point as PublicKey, without the compression flag
+	legacy := sk.PublicKey().Serialize()
+	compressed := pk.Serialize()
+	compressed[0] &^= 0x80
+	if !bytes.Equal(compressed, legacy) {
+		t.Errorf("got %x, expected %x", compressed, legacy)
+	}
+
+	for _, data := range [][]byte{pk.Serialize(), pk.SerializeUncompressed()} {
+		decoded, err := bls.G1ElementFromBytes(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if decoded != pk || !decoded.Equal(pk) {
+			t.Errorf("got %x, expected %x", decoded.Serialize(), pk.Serialize())
+		}
+	}
+	if pk.IsIdentity() {
+		t.Error("pk should not be the identity")
+	}
+
+	sk.Free()
+}
+
+func TestG2Element(t *testing.T) {
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sig := bls.BasicScheme{}.Sign(sk, payload)
+
+	for _, data := range [][]byte{sig.Serialize(), sig.SerializeUncompressed()} {
+		decoded, err := bls.G2ElementFromBytes(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if decoded != sig || !decoded.Equal(sig) {
+			t.Errorf("got %x, expected %x", decoded.Serialize(), sig.Serialize())
+		}
+	}
+	if sig.IsIdentity() {
+		t.Error("sig should not be the identity")
+	}
+
+	sk.Free()
+}
+
+func TestElementIdentity(t *testing.T) {
+	var g1 bls.G1Element
+	if !g1.IsIdentity() {
+		t.Error("the zero value should be the identity")
+	}
+	compressed := append([]byte{0xc0}, make([]byte, bls.G1ElementSize-1)...)
+	uncompressed := append([]byte{0x40}, make([]byte, bls.G1ElementUncompressedSize-1)...)
+	if !bytes.Equal(g1.Serialize(), compressed) {
+		t.Errorf("got %x, expected %x", g1.Serialize(), compressed)
+	}
+	if !bytes.Equal(g1.SerializeUncompressed(), uncompressed) {
+		t.Errorf("got %x, expected %x", g1.SerializeUncompressed(), uncompressed)
+	}
+	for _, data := range [][]byte{compressed, uncompressed} {
+		decoded, err := bls.G1ElementFromBytes(data)
+		if err != nil || !decoded.IsIdentity() {
+			t.Errorf("got %x, %v, expected the identity", decoded.Serialize(), err)
+		}
+	}
+
+	var g2 bls.G2Element
+	compressed = append([]byte{0xc0}, make([]byte, bls.G2ElementSize-1)...)
+	if !bytes.Equal(g2.Serialize(), compressed) {
+		t.Errorf("got %x, expected %x", g2.Serialize(), compressed)
+	}
+	decoded, err := bls.G2ElementFromBytes(compressed)
+	if err != nil || !decoded.IsIdentity() {
+		t.Errorf("got %x, %v, expected the identity", decoded.Serialize(), err)
+	}
+}
+
+func TestElementInvalid(t *testing.T) {
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	pk := sk.G1Element()
+	sig := bls.BasicScheme{}.Sign(sk, payload)
+
+	// g1Point returns a compressed G1 encoding with the given x
+	g1Point := func(x byte) []byte {
+		data := make([]byte, bls.G1ElementSize)
+		data[0] = 0x80
+		data[len(data)-1] = x
+		return data
+	}
+	// g2Point returns a compressed G2 encoding with x = x_c0
+	g2Point := func(x byte) []byte {
+		data := make([]byte, bls.G2ElementSize)
+		data[0] = 0x80
+		data[len(data)-1] = x
+		return data
+	}
+
+	_, err := bls.G1ElementFromBytes(pk.Serialize()[1:])
+	expectError(t, err, bls.ErrLengthMismatch)
+	// The compression flag doesn't match the length
+	noFlag := pk.Serialize()
+	noFlag[0] &^= 0x80
+	_, err = bls.G1ElementFromBytes(noFlag)
+	expectError(t, err, bls.ErrInvalidPublicKey)
+	// x is not smaller than p
+	_, err = bls.G1ElementFromBytes(append([]byte{0x9f}, bytes.Repeat([]byte{0xff}, 47)...))
+	expectError(t, err, bls.ErrInvalidPublicKey)
+	// x^3 + 4 is not a square
+	_, err = bls.G1ElementFromBytes(g1Point(1))
+	expectError(t, err, bls.ErrInvalidPublicKey)
+	// (0, 2) has order 3
+	_, err = bls.G1ElementFromBytes(g1Point(0))
+	expectError(t, err, bls.ErrKeyNotInSubgroup)
+	_, err = bls.G1ElementFromBytes(g1Point(4))
+	expectError(t, err, bls.ErrKeyNotInSubgroup)
+	// The infinity flag with a non-zero x
+	infinity := g1Point(1)
+	infinity[0] = 0xc0
+	_, err = bls.G1ElementFromBytes(infinity)
+	expectError(t, err, bls.ErrInvalidPublicKey)
+	// The sign flag on an uncompressed point
+	uncompressed := pk.SerializeUncompressed()
+	uncompressed[0] |= 0x20
+	_, err = bls.G1ElementFromBytes(uncompressed)
+	expectError(t, err, bls.ErrInvalidPublicKey)
+	// y doesn't match x
+	uncompressed = pk.SerializeUncompressed()
+	uncompressed[len(uncompressed)-1] ^= 1
+	_, err = bls.G1ElementFromBytes(uncompressed)
+	expectError(t, err, bls.ErrInvalidPublicKey)
+
+	_, err = bls.G2ElementFromBytes(sig.Serialize()[1:])
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.G2ElementFromBytes(g2Point(0))
+	expectError(t, err, bls.ErrInvalidSignature)
+	_, err = bls.G2ElementFromBytes(g2Point(2))
+	expectError(t, err, bls.ErrInvalidSignature)
+	uncompressed = sig.SerializeUncompressed()
+	uncompressed[len(uncompressed)-1] ^= 1
+	_, err = bls.G2ElementFromBytes(uncompressed)
+	expectError(t, err, bls.ErrInvalidSignature)
+	// The legacy signature types parse points outside the subgroup, which
+	// must not convert to a G2Element
+	insecureSig, err := bls.InsecureSignatureFromBytes(g2Point(4))
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	_, err = insecureSig.G2Element()
+	expectError(t, err, bls.ErrInvalidSignature)
+
+	insecureSig.Free()
+	sk.Free()
+}
+
+// rMinusOne is the largest scalar, the group order minus one
+const rMinusOne = "73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000000"
+
+func TestG1ElementArithmetic(t *testing.T) {
+	g := bls.G1Generator()
+	two := bls.FrFromUint64(2)
+	three := bls.FrFromUint64(3)
+	data, _ := hex.DecodeString(rMinusOne)
+	minusOne, _ := bls.FrFromBytes(data)
+
+	if got, expected := g.Mul(two), g.Add(g); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	if got, expected := g.Mul(two).Add(g), g.Mul(three); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	if got, expected := g.Mul(minusOne), g.Neg(); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	if got := g.Add(g.Neg()); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+	if got := g.Mul(bls.Fr{}); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+
+	var identity bls.G1Element
+	if got := identity.Add(g); got != g {
+		t.Errorf("got %x, expected %x", got.Serialize(), g.Serialize())
+	}
+	if got := identity.Neg(); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+	if got := identity.Mul(three); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+
+	// The public key is the generator multiplied by the secret
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	if got, expected := g.Mul(sk.Fr()), sk.G1Element(); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	sk.Free()
+}
+
+func TestG2ElementArithmetic(t *testing.T) {
+	g := bls.G2Generator()
+	two := bls.FrFromUint64(2)
+	three := bls.FrFromUint64(3)
+	data, _ := hex.DecodeString(rMinusOne)
+	minusOne, _ := bls.FrFromBytes(data)
+
+	if got, expected := g.Mul(two), g.Add(g); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	if got, expected := g.Mul(two).Add(g), g.Mul(three); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	if got, expected := g.Mul(minusOne), g.Neg(); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	if got := g.Add(g.Neg()); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+	if got := g.Mul(bls.Fr{}); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+
+	var identity bls.G2Element
+	if got := identity.Add(g); got != g {
+		t.Errorf("got %x, expected %x", got.Serialize(), g.Serialize())
+	}
+	if got := identity.Mul(three); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+
+	// A signature is the hash of the message multiplied by the secret
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	h, _ := bls.HashToG2(payload, []byte(bls.BasicSchemeDST))
+	if got, expected := h.Mul(sk.Fr()), (bls.BasicScheme{}).Sign(sk, payload); got != expected {
+		t.Errorf("got %x, expected %x", got.Serialize(), expected.Serialize())
+	}
+	sk.Free()
+}
+
+func TestElementConversion(t *testing.T) {
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+
+	pk := sk.PublicKey()
+	p := pk.G1Element()
+	if expected := sk.G1Element(); p != expected {
+		t.Errorf("got %x, expected %x", p.Serialize(), expected.Serialize())
+	}
+	if got := p.PublicKey(); !got.Equal(pk) {
+		t.Errorf("got %x, expected %x", got.Serialize(), pk.Serialize())
+	}
+
+	sig := sk.SignInsecure(payload)
+	q, err := sig.G2Element()
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if expected := bls.HashToG2Legacy(payload).Mul(sk.Fr()); q != expected {
+		t.Errorf("got %x, expected %x", q.Serialize(), expected.Serialize())
+	}
+	if got := q.InsecureSignature(); !got.Equal(sig) {
+		t.Errorf("got %x, expected %x", got.Serialize(), sig.Serialize())
+	}
+
+	// The point at infinity survives the round trip, although the library
+	// can't serialize it
+	var identity1 bls.G1Element
+	if got := identity1.PublicKey().G1Element(); !got.IsIdentity() {
+		t.Errorf("got %x, expected the identity", got.Serialize())
+	}
+	var identity2 bls.G2Element
+	if got, err := identity2.InsecureSignature().G2Element(); err != nil || !got.IsIdentity() {
+		t.Errorf("got %x %v, expected the identity", got.Serialize(), err)
+	}
+
+	// Signatures made with the group element types verify with the legacy
+	// ones
+	converted := bls.HashToG2Legacy(payload).Mul(sk.Fr()).InsecureSignature()
+	if !converted.Verify([][]byte{Sha256(payload)}, []bls.PublicKey{p.PublicKey()}) {
+		t.Error("converted signature should verify")
+	}
+
+	sk.Free()
+}
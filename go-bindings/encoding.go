@@ -0,0 +1,44 @@
+package blschia
+
+import (
+	"encoding/hex"
+	"encoding/json"
+)
+
+// jsonNull is the JSON encoding of a zero value key or signature
+var jsonNull = []byte("null")
+
+// marshalHex returns the hex encoding of data, for use by MarshalText
+func marshalHex(data []byte) []byte {
+	text := make([]byte, hex.EncodedLen(len(data)))
+	hex.Encode(text, data)
+	return text
+}
+
+// unmarshalHex decodes hex text, for use by UnmarshalText
+func unmarshalHex(text []byte) ([]byte, error) {
+	data := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(data, text); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// marshalJSON returns data encoded as a JSON string holding its hex encoding
+func marshalJSON(data []byte) ([]byte, error) {
+	return json.Marshal(string(marshalHex(data)))
+}
+
+// unmarshalJSON decodes a JSON string into the text it holds. It returns nil
+// text for a JSON null, which callers treat as a no-op, like encoding/json
+// does.
+func unmarshalJSON(data []byte) ([]byte, error) {
+	if string(data) == string(jsonNull) {
+		return nil, nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
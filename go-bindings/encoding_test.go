@@ -0,0 +1,201 @@
+package blschia_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+// marshaler is implemented by all key and signature types
+type marshaler interface {
+	encoding.BinaryMarshaler
+	encoding.TextMarshaler
+	json.Marshaler
+}
+
+// unmarshaler is implemented by pointers to all key and signature types
+type unmarshaler interface {
+	encoding.BinaryUnmarshaler
+	encoding.TextUnmarshaler
+	json.Unmarshaler
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	xprv := bls.ExtendedPrivateKeyFromSeed(xprvSeed)
+	xpub := xprv.GetExtendedPublicKey()
+
+	tests := []struct {
+		name string
+		v    marshaler
+		new  func() unmarshaler
+		data []byte
+	}{
+		{"PublicKey", sk1.PublicKey(), func() unmarshaler { return &bls.PublicKey{} }, pk1Bytes},
+		{"PrivateKey", sk1, func() unmarshaler { return &bls.PrivateKey{} }, sk1Bytes},
+		{"Signature", sk1.Sign(payload), func() unmarshaler { return &bls.Signature{} }, sig1Bytes},
+		{"InsecureSignature", sk1.SignInsecure(payload), func() unmarshaler { return &bls.InsecureSignature{} }, nil},
+		{"ExtendedPublicKey", xpub, func() unmarshaler { return &bls.ExtendedPublicKey{} }, nil},
+		{"ExtendedPrivateKey", xprv, func() unmarshaler { return &bls.ExtendedPrivateKey{} }, nil},
+		{"ChainCode", xprv.GetChainCode(), func() unmarshaler { return &bls.ChainCode{} }, nil},
+		{"G1Element", sk1.G1Element(), func() unmarshaler { return &bls.G1Element{} }, nil},
+		{"G2Element", bls.BasicScheme{}.Sign(sk1, payload), func() unmarshaler { return &bls.G2Element{} }, nil},
+		{"GTElement", bls.GTGenerator(), func() unmarshaler { return &bls.GTElement{} }, nil},
+		{"Fr", sk1.Fr(), func() unmarshaler { return &bls.Fr{} }, sk1Bytes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin, err := tt.v.MarshalBinary()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.data != nil && !bytes.Equal(bin, tt.data) {
+				t.Errorf("got %v, expected %v", bin, tt.data)
+			}
+
+			text, err := tt.v.MarshalText()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := hex.EncodeToString(bin); string(text) != expected {
+				t.Errorf("got %s, expected %s", text, expected)
+			}
+
+			js, err := tt.v.MarshalJSON()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := `"` + hex.EncodeToString(bin) + `"`; string(js) != expected {
+				t.Errorf("got %s, expected %s", js, expected)
+			}
+
+			decoders := map[string]func(u unmarshaler) error{
+				"binary": func(u unmarshaler) error { return u.UnmarshalBinary(bin) },
+				"text":   func(u unmarshaler) error { return u.UnmarshalText(text) },
+				"json":   func(u unmarshaler) error { return u.UnmarshalJSON(js) },
+			}
+			for kind, decode := range decoders {
+				u := tt.new()
+				if err := decode(u); err != nil {
+					t.Fatalf("%s: unexpected error: %v", kind, err)
+				}
+				got, _ := u.(encoding.BinaryMarshaler).MarshalBinary()
+				if !bytes.Equal(got, bin) {
+					t.Errorf("%s: got %v, expected %v", kind, got, bin)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodingJSONStruct(t *testing.T) {
+	type record struct {
+		PublicKey bls.PublicKey
+		Signature bls.Signature
+		Missing   bls.PublicKey
+	}
+
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	in := record{PublicKey: sk1.PublicKey(), Signature: sk1.Sign(payload)}
+	js, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"PublicKey":"` + hex.EncodeToString(pk1Bytes) +
+		`","Signature":"` + hex.EncodeToString(sig1Bytes) +
+		`","Missing":null}`
+	if string(js) != expected {
+		t.Errorf("got %s, expected %s", js, expected)
+	}
+
+	var out record
+	if err := json.Unmarshal(js, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !out.PublicKey.Equal(in.PublicKey) {
+		t.Error("public keys should be equal")
+	}
+	if !out.Signature.Equal(in.Signature) {
+		t.Error("signatures should be equal")
+	}
+	out.Signature.SetAggregationInfo(in.Signature.GetAggregationInfo())
+	if !out.Signature.Verify() {
+		t.Error("unmarshalled signature should verify")
+	}
+
+	sk1.Free()
+}
+
+// The zero values of the types holding C memory can't be encoded, except as a
+// JSON null
+func TestEncodingZeroValue(t *testing.T) {
+	for _, v := range []marshaler{
+		bls.PublicKey{},
+		bls.PrivateKey{},
+		bls.Signature{},
+		bls.InsecureSignature{},
+		bls.ExtendedPublicKey{},
+		bls.ExtendedPrivateKey{},
+		bls.ChainCode{},
+	} {
+		_, err := v.MarshalBinary()
+		expectError(t, err, bls.ErrZeroValue)
+		_, err = v.MarshalText()
+		expectError(t, err, bls.ErrZeroValue)
+		if js, err := v.MarshalJSON(); err != nil || string(js) != "null" {
+			t.Errorf("got %s %v, expected null", js, err)
+		}
+	}
+
+	type record struct {
+		PublicKey bls.PublicKey
+	}
+	if _, err := xml.Marshal(record{}); !errors.Is(err, bls.ErrZeroValue) {
+		t.Errorf("got %v, expected %v", err, bls.ErrZeroValue)
+	}
+}
+
+func TestEncodingInvalid(t *testing.T) {
+	var pk bls.PublicKey
+	expectError(t, pk.UnmarshalBinary(pk1Bytes[:47]), bls.ErrLengthMismatch)
+	expectError(t, pk.UnmarshalText([]byte(hex.EncodeToString(make([]byte, 48)))), bls.ErrKeyNotInSubgroup)
+	expectError(t, pk.UnmarshalJSON([]byte(`"`+hex.EncodeToString(bytes.Repeat([]byte{0x1f}, 48))+`"`)), bls.ErrInvalidPublicKey)
+	if err := pk.UnmarshalText([]byte("not hex")); err == nil {
+		t.Error("did not get expected error")
+	}
+	if err := pk.UnmarshalJSON([]byte("42")); err == nil {
+		t.Error("did not get expected error")
+	}
+
+	var sk bls.PrivateKey
+	expectError(t, sk.UnmarshalBinary(bytes.Repeat([]byte{0xff}, 32)), bls.ErrInvalidPrivateKey)
+
+	var sig bls.Signature
+	expectError(t, sig.UnmarshalText([]byte("")), bls.ErrLengthMismatch)
+
+	var xpub bls.ExtendedPublicKey
+	xpubBad := append([]byte{}, xpubBytes...)
+	copy(xpubBad[len(xpubBad)-48:], make([]byte, 48))
+	expectError(t, xpub.UnmarshalBinary(xpubBad), bls.ErrKeyNotInSubgroup)
+
+	var xprv bls.ExtendedPrivateKey
+	expectError(t, xprv.UnmarshalBinary(xpubBytes), bls.ErrLengthMismatch)
+
+	var cc bls.ChainCode
+	expectError(t, cc.UnmarshalBinary(sk1Bytes[1:]), bls.ErrLengthMismatch)
+
+	// A null leaves the value unchanged
+	pk, _ = bls.PublicKeyFromBytes(pk1Bytes)
+	if err := pk.UnmarshalJSON([]byte("null")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(pk.Serialize(), pk1Bytes) {
+		t.Error("null should not change the key")
+	}
+}
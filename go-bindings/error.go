@@ -0,0 +1,189 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// Sentinel errors describing why a call failed. Errors returned by this
+// package wrap one of these, so callers can test for them with errors.Is.
+var (
+	// ErrInvalidPublicKey is returned when bytes can't be decoded into an
+	// public key.
+	ErrInvalidPublicKey = errors.New("invalid public key")
+
+	// ErrInvalidPrivateKey is returned when bytes can't be decoded into an
+	// private key, e.g. when the value is not smaller than the group order.
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+
+	// ErrInvalidSignature is returned when bytes can't be decoded into an
+	// signature, or when signatures can't be combined.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrKeyNotInSubgroup is returned when a public key is a valid curve
+	// point, but does not lie in the prime order subgroup of G1.
+	ErrKeyNotInSubgroup = errors.New("public key is not in the G1 subgroup")
+
+	// ErrInvalidAggregationInfo is returned when bytes can't be decoded into
+	// an AggregationInfo object.
+	ErrInvalidAggregationInfo = errors.New("invalid aggregation info")
+
+	// ErrEmptyAggregation is returned when asked to aggregate an empty list.
+	ErrEmptyAggregation = errors.New("nothing to aggregate")
+
+	// ErrDivisionNotSubset is returned by Signature.DivideBy when a divisor
+	// contains msg/pk pairs which are not part of the dividend.
+	ErrDivisionNotSubset = errors.New("divisor is not a subset of the signature")
+
+	// ErrDivisionNotUnique is returned by Signature.DivideBy when the msg/pk
+	// pairs of a divisor are not unique within the dividend.
+	ErrDivisionNotUnique = errors.New("cannot divide, msg/pk pairs are not unique")
+
+	// ErrLengthMismatch is returned when an input has the wrong length, or
+	// when slices which must be of the same length are not.
+	ErrLengthMismatch = errors.New("length mismatch")
+
+	// ErrInvalidThreshold is returned when a threshold parameter T is not
+	// between 1 and the number of players N.
+	ErrInvalidThreshold = errors.New("invalid threshold parameter")
+
+	// ErrInvalidPlayers is returned when threshold player indices are not
+	// positive and distinct, or when a player is not one of the signers.
+	ErrInvalidPlayers = errors.New("invalid player indices")
+
+	// ErrInvalidPath is returned when a derivation path can't be parsed, or
+	// is too deep to derive.
+	ErrInvalidPath = errors.New("invalid derivation path")
+
+	// ErrHardenedDerivation is returned when asked to derive a hardened child
+	// from an extended public key.
+	ErrHardenedDerivation = errors.New("cannot derive hardened children from a public key")
+
+	// ErrInvalidBase58 is returned when a string is not valid Base58Check,
+	// e.g. because it contains characters outside the Base58 alphabet.
+	ErrInvalidBase58 = errors.New("invalid base58 encoding")
+
+	// ErrChecksumMismatch is returned when the checksum of a Base58Check
+	// string does not match its payload, e.g. because of a typo.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrUnknownNetwork is returned when decoding an extended key whose
+	// version prefix belongs to no registered Network, and when registering
+	// a Network whose prefixes are already in use.
+	ErrUnknownNetwork = errors.New("unknown network")
+
+	// ErrInvalidMnemonic is returned when a mnemonic sentence has the wrong
+	// number of words, or a word which is not in the wordlist.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrInvalidKeystore is returned when an EIP-2335 keystore is malformed,
+	// or uses a version, function or parameters which are not supported.
+	ErrInvalidKeystore = errors.New("invalid keystore")
+
+	// ErrInvalidPassword is returned when the checksum of a keystore does not
+	// match, which means the password is wrong or the keystore corrupted.
+	ErrInvalidPassword = errors.New("invalid keystore password")
+
+	// ErrInvalidScalar is returned when bytes can't be decoded into an
+	// Fr, i.e. when the value is not smaller than the group order.
+	ErrInvalidScalar = errors.New("invalid scalar")
+
+	// ErrInvalidGTElement is returned when bytes can't be decoded into an
+	// element of GT.
+	ErrInvalidGTElement = errors.New("invalid GT element")
+
+	// ErrVerifierClosed is the Result error for verifications submitted to a
+	// Verifier after it was closed.
+	ErrVerifierClosed = errors.New("verifier is closed")
+
+	// ErrAllocatorUnsupported is returned by SetSecureAllocator when the
+	// allocator is not available on this platform.
+	ErrAllocatorUnsupported = errors.New("secure allocator not supported")
+
+	// ErrZeroValue is returned when marshaling the zero value of a type
+	// which holds C memory, as it has no encoding.
+	ErrZeroValue = errors.New("zero value")
+)
+
+// Error is the error type returned by this package. It carries the message
+// reported by the underlying library (or by the bindings themselves) and
+// wraps the sentinel error describing the failure.
+type Error struct {
+	// Err is one of the sentinel errors above
+	Err error
+	// Msg is the underlying error message
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error() + ": " + e.Msg
+}
+
+// Unwrap returns the sentinel error, for use with errors.Is and errors.As.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errFromC converts an error message returned by a C call into a Go error
+// wrapping kind, and releases the memory allocated for the message.
+func errFromC(kind error, cErrMsg *C.char) *Error {
+	defer C.free(unsafe.Pointer(cErrMsg))
+	return &Error{Err: kind, Msg: C.GoString(cErrMsg)}
+}
+
+// errDivideBy converts an error message returned by CSignatureDivideBy into a
+// Go error. The library throws the same exception type whether the divisor
+// is not a subset or its msg/pk pairs are not unique, so the message has to
+// be inspected.
+func errDivideBy(cErrMsg *C.char) error {
+	err := errFromC(ErrDivisionNotSubset, cErrMsg)
+	if strings.Contains(err.Msg, "not unique") {
+		err.Err = ErrDivisionNotUnique
+	}
+	return err
+}
+
+// errNotInSubgroup returns the error for a public key which failed the G1
+// subgroup check.
+func errNotInSubgroup() error {
+	return &Error{
+		Err: ErrKeyNotInSubgroup,
+		Msg: "multiplying by the group order does not give the identity",
+	}
+}
+
+// errG2NotInSubgroup returns the error for a signature which failed the G2
+// subgroup check.
+func errG2NotInSubgroup() error {
+	return &Error{
+		Err: ErrInvalidSignature,
+		Msg: "point is not in the G2 subgroup",
+	}
+}
+
+// errZeroValue returns the error for marshaling the zero value of the type
+// named what.
+func errZeroValue(what string) error {
+	return &Error{
+		Err: ErrZeroValue,
+		Msg: fmt.Sprintf("cannot marshal a zero %s", what),
+	}
+}
+
+// checkLength returns an ErrLengthMismatch error if data is not exactly size
+// bytes long.
+func checkLength(what string, data []byte, size int) error {
+	if len(data) != size {
+		return &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("%s must be %d bytes, got %d", what, size, len(data)),
+		}
+	}
+	return nil
+}
@@ -14,8 +14,14 @@
 
 #ifndef GO_BINDINGS_ERROR_H_
 #define GO_BINDINGS_ERROR_H_
+#include <exception>
 #include <string>
 
-extern std::string gErrMsg;
+// Copies the exception message into a newly allocated C string and stores it
+// in errMsg. Each call gets its own copy, which the caller must free.
+void SetErrorMsg(char **errMsg, const std::exception& ex);
+
+// Some library functions throw plain strings rather than exceptions
+void SetErrorMsg(char **errMsg, const std::string& ex);
 
 #endif  // GO_BINDINGS_ERROR_H_
@@ -0,0 +1,181 @@
+package blschia_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+// Run with -race: every failing call must get its own error message, even
+// when many of them fail concurrently.
+func TestErrorConcurrency(t *testing.T) {
+	badPkBytes := bytes.Repeat([]byte{0x1f}, 48)
+	badSigBytes := bytes.Repeat([]byte{0xff}, 96)
+
+	_, pkErr := bls.PublicKeyFromBytes(badPkBytes)
+	if pkErr == nil {
+		t.Fatal("did not get expected error")
+	}
+	_, sigErr := bls.SignatureFromBytes(badSigBytes)
+	if sigErr == nil {
+		t.Fatal("did not get expected error")
+	}
+	if pkErr.Error() == sigErr.Error() {
+		t.Fatal("expected distinct error messages")
+	}
+
+	const numWorkers = 500
+	var wg sync.WaitGroup
+	errs := make(chan string, numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				_, err := bls.PublicKeyFromBytes(badPkBytes)
+				if err == nil || err.Error() != pkErr.Error() {
+					errs <- "PublicKeyFromBytes: unexpected error " + errString(err)
+				}
+			} else {
+				_, err := bls.SignatureFromBytes(badSigBytes)
+				if err == nil || err.Error() != sigErr.Error() {
+					errs <- "SignatureFromBytes: unexpected error " + errString(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for msg := range errs {
+		t.Error(msg)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+func TestErrorSentinels(t *testing.T) {
+	_, err := bls.PublicKeyFromBytes(pk1Bytes[:47])
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.PublicKeyFromBytes(bytes.Repeat([]byte{0x1f}, 48))
+	expectError(t, err, bls.ErrInvalidPublicKey)
+	// (0, 2) is on the curve, but not in the G1 subgroup
+	_, err = bls.PublicKeyFromBytes(make([]byte, 48))
+	expectError(t, err, bls.ErrKeyNotInSubgroup)
+
+	_, err = bls.PrivateKeyFromBytes(sk1Bytes[1:], false)
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.PrivateKeyFromBytes(bytes.Repeat([]byte{0xff}, 32), false)
+	expectError(t, err, bls.ErrInvalidPrivateKey)
+
+	_, err = bls.SignatureFromBytes(bytes.Repeat([]byte{0xff}, 96))
+	expectError(t, err, bls.ErrInvalidSignature)
+	_, err = bls.SignatureAggregate(nil)
+	expectError(t, err, bls.ErrEmptyAggregation)
+	_, err = bls.InsecureSignatureAggregate([]bls.InsecureSignature{})
+	expectError(t, err, bls.ErrEmptyAggregation)
+	_, err = bls.PublicKeyAggregate(nil)
+	expectError(t, err, bls.ErrEmptyAggregation)
+
+	pk1, _ := bls.PublicKeyFromBytes(pk1Bytes)
+	_, err = bls.AggregationInfoFromSlices([]bls.PublicKey{pk1}, nil, nil)
+	expectError(t, err, bls.ErrLengthMismatch)
+
+	ai := bls.AggregationInfoFromMsg(pk1, payload)
+	err = ai.RemoveEntries([][]byte{Sha256(payload)}, nil)
+	expectError(t, err, bls.ErrLengthMismatch)
+
+	ai.Free()
+	pk1.Free()
+}
+
+func TestErrorDivideBy(t *testing.T) {
+	m1 := []byte{1, 2, 3, 40}
+	m2 := []byte{5, 6, 70, 201}
+	m3 := []byte{9, 10, 11, 12, 13}
+
+	sk1 := bls.PrivateKeyFromSeed([]byte{1, 2, 3, 4, 5})
+	sk2 := bls.PrivateKeyFromSeed([]byte{1, 2, 3, 4, 5, 6})
+
+	sig1 := sk1.Sign(m1)
+	sig2 := sk2.Sign(m2)
+	sig3 := sk2.Sign(m1)
+	sig4 := sk1.Sign(m3)
+
+	aggSigL, _ := bls.SignatureAggregate([]bls.Signature{sig1, sig2})
+	_, err := aggSigL.DivideBy([]bls.Signature{sig4})
+	expectError(t, err, bls.ErrDivisionNotSubset)
+
+	// sk1 signs m1 on both sides, so the pairs of aggSigL end up with
+	// different exponents in the final aggregate
+	sig5 := sk1.Sign(m1)
+	aggSigR, _ := bls.SignatureAggregate([]bls.Signature{sig3, sig4, sig5})
+	aggSig, _ := bls.SignatureAggregate([]bls.Signature{aggSigL, aggSigR})
+	_, err = aggSig.DivideBy([]bls.Signature{aggSigL})
+	expectError(t, err, bls.ErrDivisionNotUnique)
+
+	aggSig.Free()
+	aggSigR.Free()
+	sig5.Free()
+	aggSigL.Free()
+	sig4.Free()
+	sig3.Free()
+	sig2.Free()
+	sig1.Free()
+	sk2.Free()
+	sk1.Free()
+}
+
+func TestErrorAs(t *testing.T) {
+	_, err := bls.PublicKeyFromBytes(bytes.Repeat([]byte{0x1f}, 48))
+
+	var blsErr *bls.Error
+	if !errors.As(err, &blsErr) {
+		t.Fatalf("expected a *bls.Error, got %T", err)
+	}
+	if blsErr.Err != bls.ErrInvalidPublicKey {
+		t.Errorf("got %v, expected %v", blsErr.Err, bls.ErrInvalidPublicKey)
+	}
+	if blsErr.Msg != "Relic library error" {
+		t.Errorf("got %v, expected %v", blsErr.Msg, "Relic library error")
+	}
+}
+
+func TestAggregationInfoFromSlices(t *testing.T) {
+	pk1, _ := bls.PublicKeyFromBytes(pk1Bytes)
+	hash := Sha256(payload)
+
+	ai, err := bls.AggregationInfoFromSlices([]bls.PublicKey{pk1},
+		[][]byte{hash}, []bls.Fr{bls.FrFromUint64(1)})
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err.Error())
+	}
+	expected := bls.AggregationInfoFromMsgHash(pk1, hash)
+	if !ai.Equal(expected) {
+		t.Error("ai should be equal to expected")
+	}
+
+	// A zero exponent can't be serialized, so it is rejected
+	_, err = bls.AggregationInfoFromSlices([]bls.PublicKey{pk1},
+		[][]byte{hash}, []bls.Fr{{}})
+	expectError(t, err, bls.ErrInvalidAggregationInfo)
+
+	expected.Free()
+	ai.Free()
+	pk1.Free()
+}
+
+func expectError(t *testing.T, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("got %v, expected %v", errString(err), target)
+	}
+}
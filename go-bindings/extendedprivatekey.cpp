@@ -17,6 +17,7 @@
 #include "privatekey.h"
 #include "publickey.h"
 #include "chaincode.h"
+#include "error.h"
 
 CExtendedPrivateKey CExtendedPrivateKeyFromSeed(void *seed, size_t len) {
     bls::ExtendedPrivateKey* key = new bls::ExtendedPrivateKey(
@@ -25,10 +26,17 @@ CExtendedPrivateKey CExtendedPrivateKeyFromSeed(void *seed, size_t len) {
     return key;
 }
 
-CExtendedPrivateKey CExtendedPrivateKeyFromBytes(void *p) {
-    bls::ExtendedPrivateKey* key = new bls::ExtendedPrivateKey(
-        bls::ExtendedPrivateKey::FromBytes(static_cast<uint8_t*>(p))
-    );
+CExtendedPrivateKey CExtendedPrivateKeyFromBytes(void *p, char **errMsg) {
+    bls::ExtendedPrivateKey* key;
+    try {
+        key = new bls::ExtendedPrivateKey(
+            bls::ExtendedPrivateKey::FromBytes(static_cast<uint8_t*>(p))
+        );
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
     return key;
 }
 
@@ -37,14 +45,11 @@ void CExtendedPrivateKeyFree(CExtendedPrivateKey inPtr) {
     delete key;
 }
 
-void* CExtendedPrivateKeySerialize(CExtendedPrivateKey inPtr) {
+// Serialize straight into the caller's buffer, so that no copy of the key is
+// left behind
+void CExtendedPrivateKeySerialize(CExtendedPrivateKey inPtr, void *buffer) {
     bls::ExtendedPrivateKey* key = (bls::ExtendedPrivateKey*)inPtr;
-
-    uint8_t* buffer = bls::Util::SecAlloc<uint8_t>(
-        bls::ExtendedPrivateKey::EXTENDED_PRIVATE_KEY_SIZE);
-
-    key->Serialize(buffer);
-    return static_cast<void*>(buffer);
+    key->Serialize(static_cast<uint8_t*>(buffer));
 }
 
 int CExtendedPrivateKeySizeBytes() {
@@ -9,122 +9,272 @@ package blschia
 // #include "publickey.h"
 // #include "blschia.h"
 import "C"
-import "runtime"
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// ExtendedPrivateKeySize is the size in bytes of a serialized extended
+// private key
+const ExtendedPrivateKeySize = 77
 
 // ExtendedPrivateKey represents a BIP-32 style extended key, which is composed
 // of a private key and a chain code.
 type ExtendedPrivateKey struct {
+	*extendedPrivateKey
+}
+
+// extendedPrivateKey owns the C object. It is shared by all copies of the
+// ExtendedPrivateKey, so the finalizer only runs once none of them is
+// reachable.
+type extendedPrivateKey struct {
 	key C.CExtendedPrivateKey
 }
 
+func newExtendedPrivateKey(ptr C.CExtendedPrivateKey) ExtendedPrivateKey {
+	key := &extendedPrivateKey{key: ptr}
+	runtime.SetFinalizer(key, (*extendedPrivateKey).free)
+	return ExtendedPrivateKey{key}
+}
+
+func (key *extendedPrivateKey) free() {
+	if key.key != nil {
+		C.CExtendedPrivateKeyFree(key.key)
+		key.key = nil
+	}
+}
+
 // ExtendedPrivateKeyFromSeed generates a master private key and chain code
 // from a seed
 func ExtendedPrivateKeyFromSeed(seed []byte) ExtendedPrivateKey {
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(seed)
-	defer C.free(cBytesPtr)
-
-	var key ExtendedPrivateKey
-	key.key = C.CExtendedPrivateKeyFromSeed(cBytesPtr, C.size_t(len(seed)))
-	runtime.SetFinalizer(&key, func(p *ExtendedPrivateKey) { p.Free() })
+	defer freeSecret(cBytesPtr, len(seed))
 
-	return key
+	return newExtendedPrivateKey(C.CExtendedPrivateKeyFromSeed(cBytesPtr, C.size_t(len(seed))))
 }
 
 // ExtendedPrivateKeyFromBytes parses a private key and chain code from bytes
-func ExtendedPrivateKeyFromBytes(data []byte) ExtendedPrivateKey {
+func ExtendedPrivateKeyFromBytes(data []byte) (ExtendedPrivateKey, error) {
+	if err := checkLength("extended private key", data, int(C.CExtendedPrivateKeySizeBytes())); err != nil {
+		return ExtendedPrivateKey{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
-	defer C.free(cBytesPtr)
+	defer freeSecret(cBytesPtr, len(data))
 
-	var key ExtendedPrivateKey
-	key.key = C.CExtendedPrivateKeyFromBytes(cBytesPtr)
-	runtime.SetFinalizer(&key, func(p *ExtendedPrivateKey) { p.Free() })
+	var cErrMsg *C.char
+	cKey := C.CExtendedPrivateKeyFromBytes(cBytesPtr, &cErrMsg)
+	if cErrMsg != nil {
+		return ExtendedPrivateKey{}, errFromC(ErrInvalidPrivateKey, cErrMsg)
+	}
 
-	return key
+	return newExtendedPrivateKey(cKey), nil
 }
 
-// Free releases memory allocated by the key
+// Free releases memory allocated by the key. All copies of the key share that
+// memory, so none of them may be used afterwards. Calling Free again is a
+// no-op.
 func (key ExtendedPrivateKey) Free() {
-	C.CExtendedPrivateKeyFree(key.key)
+	if key.extendedPrivateKey == nil {
+		return
+	}
+	runtime.SetFinalizer(key.extendedPrivateKey, nil)
+	key.free()
 }
 
 // Serialize returns the serialized byte representation of the
 // ExtendedPrivateKey object
 func (key ExtendedPrivateKey) Serialize() []byte {
-	ptr := C.CExtendedPrivateKeySerialize(key.key)
-	defer C.SecFree(ptr)
-	return C.GoBytes(ptr, C.CExtendedPrivateKeySizeBytes())
+	buf := make([]byte, ExtendedPrivateKeySize)
+	key.SerializeInto(buf)
+	return buf
+}
+
+// SerializeInto writes the byte representation of the extended private key
+// into the first ExtendedPrivateKeySize bytes of buf, so that the caller
+// controls every copy of the key. It returns ErrLengthMismatch if buf is too
+// short.
+func (key ExtendedPrivateKey) SerializeInto(buf []byte) error {
+	defer runtime.KeepAlive(key)
+
+	if len(buf) < ExtendedPrivateKeySize {
+		return &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("buffer must be at least %d bytes, got %d", ExtendedPrivateKeySize, len(buf)),
+		}
+	}
+	C.CExtendedPrivateKeySerialize(key.key, unsafe.Pointer(&buf[0]))
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize. The zero value can't be encoded, so it returns an
+// ErrZeroValue error.
+func (key ExtendedPrivateKey) MarshalBinary() ([]byte, error) {
+	if key.extendedPrivateKey == nil {
+		return nil, errZeroValue("ExtendedPrivateKey")
+	}
+	return key.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by ExtendedPrivateKeyFromBytes.
+func (key *ExtendedPrivateKey) UnmarshalBinary(data []byte) error {
+	parsed, err := ExtendedPrivateKeyFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*key = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the key as hex.
+func (key ExtendedPrivateKey) MarshalText() ([]byte, error) {
+	data, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	defer Wipe(data)
+	return marshalHex(data), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// key.
+func (key *ExtendedPrivateKey) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	defer Wipe(data)
+	return key.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the key as a hex string.
+// The zero value is encoded as null.
+func (key ExtendedPrivateKey) MarshalJSON() ([]byte, error) {
+	if key.extendedPrivateKey == nil {
+		return jsonNull, nil
+	}
+	data := key.Serialize()
+	defer Wipe(data)
+	return marshalJSON(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the key unchanged.
+func (key *ExtendedPrivateKey) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return key.UnmarshalText(text)
 }
 
 // GetPublicKey returns the PublicKey which corresponds to the PrivateKey for
 // the given node
 func (key ExtendedPrivateKey) GetPublicKey() PublicKey {
-	var pk PublicKey
-	pk.pk = C.CExtendedPrivateKeyGetPublicKey(key.key)
-	runtime.SetFinalizer(&pk, func(p *PublicKey) { p.Free() })
-	return pk
+	defer runtime.KeepAlive(key)
+	return newPublicKey(C.CExtendedPrivateKeyGetPublicKey(key.key))
 }
 
 // GetChainCode returns the ChainCode for the given node
 func (key ExtendedPrivateKey) GetChainCode() ChainCode {
-	var cc ChainCode
-	cc.cc = C.CExtendedPrivateKeyGetChainCode(key.key)
-	runtime.SetFinalizer(&cc, func(p *ChainCode) { p.Free() })
-	return cc
+	defer runtime.KeepAlive(key)
+	return newChainCode(C.CExtendedPrivateKeyGetChainCode(key.key))
 }
 
 // PrivateChild derives a child ExtendedPrivateKey
 func (key ExtendedPrivateKey) PrivateChild(i uint32) ExtendedPrivateKey {
+	defer runtime.KeepAlive(key)
+
 	if key.GetDepth() >= 255 {
 		panic("cannot go further than 255 levels")
 	}
-	var child ExtendedPrivateKey
-	child.key = C.CExtendedPrivateKeyPrivateChild(key.key, C.uint(i))
-	runtime.SetFinalizer(&child, func(p *ExtendedPrivateKey) { p.Free() })
-	return child
+	return newExtendedPrivateKey(C.CExtendedPrivateKeyPrivateChild(key.key, C.uint(i)))
+}
+
+// DerivePath parses path with ParsePath and derives it from key, e.g.
+// "m/12381/3600/0'/0". The path is relative to key, which the leading m
+// stands for.
+func (key ExtendedPrivateKey) DerivePath(path string) (ExtendedPrivateKey, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return ExtendedPrivateKey{}, err
+	}
+	return key.Derive(p)
+}
+
+// Derive derives the children in path from key in turn. Unlike PrivateChild,
+// it returns an error rather than panicking if the path is too deep. The
+// empty path returns a copy of key.
+func (key ExtendedPrivateKey) Derive(path Path) (ExtendedPrivateKey, error) {
+	if err := checkDepth(key.GetDepth(), path); err != nil {
+		return ExtendedPrivateKey{}, err
+	}
+	if len(path) == 0 {
+		var buf [ExtendedPrivateKeySize]byte
+		defer Wipe(buf[:])
+		key.SerializeInto(buf[:])
+		return ExtendedPrivateKeyFromBytes(buf[:])
+	}
+
+	child := key
+	for _, index := range path {
+		next := child.PrivateChild(index)
+		// Free the intermediate keys now rather than leaving them to the GC
+		if child != key {
+			child.Free()
+		}
+		child = next
+	}
+	return child, nil
 }
 
 // GetExtendedPublicKey returns the extended public key which corresponds to
 // the extended private key for the given node
 func (key ExtendedPrivateKey) GetExtendedPublicKey() ExtendedPublicKey {
-	var xpub ExtendedPublicKey
-	xpub.key = C.CExtendedPrivateKeyGetExtendedPublicKey(key.key)
-	runtime.SetFinalizer(&xpub, func(p *ExtendedPublicKey) { p.Free() })
-	return xpub
+	defer runtime.KeepAlive(key)
+	return newExtendedPublicKey(C.CExtendedPrivateKeyGetExtendedPublicKey(key.key))
 }
 
 // GetVersion returns the version bytes
 func (key ExtendedPrivateKey) GetVersion() uint32 {
+	defer runtime.KeepAlive(key)
 	return uint32(C.CExtendedPrivateKeyGetVersion(key.key))
 }
 
 // GetDepth returns the depth byte
 func (key ExtendedPrivateKey) GetDepth() uint8 {
+	defer runtime.KeepAlive(key)
 	return uint8(C.CExtendedPrivateKeyGetDepth(key.key))
 }
 
 // GetParentFingerprint returns the parent fingerprint
 func (key ExtendedPrivateKey) GetParentFingerprint() uint32 {
+	defer runtime.KeepAlive(key)
 	return uint32(C.CExtendedPrivateKeyGetParentFingerprint(key.key))
 }
 
 // GetChildNumber returns the child number
 func (key ExtendedPrivateKey) GetChildNumber() uint32 {
+	defer runtime.KeepAlive(key)
 	return uint32(C.CExtendedPrivateKeyGetChildNumber(key.key))
 }
 
 // GetPrivateKey returns the private key at the given node
 func (key ExtendedPrivateKey) GetPrivateKey() PrivateKey {
-	var sk PrivateKey
-	sk.sk = C.CExtendedPrivateKeyGetPrivateKey(key.key)
-	runtime.SetFinalizer(&sk, func(p *PrivateKey) { p.Free() })
-	return sk
+	defer runtime.KeepAlive(key)
+	return newPrivateKey(C.CExtendedPrivateKeyGetPrivateKey(key.key))
 }
 
 // Equal tests if one ExtendedPrivateKey object is equal to another
 //
 // Only the privatekey and chaincode material is tested
 func (key ExtendedPrivateKey) Equal(other ExtendedPrivateKey) bool {
+	defer runtime.KeepAlive(key)
+	defer runtime.KeepAlive(other)
 	return bool(C.CExtendedPrivateKeyIsEqual(key.key, other.key))
 }
@@ -27,7 +27,7 @@ typedef void* CExtendedPrivateKey;
 
 CExtendedPrivateKey CExtendedPrivateKeyFromSeed(void *seed, size_t len);
 
-CExtendedPrivateKey CExtendedPrivateKeyFromBytes(void *p);
+CExtendedPrivateKey CExtendedPrivateKeyFromBytes(void *p, char **errMsg);
 
 CExtendedPrivateKey CExtendedPrivateKeyPrivateChild(CExtendedPrivateKey inPtr,
     uint32_t i);
@@ -50,7 +50,7 @@ CExtendedPublicKey CExtendedPrivateKeyGetExtendedPublicKey(
 bool CExtendedPrivateKeyIsEqual(CExtendedPrivateKey aPtr,
     CExtendedPrivateKey bPtr);
 
-void* CExtendedPrivateKeySerialize(CExtendedPrivateKey inPtr);
+void CExtendedPrivateKeySerialize(CExtendedPrivateKey inPtr, void *buffer);
 void CExtendedPrivateKeyFree(CExtendedPrivateKey inPtr);
 int CExtendedPrivateKeySizeBytes();
 
@@ -27,7 +27,7 @@ func TestExtendedPrivateKey(t *testing.T) {
 		t.Errorf("got %v, expected %v", xprv1Bytes, xprv1Expected)
 	}
 
-	xprv2 := bls.ExtendedPrivateKeyFromBytes(xprv1Bytes)
+	xprv2, _ := bls.ExtendedPrivateKeyFromBytes(xprv1Bytes)
 	if !xprv2.Equal(xprv1) {
 		t.Error("xprv2 should be equal to xprv1")
 	}
@@ -81,3 +81,64 @@ func TestExtendedPrivateKey(t *testing.T) {
 }
 
 var xprvSeed = []byte{0x01, 0x32, 0x06, 0xf4, 0x18, 0xc7, 0x01, 0x19}
+
+func TestExtendedPrivateKeyLifetime(t *testing.T) {
+	xprv := bls.ExtendedPrivateKeyFromSeed(xprvSeed)
+	children := make([]bls.ExtendedPrivateKey, 10)
+	for i := range children {
+		children[i] = xprv.PrivateChild(uint32(i))
+	}
+	xpub := children[3].GetExtendedPublicKey()
+	cc := xpub.GetChainCode()
+	expected := [][]byte{
+		children[3].Serialize(),
+		xpub.Serialize(),
+		cc.Serialize(),
+	}
+
+	xprv.Free()
+	collectGarbage()
+	for i := 0; i < 100; i++ {
+		bls.ExtendedPrivateKeyFromSeed([]byte{byte(i)}).PrivateChild(0)
+	}
+	collectGarbage()
+
+	got := [][]byte{
+		children[3].Serialize(),
+		xpub.Serialize(),
+		cc.Serialize(),
+	}
+	for i := range got {
+		if !bytes.Equal(got[i], expected[i]) {
+			t.Errorf("got %v, expected %v", got[i], expected[i])
+		}
+	}
+
+	cc.Free()
+	xpub.Free()
+	xpub.Free()
+	for _, child := range children {
+		child.Free()
+	}
+}
+
+func TestExtendedPrivateKeySerializeInto(t *testing.T) {
+	xprv := bls.ExtendedPrivateKeyFromSeed(xprvSeed)
+	expected := xprv.Serialize()
+	if len(expected) != bls.ExtendedPrivateKeySize {
+		t.Errorf("got %d bytes, expected %d", len(expected), bls.ExtendedPrivateKeySize)
+	}
+
+	buf := make([]byte, bls.ExtendedPrivateKeySize)
+	if err := xprv.SerializeInto(buf); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("got %v, expected %v", buf, expected)
+	}
+	expectError(t, xprv.SerializeInto(buf[1:]), bls.ErrLengthMismatch)
+
+	bls.Wipe(buf)
+	bls.Wipe(expected)
+	xprv.Free()
+}
@@ -16,11 +16,19 @@
 #include "extendedpublickey.h"
 #include "publickey.h"
 #include "chaincode.h"
-
-CExtendedPublicKey CExtendedPublicKeyFromBytes(void *p) {
-    bls::ExtendedPublicKey* key = new bls::ExtendedPublicKey(
-        bls::ExtendedPublicKey::FromBytes(static_cast<uint8_t*>(p))
-    );
+#include "error.h"
+
+CExtendedPublicKey CExtendedPublicKeyFromBytes(void *p, char **errMsg) {
+    bls::ExtendedPublicKey* key;
+    try {
+        key = new bls::ExtendedPublicKey(
+            bls::ExtendedPublicKey::FromBytes(static_cast<uint8_t*>(p))
+        );
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
     return key;
 }
 
@@ -6,51 +6,157 @@ package blschia
 // #include <stdlib.h>
 // #include "blschia.h"
 import "C"
-import "runtime"
+import (
+	"fmt"
+	"runtime"
+)
+
+// ExtendedPublicKeySize is the size in bytes of a serialized extended public
+// key
+const ExtendedPublicKeySize = 93
 
 // ExtendedPublicKey represents a BIP-32 style extended public key
 type ExtendedPublicKey struct {
+	*extendedPublicKey
+}
+
+// extendedPublicKey owns the C object. It is shared by all copies of the
+// ExtendedPublicKey, so the finalizer only runs once none of them is reachable.
+type extendedPublicKey struct {
 	key C.CExtendedPublicKey
 }
 
-// ExtendedPublicKeyFromBytes parses a public key and chain code from bytes
-func ExtendedPublicKeyFromBytes(data []byte) ExtendedPublicKey {
+func newExtendedPublicKey(ptr C.CExtendedPublicKey) ExtendedPublicKey {
+	key := &extendedPublicKey{key: ptr}
+	runtime.SetFinalizer(key, (*extendedPublicKey).free)
+	return ExtendedPublicKey{key}
+}
+
+func (key *extendedPublicKey) free() {
+	if key.key != nil {
+		C.CExtendedPublicKeyFree(key.key)
+		key.key = nil
+	}
+}
+
+// ExtendedPublicKeyFromBytes parses a public key and chain code from bytes.
+// Like PublicKeyFromBytes, it rejects public keys which are not in the G1
+// subgroup.
+func ExtendedPublicKeyFromBytes(data []byte) (ExtendedPublicKey, error) {
+	if err := checkLength("extended public key", data, int(C.CExtendedPublicKeySizeBytes())); err != nil {
+		return ExtendedPublicKey{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
 	defer C.free(cBytesPtr)
 
-	var key ExtendedPublicKey
-	key.key = C.CExtendedPublicKeyFromBytes(cBytesPtr)
-	runtime.SetFinalizer(&key, func(p *ExtendedPublicKey) { p.Free() })
+	var cErrMsg *C.char
+	cKey := C.CExtendedPublicKeyFromBytes(cBytesPtr, &cErrMsg)
+	if cErrMsg != nil {
+		return ExtendedPublicKey{}, errFromC(ErrInvalidPublicKey, cErrMsg)
+	}
+
+	key := newExtendedPublicKey(cKey)
+	if !key.GetPublicKey().inSubgroup() {
+		key.Free()
+		return ExtendedPublicKey{}, errNotInSubgroup()
+	}
 
-	return key
+	return key, nil
 }
 
-// Free releases memory allocated by the key
+// Free releases memory allocated by the key. All copies of the key share that
+// memory, so none of them may be used afterwards. Calling Free again is a
+// no-op.
 func (key ExtendedPublicKey) Free() {
-	C.CExtendedPublicKeyFree(key.key)
+	if key.extendedPublicKey == nil {
+		return
+	}
+	runtime.SetFinalizer(key.extendedPublicKey, nil)
+	key.free()
 }
 
 // Serialize returns the serialized byte representation of the
 // ExtendedPublicKey object
 func (key ExtendedPublicKey) Serialize() []byte {
+	defer runtime.KeepAlive(key)
 	ptr := C.CExtendedPublicKeySerialize(key.key)
 	defer C.free(ptr)
 	return C.GoBytes(ptr, C.CExtendedPublicKeySizeBytes())
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize. The zero value can't be encoded, so it returns an
+// ErrZeroValue error.
+func (key ExtendedPublicKey) MarshalBinary() ([]byte, error) {
+	if key.extendedPublicKey == nil {
+		return nil, errZeroValue("ExtendedPublicKey")
+	}
+	return key.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by ExtendedPublicKeyFromBytes.
+func (key *ExtendedPublicKey) UnmarshalBinary(data []byte) error {
+	parsed, err := ExtendedPublicKeyFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*key = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the key as hex.
+func (key ExtendedPublicKey) MarshalText() ([]byte, error) {
+	data, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return marshalHex(data), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// key.
+func (key *ExtendedPublicKey) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return key.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the key as a hex string.
+// The zero value is encoded as null.
+func (key ExtendedPublicKey) MarshalJSON() ([]byte, error) {
+	if key.extendedPublicKey == nil {
+		return jsonNull, nil
+	}
+	return marshalJSON(key.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the key unchanged.
+func (key *ExtendedPublicKey) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return key.UnmarshalText(text)
+}
+
 // GetPublicKey returns the public key for the given node
 func (key ExtendedPublicKey) GetPublicKey() PublicKey {
-	var pk PublicKey
-	pk.pk = C.CExtendedPublicKeyGetPublicKey(key.key)
-	runtime.SetFinalizer(&pk, func(p *PublicKey) { p.Free() })
-	return pk
+	defer runtime.KeepAlive(key)
+	return newPublicKey(C.CExtendedPublicKeyGetPublicKey(key.key))
 }
 
 var childComparator uint32 = (1 << 31)
 
 // PublicChild derives a child extended public key
 func (key ExtendedPublicKey) PublicChild(i uint32) ExtendedPublicKey {
+	defer runtime.KeepAlive(key)
+
 	// Hardened children have i >= 2^31. Non-hardened have i < 2^31
 	if i >= childComparator {
 		panic("cannot derive hardened children from public key")
@@ -59,41 +165,84 @@ func (key ExtendedPublicKey) PublicChild(i uint32) ExtendedPublicKey {
 		panic("cannot go further than 255 levels")
 	}
 
-	var child ExtendedPublicKey
-	child.key = C.CExtendedPublicKeyPublicChild(key.key, C.uint(i))
-	runtime.SetFinalizer(&child, func(p *ExtendedPublicKey) { p.Free() })
-	return child
+	return newExtendedPublicKey(C.CExtendedPublicKeyPublicChild(key.key, C.uint(i)))
+}
+
+// DerivePath parses path with ParsePath and derives it from key, e.g.
+// "m/12381/3600/0/0". The path is relative to key, which the leading m stands
+// for.
+func (key ExtendedPublicKey) DerivePath(path string) (ExtendedPublicKey, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return ExtendedPublicKey{}, err
+	}
+	return key.Derive(p)
+}
+
+// Derive derives the children in path from key in turn. Unlike PublicChild,
+// it returns an error rather than panicking if the path contains a hardened
+// index, which wraps ErrHardenedDerivation, or is too deep. The empty path
+// returns a copy of key.
+func (key ExtendedPublicKey) Derive(path Path) (ExtendedPublicKey, error) {
+	for i, index := range path {
+		if index >= HardenedOffset {
+			return ExtendedPublicKey{}, &Error{
+				Err: ErrHardenedDerivation,
+				Msg: fmt.Sprintf("step %d of %s is hardened", i+1, path),
+			}
+		}
+	}
+	if err := checkDepth(key.GetDepth(), path); err != nil {
+		return ExtendedPublicKey{}, err
+	}
+	if len(path) == 0 {
+		return ExtendedPublicKeyFromBytes(key.Serialize())
+	}
+
+	child := key
+	for _, index := range path {
+		next := child.PublicChild(index)
+		if child != key {
+			child.Free()
+		}
+		child = next
+	}
+	return child, nil
 }
 
 // GetVersion returns the version bytes
 func (key ExtendedPublicKey) GetVersion() uint32 {
+	defer runtime.KeepAlive(key)
 	return uint32(C.CExtendedPublicKeyGetVersion(key.key))
 }
 
 // GetDepth returns the depth byte
 func (key ExtendedPublicKey) GetDepth() uint8 {
+	defer runtime.KeepAlive(key)
 	return uint8(C.CExtendedPublicKeyGetDepth(key.key))
 }
 
 // GetParentFingerprint returns the parent fingerprint
 func (key ExtendedPublicKey) GetParentFingerprint() uint32 {
+	defer runtime.KeepAlive(key)
 	return uint32(C.CExtendedPublicKeyGetParentFingerprint(key.key))
 }
 
 // GetChildNumber returns the child number
 func (key ExtendedPublicKey) GetChildNumber() uint32 {
+	defer runtime.KeepAlive(key)
 	return uint32(C.CExtendedPublicKeyGetChildNumber(key.key))
 }
 
 // GetChainCode returns the ChainCode for the given node
 func (key ExtendedPublicKey) GetChainCode() ChainCode {
-	var cc ChainCode
-	cc.cc = C.CExtendedPublicKeyGetChainCode(key.key)
-	runtime.SetFinalizer(&cc, func(p *ChainCode) { p.Free() })
-	return cc
+	defer runtime.KeepAlive(key)
+	return newChainCode(C.CExtendedPublicKeyGetChainCode(key.key))
 }
 
 // Equal tests if one ExtendedPublicKey object is equal to another
 func (key ExtendedPublicKey) Equal(other ExtendedPublicKey) bool {
+	defer runtime.KeepAlive(key)
+	defer runtime.KeepAlive(other)
 	return bool(C.CExtendedPublicKeyIsEqual(key.key, other.key))
 }
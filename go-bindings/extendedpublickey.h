@@ -23,7 +23,7 @@ extern "C" {
 
 typedef void* CExtendedPublicKey;
 
-CExtendedPublicKey CExtendedPublicKeyFromBytes(void *p);
+CExtendedPublicKey CExtendedPublicKeyFromBytes(void *p, char **errMsg);
 
 CExtendedPublicKey CExtendedPublicKeyPublicChild(CExtendedPublicKey inPtr,
     uint32_t i);
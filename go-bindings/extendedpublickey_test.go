@@ -8,13 +8,13 @@ import (
 )
 
 func TestExtendedPublicKey(t *testing.T) {
-	xpub1 := bls.ExtendedPublicKeyFromBytes(xpubBytes)
+	xpub1, _ := bls.ExtendedPublicKeyFromBytes(xpubBytes)
 	xpub1GotBytes := xpub1.Serialize()
 	if !bytes.Equal(xpub1GotBytes, xpubBytes) {
 		t.Errorf("got %v, expected %v", xpub1GotBytes, xpubBytes)
 	}
 
-	xpub3 := bls.ExtendedPublicKeyFromBytes(xpub1GotBytes)
+	xpub3, _ := bls.ExtendedPublicKeyFromBytes(xpub1GotBytes)
 	if !xpub1.Equal(xpub3) {
 		t.Error("xpub1 should be equal to xpub3")
 	}
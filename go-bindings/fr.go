@@ -0,0 +1,317 @@
+package blschia
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"unsafe"
+)
+
+// FrSize is the size in bytes of a serialized Fr
+const FrSize = 32
+
+// Fr is an element of the scalar field of BLS12-381, i.e. an integer modulo
+// the order r of the groups. It multiplies group elements, and is used for
+// aggregation exponents and Lagrange coefficients. It is serialized as 32
+// bytes big-endian, like PrivateKey.
+//
+// The arithmetic runs in constant time, so an Fr can hold secret values. An Fr
+// holds no C memory and can be compared with ==, but Equal doesn't leak
+// timing. The zero value is 0.
+type Fr struct {
+	// value is big-endian and smaller than r
+	value [FrSize]byte
+}
+
+// frLimbs holds a 256-bit integer as four words, least significant first. The
+// arithmetic unpacks an Fr into limbs and multiplies in Montgomery form, where
+// x is represented by x * 2^256 mod r.
+type frLimbs [4]uint64
+
+var (
+	// frModulus is the group order r
+	frModulus = frLimbs{0xffffffff00000001, 0x53bda402fffe5bfe, 0x3339d80809a1d805, 0x73eda753299d7d48}
+	// frR is 2^256 mod r, which is 1 in Montgomery form
+	frR = frLimbs{0x00000001fffffffe, 0x5884b7fa00034802, 0x998c4fefecbc4ff5, 0x1824b159acc5056f}
+	// frR2 is 2^512 mod r, by which values are multiplied to enter Montgomery
+	// form
+	frR2 = frLimbs{0xc999e990f3f29c6d, 0x2b6cedcb87925c23, 0x05d314967254398f, 0x0748d9d99f59ff11}
+)
+
+// frInv is -r^-1 mod 2^64
+const frInv = 0xfffffffeffffffff
+
+// FrFromBytes decodes a 32-byte big-endian value. Values which are not
+// smaller than the group order are rejected with ErrInvalidScalar.
+func FrFromBytes(data []byte) (Fr, error) {
+	if err := checkLength("scalar", data, FrSize); err != nil {
+		return Fr{}, err
+	}
+	var s Fr
+	copy(s.value[:], data)
+	if _, borrow := frSub(s.limbs(), frModulus); borrow == 0 {
+		return Fr{}, &Error{
+			Err: ErrInvalidScalar,
+			Msg: "value is not smaller than the group order",
+		}
+	}
+	return s, nil
+}
+
+// FrFromUint64 returns v as an Fr
+func FrFromUint64(v uint64) Fr {
+	var s Fr
+	binary.BigEndian.PutUint64(s.value[FrSize-8:], v)
+	return s
+}
+
+// FrRandom returns a uniformly random Fr, reading 64 bytes from rand and
+// reducing them modulo r so that the bias is negligible. rand should be
+// crypto/rand.Reader unless the value is public.
+func FrRandom(rand io.Reader) (Fr, error) {
+	var buf [2 * FrSize]byte
+	defer Wipe(buf[:])
+	if _, err := io.ReadFull(rand, buf[:]); err != nil {
+		return Fr{}, err
+	}
+	return frFromWideBytes(buf[:]), nil
+}
+
+// frFromWideBytes reduces a big-endian integer of up to 64 bytes modulo r
+func frFromWideBytes(data []byte) Fr {
+	var buf [2 * FrSize]byte
+	defer Wipe(buf[:])
+	copy(buf[len(buf)-len(data):], data)
+
+	var hi, lo Fr
+	copy(hi.value[:], buf[:FrSize])
+	copy(lo.value[:], buf[FrSize:])
+	// Montgomery multiplication divides by 2^256, so hi*R2 gives hi*2^256 and
+	// lo*R gives lo, both reduced. The inputs only need to be below 2^256.
+	return frFromLimbs(frAdd(frMontMul(hi.limbs(), frR2), frMontMul(lo.limbs(), frR)))
+}
+
+// Fr returns the private key as an Fr, so that it can multiply group
+// elements. Callers should treat it as secret.
+func (sk PrivateKey) Fr() Fr {
+	var s Fr
+	sk.SerializeInto(s.value[:])
+	return s
+}
+
+// PrivateKeyFromFr returns the private key with the value of s
+func PrivateKeyFromFr(s Fr) PrivateKey {
+	sk, _ := PrivateKeyFromBytes(s.value[:], false)
+	return sk
+}
+
+func (s *Fr) ptr() unsafe.Pointer {
+	return unsafe.Pointer(&s.value[0])
+}
+
+// Add returns s + other mod r
+func (s Fr) Add(other Fr) Fr {
+	return frFromLimbs(frAdd(s.limbs(), other.limbs()))
+}
+
+// Sub returns s - other mod r
+func (s Fr) Sub(other Fr) Fr {
+	return frFromLimbs(frSubMod(s.limbs(), other.limbs()))
+}
+
+// Neg returns -s mod r
+func (s Fr) Neg() Fr {
+	return Fr{}.Sub(s)
+}
+
+// Mul returns s * other mod r
+func (s Fr) Mul(other Fr) Fr {
+	return frFromLimbs(frMontMul(frMontMul(s.limbs(), other.limbs()), frR2))
+}
+
+// Inverse returns the multiplicative inverse of s, so that s.Mul(s.Inverse())
+// is 1. The inverse of 0 is 0.
+func (s Fr) Inverse() Fr {
+	// s^(r-2) by Fermat's little theorem. The exponent is public, so it can
+	// be scanned with branches.
+	exp := frModulus
+	exp[0] -= 2
+	x := frMontMul(s.limbs(), frR2)
+	res := frR
+	for i := 255; i >= 0; i-- {
+		res = frMontMul(res, res)
+		if (exp[i/64]>>(uint(i)%64))&1 == 1 {
+			res = frMontMul(res, x)
+		}
+	}
+	return frFromLimbs(frMontMul(res, frLimbs{1}))
+}
+
+// Serialize returns the 32-byte big-endian encoding of the scalar
+func (s Fr) Serialize() []byte {
+	buf := make([]byte, FrSize)
+	copy(buf, s.value[:])
+	return buf
+}
+
+// IsZero reports whether the scalar is 0
+func (s Fr) IsZero() bool {
+	return s.Equal(Fr{})
+}
+
+// Equal tests if one Fr is equal to another in constant time
+func (s Fr) Equal(other Fr) bool {
+	return subtle.ConstantTimeCompare(s.value[:], other.value[:]) == 1
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize.
+func (s Fr) MarshalBinary() ([]byte, error) {
+	return s.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by FrFromBytes.
+func (s *Fr) UnmarshalBinary(data []byte) error {
+	parsed, err := FrFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the scalar as hex.
+func (s Fr) MarshalText() ([]byte, error) {
+	return marshalHex(s.Serialize()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// scalar.
+func (s *Fr) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the scalar as a hex string.
+// The zero value is 0, so it is not encoded as null.
+func (s Fr) MarshalJSON() ([]byte, error) {
+	return marshalJSON(s.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the scalar unchanged.
+func (s *Fr) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return s.UnmarshalText(text)
+}
+
+func (s *Fr) limbs() frLimbs {
+	var l frLimbs
+	for i := range l {
+		l[i] = binary.BigEndian.Uint64(s.value[FrSize-8*(i+1):])
+	}
+	return l
+}
+
+func frFromLimbs(l frLimbs) Fr {
+	var s Fr
+	for i := range l {
+		binary.BigEndian.PutUint64(s.value[FrSize-8*(i+1):], l[i])
+	}
+	return s
+}
+
+// frSelect returns a if mask is all ones and b if it is zero
+func frSelect(mask uint64, a, b frLimbs) frLimbs {
+	var res frLimbs
+	for i := range res {
+		res[i] = (a[i] & mask) | (b[i] &^ mask)
+	}
+	return res
+}
+
+// frSub returns a - b and the final borrow, without reducing
+func frSub(a, b frLimbs) (frLimbs, uint64) {
+	var res frLimbs
+	var borrow uint64
+	for i := range res {
+		res[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+	return res, borrow
+}
+
+// frReduce subtracts r from a if a is not smaller than it. a must be smaller
+// than 2r.
+func frReduce(a frLimbs) frLimbs {
+	diff, borrow := frSub(a, frModulus)
+	return frSelect(-borrow, a, diff)
+}
+
+// frAdd returns a + b mod r. Since r < 2^255 the sum can't overflow.
+func frAdd(a, b frLimbs) frLimbs {
+	var sum frLimbs
+	var carry uint64
+	for i := range sum {
+		sum[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+	return frReduce(sum)
+}
+
+// frSubMod returns a - b mod r
+func frSubMod(a, b frLimbs) frLimbs {
+	diff, borrow := frSub(a, b)
+	mask := -borrow
+	var carry uint64
+	for i := range diff {
+		diff[i], carry = bits.Add64(diff[i], frModulus[i]&mask, carry)
+	}
+	return diff
+}
+
+// frMontMul returns a * b / 2^256 mod r, using the CIOS method. b must be
+// smaller than r, but a only has to be smaller than 2^256.
+func frMontMul(a, b frLimbs) frLimbs {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		// t += a * b[i]
+		var c uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(a[j], b[i])
+			var cc uint64
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j], c = lo, hi
+		}
+		var cc uint64
+		t[4], cc = bits.Add64(t[4], c, 0)
+		t[5] = cc
+
+		// t = (t + m * r) / 2^64, where m makes the low word vanish
+		m := t[0] * frInv
+		hi, lo := bits.Mul64(m, frModulus[0])
+		_, cc = bits.Add64(lo, t[0], 0)
+		c = hi + cc
+		for j := 1; j < 4; j++ {
+			hi, lo = bits.Mul64(m, frModulus[j])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j-1], c = lo, hi
+		}
+		t[3], cc = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + cc
+	}
+	// The result is smaller than 2r < 2^256, so t[4] is zero here
+	return frReduce(frLimbs{t[0], t[1], t[2], t[3]})
+}
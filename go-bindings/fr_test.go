@@ -0,0 +1,148 @@
+package blschia_test
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestFr(t *testing.T) {
+	s := bls.FrFromUint64(0x0102030405060708)
+	expected := make([]byte, bls.FrSize)
+	copy(expected[24:], []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	if got := s.Serialize(); !bytes.Equal(got, expected) {
+		t.Errorf("got %x, expected %x", got, expected)
+	}
+
+	decoded, err := bls.FrFromBytes(expected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded != s || !decoded.Equal(s) {
+		t.Errorf("got %x, expected %x", decoded.Serialize(), s.Serialize())
+	}
+	if s.IsZero() {
+		t.Error("s should not be zero")
+	}
+	if !(bls.Fr{}).IsZero() || !bls.FrFromUint64(0).IsZero() {
+		t.Error("zero value should be zero")
+	}
+
+	data, _ := hex.DecodeString(rMinusOne)
+	if _, err := bls.FrFromBytes(data); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFrInvalid(t *testing.T) {
+	order, _ := hex.DecodeString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+	_, err := bls.FrFromBytes(order)
+	expectError(t, err, bls.ErrInvalidScalar)
+
+	_, err = bls.FrFromBytes(bytes.Repeat([]byte{0xff}, bls.FrSize))
+	expectError(t, err, bls.ErrInvalidScalar)
+
+	_, err = bls.FrFromBytes(make([]byte, bls.FrSize-1))
+	expectError(t, err, bls.ErrLengthMismatch)
+}
+
+func TestFrPrivateKey(t *testing.T) {
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	s := sk.Fr()
+	if got := s.Serialize(); !bytes.Equal(got, sk1Bytes) {
+		t.Errorf("got %x, expected %x", got, sk1Bytes)
+	}
+
+	sk2 := bls.PrivateKeyFromFr(s)
+	if !sk2.Equal(sk) {
+		t.Errorf("got %x, expected %x", sk2.Serialize(), sk1Bytes)
+	}
+
+	sk.Free()
+	sk2.Free()
+}
+
+func TestFrArithmetic(t *testing.T) {
+	order, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+	toBig := func(s bls.Fr) *big.Int {
+		return new(big.Int).SetBytes(s.Serialize())
+	}
+
+	rMinusOneBytes, _ := hex.DecodeString(rMinusOne)
+	edge, _ := bls.FrFromBytes(rMinusOneBytes)
+	values := []bls.Fr{{}, bls.FrFromUint64(1), bls.FrFromUint64(2), edge}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 16; i++ {
+		s, err := bls.FrRandom(rng)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		values = append(values, s)
+	}
+
+	for _, a := range values {
+		for _, b := range values {
+			x, y := toBig(a), toBig(b)
+			tests := []struct {
+				op       string
+				got      bls.Fr
+				expected *big.Int
+			}{
+				{"add", a.Add(b), new(big.Int).Add(x, y)},
+				{"sub", a.Sub(b), new(big.Int).Sub(x, y)},
+				{"mul", a.Mul(b), new(big.Int).Mul(x, y)},
+			}
+			for _, tt := range tests {
+				tt.expected.Mod(tt.expected, order)
+				if got := toBig(tt.got); got.Cmp(tt.expected) != 0 {
+					t.Errorf("%x %s %x: got %x, expected %x", x, tt.op, y, got, tt.expected)
+				}
+			}
+		}
+
+		if got := a.Add(a.Neg()); !got.IsZero() {
+			t.Errorf("%x + -%x: got %x, expected 0", a.Serialize(), a.Serialize(), got.Serialize())
+		}
+		if a.IsZero() {
+			if got := a.Inverse(); !got.IsZero() {
+				t.Errorf("got %x, expected the inverse of 0 to be 0", got.Serialize())
+			}
+			continue
+		}
+		if got := a.Mul(a.Inverse()); got != bls.FrFromUint64(1) {
+			t.Errorf("%x * %x^-1: got %x, expected 1", a.Serialize(), a.Serialize(), got.Serialize())
+		}
+	}
+}
+
+func TestFrRandom(t *testing.T) {
+	a, err := bls.FrRandom(crand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := bls.FrRandom(crand.Reader)
+	if a.Equal(b) {
+		t.Error("random values should differ")
+	}
+
+	// The value is reduced from 64 bytes, so all ones plus one is 2^512 mod r
+	s, err := bls.FrRandom(bytes.NewReader(bytes.Repeat([]byte{0xff}, 64)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, _ := hex.DecodeString("0748d9d99f59ff1105d314967254398f2b6cedcb87925c23c999e990f3f29c6d")
+	if got := s.Add(bls.FrFromUint64(1)).Serialize(); !bytes.Equal(got, expected) {
+		t.Errorf("got %x, expected %x", got, expected)
+	}
+
+	_, err = bls.FrRandom(bytes.NewReader(make([]byte, 63)))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v, expected %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/nmarley/bls-signatures/go-bindings
 
 go 1.13
+
+require (
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
+	golang.org/x/text v0.3.7
+)
@@ -0,0 +1,595 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+#include "hashtocurve.h"
+#include <algorithm>
+#include <cstring>
+#include <mutex>
+#include <stdexcept>
+#include <vector>
+#include "curve.h"
+
+// Hashing to G1 and G2 as specified by RFC 9380, for the suites
+// BLS12381G1_XMD:SHA-256_SSWU_RO_ and BLS12381G2_XMD:SHA-256_SSWU_RO_ and
+// their NU_ variants. Messages are public, so none of this needs to run in
+// constant time.
+
+namespace {
+
+const size_t HASH_SIZE = 32;
+const size_t HASH_BLOCK_SIZE = 64;
+const size_t MAX_DST_SIZE = 255;
+// Bytes hashed into each field element, ceil((381 + 128) / 8)
+const size_t FIELD_ELEMENT_BYTES = 64;
+
+// Coefficients of the 11-isogeny from E1' to E1, in Appendix E.2 of the RFC.
+// Each row holds the coefficients of one polynomial from the constant term
+// up. The denominators are monic and padded to sixteen coefficients.
+const char *ISO_COEFFS_G1[4][16] = {
+    // xNum
+    {
+        "11a05f2b1e833340b809101dd99815856b303e88a2d7005ff2627b56cdb4e2c85610c2d5f2e62d6eaeac1662734649b7",
+        "17294ed3e943ab2f0588bab22147a81c7c17e75b2f6a8417f565e33c70d1e86b4838f2a6f318c356e834eef1b3cb83bb",
+        "d54005db97678ec1d1048c5d10a9a1bce032473295983e56878e501ec68e25c958c3e3d2a09729fe0179f9dac9edcb0",
+        "1778e7166fcc6db74e0609d307e55412d7f5e4656a8dbf25f1b33289f1b330835336e25ce3107193c5b388641d9b6861",
+        "e99726a3199f4436642b4b3e4118e5499db995a1257fb3f086eeb65982fac18985a286f301e77c451154ce9ac8895d9",
+        "1630c3250d7313ff01d1201bf7a74ab5db3cb17dd952799b9ed3ab9097e68f90a0870d2dcae73d19cd13c1c66f652983",
+        "d6ed6553fe44d296a3726c38ae652bfb11586264f0f8ce19008e218f9c86b2a8da25128c1052ecaddd7f225a139ed84",
+        "17b81e7701abdbe2e8743884d1117e53356de5ab275b4db1a682c62ef0f2753339b7c8f8c8f475af9ccb5618e3f0c88e",
+        "80d3cf1f9a78fc47b90b33563be990dc43b756ce79f5574a2c596c928c5d1de4fa295f296b74e956d71986a8497e317",
+        "169b1f8e1bcfa7c42e0c37515d138f22dd2ecb803a0c5c99676314baf4bb1b7fa3190b2edc0327797f241067be390c9e",
+        "10321da079ce07e272d8ec09d2565b0dfa7dccdde6787f96d50af36003b14866f69b771f8c285decca67df3f1605fb7b",
+        "6e08c248e260e70bd1e962381edee3d31d79d7e22c837bc23c0bf1bc24c6b68c24b1b80b64d391fa9c8ba2e8ba2d229",
+        "0",
+        "0",
+        "0",
+        "0",
+    },
+    // xDen
+    {
+        "8ca8d548cff19ae18b2e62f4bd3fa6f01d5ef4ba35b48ba9c9588617fc8ac62b558d681be343df8993cf9fa40d21b1c",
+        "12561a5deb559c4348b4711298e536367041e8ca0cf0800c0126c2588c48bf5713daa8846cb026e9e5c8276ec82b3bff",
+        "b2962fe57a3225e8137e629bff2991f6f89416f5a718cd1fca64e00b11aceacd6a3d0967c94fedcfcc239ba5cb83e19",
+        "3425581a58ae2fec83aafef7c40eb545b08243f16b1655154cca8abc28d6fd04976d5243eecf5c4130de8938dc62cd8",
+        "13a8e162022914a80a6f1d5f43e7a07dffdfc759a12062bb8d6b44e833b306da9bd29ba81f35781d539d395b3532a21e",
+        "e7355f8e4e667b955390f7f0506c6e9395735e9ce9cad4d0a43bcef24b8982f7400d24bc4228f11c02df9a29f6304a5",
+        "772caacf16936190f3e0c63e0596721570f5799af53a1894e2e073062aede9cea73b3538f0de06cec2574496ee84a3a",
+        "14a7ac2a9d64a8b230b3f5b074cf01996e7f63c21bca68a81996e1cdf9822c580fa5b9489d11e2d311f7d99bbdcc5a5e",
+        "a10ecf6ada54f825e920b3dafc7a3cce07f8d1d7161366b74100da67f39883503826692abba43704776ec3a79a1d641",
+        "95fc13ab9e92ad4476d6e3eb3a56680f682b4ee96f7d03776df533978f31c1593174e4b4b7865002d6384d168ecdd0a",
+        "1",
+        "0",
+        "0",
+        "0",
+        "0",
+        "0",
+    },
+    // yNum
+    {
+        "90d97c81ba24ee0259d1f094980dcfa11ad138e48a869522b52af6c956543d3cd0c7aee9b3ba3c2be9845719707bb33",
+        "134996a104ee5811d51036d776fb46831223e96c254f383d0f906343eb67ad34d6c56711962fa8bfe097e75a2e41c696",
+        "cc786baa966e66f4a384c86a3b49942552e2d658a31ce2c344be4b91400da7d26d521628b00523b8dfe240c72de1f6",
+        "1f86376e8981c217898751ad8746757d42aa7b90eeb791c09e4a3ec03251cf9de405aba9ec61deca6355c77b0e5f4cb",
+        "8cc03fdefe0ff135caf4fe2a21529c4195536fbe3ce50b879833fd221351adc2ee7f8dc099040a841b6daecf2e8fedb",
+        "16603fca40634b6a2211e11db8f0a6a074a7d0d4afadb7bd76505c3d3ad5544e203f6326c95a807299b23ab13633a5f0",
+        "4ab0b9bcfac1bbcb2c977d027796b3ce75bb8ca2be184cb5231413c4d634f3747a87ac2460f415ec961f8855fe9d6f2",
+        "987c8d5333ab86fde9926bd2ca6c674170a05bfe3bdd81ffd038da6c26c842642f64550fedfe935a15e4ca31870fb29",
+        "9fc4018bd96684be88c9e221e4da1bb8f3abd16679dc26c1e8b6e6a1f20cabe69d65201c78607a360370e577bdba587",
+        "e1bba7a1186bdb5223abde7ada14a23c42a0ca7915af6fe06985e7ed1e4d43b9b3f7055dd4eba6f2bafaaebca731c30",
+        "19713e47937cd1be0dfd0b8f1d43fb93cd2fcbcb6caf493fd1183e416389e61031bf3a5cce3fbafce813711ad011c132",
+        "18b46a908f36f6deb918c143fed2edcc523559b8aaf0c2462e6bfe7f911f643249d9cdf41b44d606ce07c8a4d0074d8e",
+        "b182cac101b9399d155096004f53f447aa7b12a3426b08ec02710e807b4633f06c851c1919211f20d4c04f00b971ef8",
+        "245a394ad1eca9b72fc00ae7be315dc757b3b080d4c158013e6632d3c40659cc6cf90ad1c232a6442d9d3f5db980133",
+        "5c129645e44cf1102a159f748c4a3fc5e673d81d7e86568d9ab0f5d396a7ce46ba1049b6579afb7866b1e715475224b",
+        "15e6be4e990f03ce4ea50b3b42df2eb5cb181d8f84965a3957add4fa95af01b2b665027efec01c7704b456be69c8b604",
+    },
+    // yDen
+    {
+        "16112c4c3a9c98b252181140fad0eae9601a6de578980be6eec3232b5be72e7a07f3688ef60c206d01479253b03663c1",
+        "1962d75c2381201e1a0cbd6c43c348b885c84ff731c4d59ca4a10356f453e01f78a4260763529e3532f6102c2e49a03d",
+        "58df3306640da276faaae7d6e8eb15778c4855551ae7f310c35a5dd279cd2eca6757cd636f96f891e2538b53dbf67f2",
+        "16b7d288798e5395f20d23bf89edb4d1d115c5dbddbcd30e123da489e726af41727364f2c28297ada8d26d98445f5416",
+        "be0e079545f43e4b00cc912f8228ddcc6d19c9f0f69bbb0542eda0fc9dec916a20b15dc0fd2ededda39142311a5001d",
+        "8d9e5297186db2d9fb266eaac783182b70152c65550d881c5ecd87b6f0f5a6449f38db9dfa9cce202c6477faaf9b7ac",
+        "166007c08a99db2fc3ba8734ace9824b5eecfdfa8d0cf8ef5dd365bc400a0051d5fa9c01a58b1fb93d1a1399126a775c",
+        "16a3ef08be3ea7ea03bcddfabba6ff6ee5a4375efa1f4fd7feb34fd206357132b920f5b00801dee460ee415a15812ed9",
+        "1866c8ed336c61231a1be54fd1d74cc4f9fb0ce4c6af5920abc5750c4bf39b4852cfe2f7bb9248836b233d9d55535d4a",
+        "167a55cda70a6e1cea820597d94a84903216f763e13d87bb5308592e7ea7d4fbc7385ea3d529b35e346ef48bb8913f55",
+        "4d2f259eea405bd48f010a01ad2911d9c6dd039bb61a6290e591b36e636a5c871a5c29f4f83060400f8b49cba8f6aa8",
+        "accbb67481d033ff5852c1e48c50c477f94ff8aefce42d28c0f9a88cea7913516f968986f7ebbea9684b529e2561092",
+        "ad6b9514c767fe3c3613144b45f1496543346d98adf02267d5ceef9a00d9b8693000763e3b90ac11e99b138573345cc",
+        "2660400eb2e4f3b628bdd0d53cd76f2bf565b94e72927c1cb748df27942480e420517bd8714cc80d1fadc1326ed06f7",
+        "e0fa1d816ddc03e6b24255e0d7819c171c40f65e273b853324efcd6356caa205ca2f570f13497804415473a1d634b8f",
+        "1",
+    },
+};
+
+// Coefficients of the 3-isogeny from E2' to E2, in Appendix E.3 of the RFC,
+// as pairs of c0 and c1. The denominators are monic and padded to four
+// coefficients.
+const char *ISO_COEFFS_G2[4][4][2] = {
+    // xNum
+    {
+        {"5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6",
+         "5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6"},
+        {"0",
+         "11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71a"},
+        {"11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71e",
+         "8ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38d"},
+        {"171d6541fa38ccfaed6dea691f5fb614cb14b4e7f4e810aa22d6108f142b85757098e38d0f671c7188e2aaaaaaaa5ed1",
+         "0"},
+    },
+    // xDen
+    {
+        {"0",
+         "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa63"},
+        {"c",
+         "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa9f"},
+        {"1",
+         "0"},
+        {"0",
+         "0"},
+    },
+    // yNum
+    {
+        {"1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706",
+         "1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706"},
+        {"0",
+         "5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97be"},
+        {"11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71c",
+         "8ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38f"},
+        {"124c9ad43b6cf79bfbf7043de3811ad0761b0f37a1e26286b0e977c69aa274524e79097a56dc4bd9e1b371c71c718b10",
+         "0"},
+    },
+    // yDen
+    {
+        {"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb",
+         "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb"},
+        {"0",
+         "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa9d3"},
+        {"12",
+         "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa99"},
+        {"1",
+         "0"},
+    },
+};
+
+// E1': y^2 = x^3 + A'x + B', section 8.8.1
+const char *SSWU_A_G1 = "144698a3b8e9433d693a02c96d4982b0ea985383ee66a8d8e8981aefd881ac98936f8da0e0f97f5cf428082d584c1d";
+const char *SSWU_B_G1 = "12e2908d11688030018b12e8753eee3b2016c1f0f24f4070a0b9c14fcef35ef55a23215a316ceaa5d1cc48e98e172be0";
+
+// The parameter of the BLS12-381 curve is -0xd201000000010000, and the
+// effective cofactor of G1 is 1 - x
+const char *CURVE_X_ABS = "d201000000010000";
+const char *H_EFF_G1 = "d201000000010001";
+
+struct SSWUConstants {
+    // E1' with Z = 11
+    fp_t a1;
+    fp_t b1;
+    fp_t z1;
+    // -B'/A' and B'/(Z*A')
+    fp_t minusBOverA1;
+    fp_t bOverZA1;
+    fp_t iso1[4][16];
+
+    // E2': y^2 = x^3 + A'x + B' with A' = 240i, B' = 1012(1+i) and
+    // Z = -(2+i)
+    fp2_t a2;
+    fp2_t b2;
+    fp2_t z2;
+    fp2_t minusBOverA2;
+    fp2_t bOverZA2;
+    fp2_t iso2[4][4];
+};
+
+SSWUConstants constants;
+std::once_flag constantsOnce;
+
+void ReadHex(fp_t a, const char *hex) {
+    fp_read_str(a, hex, strlen(hex), 16);
+}
+
+void InitConstants() {
+    SSWUConstants& c = constants;
+    fp_t t1;
+    ReadHex(c.a1, SSWU_A_G1);
+    ReadHex(c.b1, SSWU_B_G1);
+    fp_set_dig(c.z1, 11);
+    fp_inv(t1, c.a1);
+    fp_mul(c.minusBOverA1, c.b1, t1);
+    fp_neg(c.minusBOverA1, c.minusBOverA1);
+    fp_mul(t1, c.z1, c.a1);
+    fp_inv(t1, t1);
+    fp_mul(c.bOverZA1, c.b1, t1);
+    for (int i = 0; i < 4; i++) {
+        for (int j = 0; j < 16; j++) {
+            ReadHex(c.iso1[i][j], ISO_COEFFS_G1[i][j]);
+        }
+    }
+
+    fp2_t t2;
+    fp2_zero(c.a2);
+    fp_set_dig(c.a2[1], 240);
+    fp_set_dig(c.b2[0], 1012);
+    fp_set_dig(c.b2[1], 1012);
+    fp_set_dig(c.z2[0], 2);
+    fp_set_dig(c.z2[1], 1);
+    fp2_neg(c.z2, c.z2);
+    fp2_inv(t2, c.a2);
+    fp2_mul(c.minusBOverA2, c.b2, t2);
+    fp2_neg(c.minusBOverA2, c.minusBOverA2);
+    fp2_mul(t2, c.z2, c.a2);
+    fp2_inv(t2, t2);
+    fp2_mul(c.bOverZA2, c.b2, t2);
+    for (int i = 0; i < 4; i++) {
+        for (int j = 0; j < 4; j++) {
+            for (int k = 0; k < 2; k++) {
+                ReadHex(c.iso2[i][j][k], ISO_COEFFS_G2[i][j][k]);
+            }
+        }
+    }
+}
+
+SSWUConstants& GetConstants() {
+    std::call_once(constantsOnce, InitConstants);
+    return constants;
+}
+
+void Sha256(uint8_t *out, const std::vector<uint8_t>& in) {
+    md_map_sh256(out, in.data(), in.size());
+}
+
+// ExpandMessageXMD implements expand_message_xmd with SHA-256, section 5.3.1
+void ExpandMessageXMD(uint8_t *out, size_t outLen, const uint8_t *msg,
+    size_t len, const uint8_t *dst, size_t dstLen) {
+    size_t ell = (outLen + HASH_SIZE - 1) / HASH_SIZE;
+    if (ell > 255 || outLen > 65535) {
+        throw std::invalid_argument("too many bytes requested");
+    }
+
+    // Long DSTs are hashed, section 5.3.3
+    std::vector<uint8_t> dstPrime;
+    if (dstLen > MAX_DST_SIZE) {
+        const char prefix[] = "H2C-OVERSIZE-DST-";
+        std::vector<uint8_t> buf(prefix, prefix + sizeof(prefix) - 1);
+        buf.insert(buf.end(), dst, dst + dstLen);
+        dstPrime.resize(HASH_SIZE);
+        Sha256(dstPrime.data(), buf);
+    } else {
+        dstPrime.assign(dst, dst + dstLen);
+    }
+    dstPrime.push_back(static_cast<uint8_t>(dstPrime.size()));
+
+    // b_0 = H(Z_pad || msg || l_i_b_str || 0 || DST_prime)
+    std::vector<uint8_t> buf(HASH_BLOCK_SIZE, 0);
+    buf.insert(buf.end(), msg, msg + len);
+    buf.push_back(static_cast<uint8_t>(outLen >> 8));
+    buf.push_back(static_cast<uint8_t>(outLen));
+    buf.push_back(0);
+    buf.insert(buf.end(), dstPrime.begin(), dstPrime.end());
+    uint8_t b0[HASH_SIZE];
+    Sha256(b0, buf);
+
+    // b_i = H((b_0 xor b_(i-1)) || i || DST_prime), with b_1 = H(b_0 || 1 ||
+    // DST_prime)
+    uint8_t bi[HASH_SIZE] = {0};
+    for (size_t i = 1; i <= ell; i++) {
+        buf.clear();
+        for (size_t j = 0; j < HASH_SIZE; j++) {
+            buf.push_back(b0[j] ^ bi[j]);
+        }
+        buf.push_back(static_cast<uint8_t>(i));
+        buf.insert(buf.end(), dstPrime.begin(), dstPrime.end());
+        Sha256(bi, buf);
+
+        size_t n = std::min(HASH_SIZE, outLen - (i - 1) * HASH_SIZE);
+        std::memcpy(out + (i - 1) * HASH_SIZE, bi, n);
+    }
+}
+
+// HashToField implements hash_to_field, section 5.2, filling count field
+// elements of m coordinates each
+void HashToField(fp_t *elements, size_t count, size_t m, const uint8_t *msg,
+    size_t len, const uint8_t *dst, size_t dstLen) {
+    std::vector<uint8_t> bytes(count * m * FIELD_ELEMENT_BYTES);
+    ExpandMessageXMD(bytes.data(), bytes.size(), msg, len, dst, dstLen);
+
+    bn_t t;
+    bn_new(t);
+    for (size_t i = 0; i < count * m; i++) {
+        // fp_prime_conv reduces mod p
+        bn_read_bin(t, bytes.data() + i * FIELD_ELEMENT_BYTES,
+            FIELD_ELEMENT_BYTES);
+        fp_prime_conv(elements[i], t);
+    }
+    bn_free(t);
+}
+
+bool FpSgn0(const fp_t a) {
+    bn_t t;
+    bn_new(t);
+    fp_prime_back(t, a);
+    bool odd = !bn_is_even(t);
+    bn_free(t);
+    return odd;
+}
+
+// Fp2Sgn0 is sgn0 for m = 2, section 4.1
+bool Fp2Sgn0(fp2_t a) {
+    return FpSgn0(a[0]) || (fp_is_zero(a[0]) && FpSgn0(a[1]));
+}
+
+// MapToCurveSSWU1 maps u to a point (x, y) on E1', section 6.6.2
+void MapToCurveSSWU1(fp_t x, fp_t y, const fp_t u) {
+    SSWUConstants& c = GetConstants();
+    fp_t zu2, tv1, x1, gx;
+
+    // tv1 = Z^2 u^4 + Z u^2
+    fp_sqr(zu2, u);
+    fp_mul(zu2, zu2, c.z1);
+    fp_sqr(tv1, zu2);
+    fp_add(tv1, tv1, zu2);
+
+    // x1 = (-B/A) (1 + 1/tv1), or B/(Z A) if tv1 is zero
+    if (fp_is_zero(tv1)) {
+        fp_copy(x1, c.bOverZA1);
+    } else {
+        fp_inv(tv1, tv1);
+        fp_add_dig(tv1, tv1, 1);
+        fp_mul(x1, c.minusBOverA1, tv1);
+    }
+
+    for (int i = 0; i < 2; i++) {
+        // gx = x^3 + A x + B
+        fp_sqr(gx, x1);
+        fp_add(gx, gx, c.a1);
+        fp_mul(gx, gx, x1);
+        fp_add(gx, gx, c.b1);
+        if (FpSqrt(y, gx)) {
+            break;
+        }
+        // If gx1 is not a square, gx2 for x2 = Z u^2 x1 is
+        if (i == 1) {
+            throw std::logic_error("neither gx1 nor gx2 is a square");
+        }
+        fp_mul(x1, x1, zu2);
+    }
+    fp_copy(x, x1);
+
+    if (FpSgn0(u) != FpSgn0(y)) {
+        fp_neg(y, y);
+    }
+}
+
+// MapToCurveSSWU2 maps u to a point (x, y) on E2', section 6.6.2
+void MapToCurveSSWU2(fp2_t x, fp2_t y, fp2_t u) {
+    SSWUConstants& c = GetConstants();
+    fp2_t zu2, tv1, x1, gx, t;
+
+    // tv1 = Z^2 u^4 + Z u^2
+    fp2_sqr(zu2, u);
+    fp2_mul(zu2, zu2, c.z2);
+    fp2_sqr(tv1, zu2);
+    fp2_add(tv1, tv1, zu2);
+
+    // x1 = (-B/A) (1 + 1/tv1), or B/(Z A) if tv1 is zero
+    if (fp2_is_zero(tv1)) {
+        fp2_copy(x1, c.bOverZA2);
+    } else {
+        fp2_inv(tv1, tv1);
+        fp2_zero(t);
+        fp_set_dig(t[0], 1);
+        fp2_add(tv1, tv1, t);
+        fp2_mul(x1, c.minusBOverA2, tv1);
+    }
+
+    for (int i = 0; i < 2; i++) {
+        // gx = x^3 + A x + B
+        fp2_sqr(gx, x1);
+        fp2_add(gx, gx, c.a2);
+        fp2_mul(gx, gx, x1);
+        fp2_add(gx, gx, c.b2);
+        if (Fp2Sqrt(y, gx)) {
+            break;
+        }
+        // If gx1 is not a square, gx2 for x2 = Z u^2 x1 is
+        if (i == 1) {
+            throw std::logic_error("neither gx1 nor gx2 is a square");
+        }
+        fp2_mul(x1, x1, zu2);
+    }
+    fp2_copy(x, x1);
+
+    if (Fp2Sgn0(u) != Fp2Sgn0(y)) {
+        fp2_neg(y, y);
+    }
+}
+
+// IsoMap1 maps a point on E1' to E1 with the 11-isogeny, section 6.6.3
+void IsoMap1(g1_t point, const fp_t x, const fp_t y) {
+    SSWUConstants& c = GetConstants();
+    fp_t values[4];
+    for (int row = 0; row < 4; row++) {
+        fp_copy(values[row], c.iso1[row][15]);
+        for (int i = 14; i >= 0; i--) {
+            fp_mul(values[row], values[row], x);
+            fp_add(values[row], values[row], c.iso1[row][i]);
+        }
+    }
+    if (fp_is_zero(values[1]) || fp_is_zero(values[3])) {
+        g1_set_infty(point);
+        return;
+    }
+
+    fp_inv(values[1], values[1]);
+    fp_inv(values[3], values[3]);
+    fp_mul(point->x, values[0], values[1]);
+    fp_mul(point->y, values[2], values[3]);
+    fp_mul(point->y, point->y, y);
+    fp_set_dig(point->z, 1);
+    point->norm = 1;
+}
+
+// IsoMap2 maps a point on E2' to E2 with the 3-isogeny, section 6.6.3
+void IsoMap2(g2_t point, fp2_t x, fp2_t y) {
+    SSWUConstants& c = GetConstants();
+    fp2_t values[4];
+    for (int row = 0; row < 4; row++) {
+        fp2_copy(values[row], c.iso2[row][3]);
+        for (int i = 2; i >= 0; i--) {
+            fp2_mul(values[row], values[row], x);
+            fp2_add(values[row], values[row], c.iso2[row][i]);
+        }
+    }
+    if (fp2_is_zero(values[1]) || fp2_is_zero(values[3])) {
+        g2_set_infty(point);
+        return;
+    }
+
+    fp2_inv(values[1], values[1]);
+    fp2_inv(values[3], values[3]);
+    fp2_mul(point->x, values[0], values[1]);
+    fp2_mul(point->y, values[2], values[3]);
+    fp2_mul(point->y, point->y, y);
+    fp2_set_dig(point->z, 1);
+    point->norm = 1;
+}
+
+// MulByHex multiplies by a constant. The point may be outside the subgroup,
+// so this uses plain double-and-add.
+void MulByHex(g1_t out, g1_t point, const char *hex) {
+    bn_t k;
+    bn_new(k);
+    bn_read_str(k, hex, strlen(hex), 16);
+    ep_mul_basic(out, point, k);
+    bn_free(k);
+}
+
+// ClearCofactor1 multiplies by h_eff, section 7
+void ClearCofactor1(g1_t out, g1_t point) {
+    MulByHex(out, point, H_EFF_G1);
+    g1_norm(out, out);
+}
+
+// MulByX multiplies by the curve parameter x
+void MulByX(g2_t out, g2_t point) {
+    bn_t k;
+    bn_new(k);
+    bn_read_str(k, CURVE_X_ABS, strlen(CURVE_X_ABS), 16);
+    ep2_mul_basic(out, point, k);
+    g2_neg(out, out);
+    bn_free(k);
+}
+
+// ClearCofactor2 multiplies by h_eff using the endomorphism psi, as in
+// Appendix G.3 of the RFC
+void ClearCofactor2(g2_t out, g2_t point) {
+    g2_t t1, t2, t3;
+    MulByX(t1, point);
+    ep2_frb(t2, point, 1);
+    g2_dbl(t3, point);
+    ep2_frb(t3, t3, 2);
+    g2_sub(t3, t3, t2);
+    g2_add(t2, t1, t2);
+    MulByX(t2, t2);
+    g2_add(t3, t3, t2);
+    g2_sub(t3, t3, t1);
+    g2_sub(out, t3, point);
+    g2_norm(out, out);
+}
+
+}  // namespace
+
+void HashToG1(g1_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen) {
+    fp_t u[2], x, y;
+    g1_t q0, q1;
+    HashToField(u, 2, 1, msg, len, dst, dstLen);
+    MapToCurveSSWU1(x, y, u[0]);
+    IsoMap1(q0, x, y);
+    MapToCurveSSWU1(x, y, u[1]);
+    IsoMap1(q1, x, y);
+    g1_add(q0, q0, q1);
+    ClearCofactor1(point, q0);
+}
+
+void EncodeToG1(g1_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen) {
+    fp_t u[1], x, y;
+    g1_t q;
+    HashToField(u, 1, 1, msg, len, dst, dstLen);
+    MapToCurveSSWU1(x, y, u[0]);
+    IsoMap1(q, x, y);
+    ClearCofactor1(point, q);
+}
+
+void HashToG2(g2_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen) {
+    fp2_t u[2], x, y;
+    g2_t q0, q1;
+    HashToField(reinterpret_cast<fp_t*>(u), 2, 2, msg, len, dst, dstLen);
+    MapToCurveSSWU2(x, y, u[0]);
+    IsoMap2(q0, x, y);
+    MapToCurveSSWU2(x, y, u[1]);
+    IsoMap2(q1, x, y);
+    g2_add(q0, q0, q1);
+    ClearCofactor2(point, q0);
+}
+
+void EncodeToG2(g2_t point, const uint8_t *msg, size_t len,
+    const uint8_t *dst, size_t dstLen) {
+    fp2_t u[1], x, y;
+    g2_t q;
+    HashToField(reinterpret_cast<fp_t*>(u), 1, 2, msg, len, dst, dstLen);
+    MapToCurveSSWU2(x, y, u[0]);
+    IsoMap2(q, x, y);
+    ClearCofactor2(point, q);
+}
+
+void CHashToG1(void *msg, size_t len, void *dst, size_t dstLen, void *out) {
+    g1_t point;
+    HashToG1(point, static_cast<uint8_t*>(msg), len,
+        static_cast<uint8_t*>(dst), dstLen);
+    WriteG1Element(static_cast<uint8_t*>(out), point);
+}
+
+void CEncodeToG1(void *msg, size_t len, void *dst, size_t dstLen,
+    void *out) {
+    g1_t point;
+    EncodeToG1(point, static_cast<uint8_t*>(msg), len,
+        static_cast<uint8_t*>(dst), dstLen);
+    WriteG1Element(static_cast<uint8_t*>(out), point);
+}
+
+void CHashToG2(void *msg, size_t len, void *dst, size_t dstLen, void *out) {
+    g2_t point;
+    HashToG2(point, static_cast<uint8_t*>(msg), len,
+        static_cast<uint8_t*>(dst), dstLen);
+    WriteG2Element(static_cast<uint8_t*>(out), point);
+}
+
+void CEncodeToG2(void *msg, size_t len, void *dst, size_t dstLen,
+    void *out) {
+    g2_t point;
+    EncodeToG2(point, static_cast<uint8_t*>(msg), len,
+        static_cast<uint8_t*>(dst), dstLen);
+    WriteG2Element(static_cast<uint8_t*>(out), point);
+}
+
+// The map used by the library's own signatures, which takes a message hash
+void CHashToG2Legacy(void *hash, void *out) {
+    g2_t point;
+    g2_map(point, static_cast<uint8_t*>(hash), bls::BLS::MESSAGE_HASH_LEN, 0);
+    WriteG2Element(static_cast<uint8_t*>(out), point);
+}
@@ -0,0 +1,105 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdbool.h>
+// #include <stdlib.h>
+// #include "hashtocurve.h"
+// #include "blschia.h"
+import "C"
+import (
+	"crypto/sha256"
+	"unsafe"
+)
+
+// MessageHashSize is the size in bytes of the message hashes taken by the
+// Prehashed functions
+const MessageHashSize = sha256.Size
+
+// HashToG1 hashes msg to G1 with the hash_to_curve function of RFC 9380, for
+// the suite BLS12381G1_XMD:SHA-256_SSWU_RO_. The output is indistinguishable
+// from a random oracle. dst is the domain separation tag of the application,
+// which must not be empty.
+func HashToG1(msg, dst []byte) (G1Element, error) {
+	var p G1Element
+	err := hashToCurve(msg, dst, func(cMsg unsafe.Pointer, cDST unsafe.Pointer) {
+		C.CHashToG1(cMsg, C.size_t(len(msg)), cDST, C.size_t(len(dst)), p.ptr())
+	})
+	return p, err
+}
+
+// EncodeToG1 hashes msg to G1 with the encode_to_curve function of RFC 9380,
+// for the suite BLS12381G1_XMD:SHA-256_SSWU_NU_. It is about twice as fast as
+// HashToG1, but its output is not uniformly distributed, so it is only
+// suitable for applications which don't need a random oracle.
+func EncodeToG1(msg, dst []byte) (G1Element, error) {
+	var p G1Element
+	err := hashToCurve(msg, dst, func(cMsg unsafe.Pointer, cDST unsafe.Pointer) {
+		C.CEncodeToG1(cMsg, C.size_t(len(msg)), cDST, C.size_t(len(dst)), p.ptr())
+	})
+	return p, err
+}
+
+// HashToG2 hashes msg to G2 with the hash_to_curve function of RFC 9380, for
+// the suite BLS12381G2_XMD:SHA-256_SSWU_RO_, which is how the IETF signature
+// schemes hash messages. dst must not be empty.
+func HashToG2(msg, dst []byte) (G2Element, error) {
+	var p G2Element
+	err := hashToCurve(msg, dst, func(cMsg unsafe.Pointer, cDST unsafe.Pointer) {
+		C.CHashToG2(cMsg, C.size_t(len(msg)), cDST, C.size_t(len(dst)), p.ptr())
+	})
+	return p, err
+}
+
+// EncodeToG2 hashes msg to G2 with the encode_to_curve function of RFC 9380,
+// for the suite BLS12381G2_XMD:SHA-256_SSWU_NU_. Like EncodeToG1, its output
+// is not uniformly distributed.
+func EncodeToG2(msg, dst []byte) (G2Element, error) {
+	var p G2Element
+	err := hashToCurve(msg, dst, func(cMsg unsafe.Pointer, cDST unsafe.Pointer) {
+		C.CEncodeToG2(cMsg, C.size_t(len(msg)), cDST, C.size_t(len(dst)), p.ptr())
+	})
+	return p, err
+}
+
+// HashToG2Legacy hashes msg to G2 the way PrivateKey.Sign and the other
+// signatures of this library do: the SHA-256 hash of msg is mapped with the
+// Shallue-van de Woestijne method of relic. It is not compatible with
+// HashToG2.
+func HashToG2Legacy(msg []byte) G2Element {
+	hash := sha256.Sum256(msg)
+	p, _ := HashToG2LegacyPrehashed(hash[:])
+	return p
+}
+
+// HashToG2LegacyPrehashed maps a 32-byte message hash to G2 like
+// HashToG2Legacy, as done by PrivateKey.SignPrehashed.
+func HashToG2LegacyPrehashed(hash []byte) (G2Element, error) {
+	if err := checkLength("message hash", hash, MessageHashSize); err != nil {
+		return G2Element{}, err
+	}
+
+	// Get a C pointer to bytes
+	cHashPtr := C.CBytes(hash)
+	defer C.free(cHashPtr)
+
+	var p G2Element
+	C.CHashToG2Legacy(cHashPtr, p.ptr())
+	return p, nil
+}
+
+// hashToCurve checks dst and calls hash with C copies of msg and dst
+func hashToCurve(msg, dst []byte, hash func(cMsg unsafe.Pointer, cDST unsafe.Pointer)) error {
+	if len(dst) == 0 {
+		return &Error{Err: ErrLengthMismatch, Msg: "domain separation tag must not be empty"}
+	}
+
+	// Get C pointers to bytes
+	cMessagePtr := C.CBytes(msg)
+	defer C.free(cMessagePtr)
+	cDSTPtr := C.CBytes(dst)
+	defer C.free(cDSTPtr)
+
+	hash(cMessagePtr, cDSTPtr)
+	return nil
+}
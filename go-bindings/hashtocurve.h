@@ -0,0 +1,35 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#ifndef GO_BINDINGS_HASHTOCURVE_H_
+#define GO_BINDINGS_HASHTOCURVE_H_
+#include <stddef.h>
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// Hash a message with a domain separation tag to a G1Element or G2Element
+void CHashToG1(void *msg, size_t len, void *dst, size_t dstLen, void *out);
+void CEncodeToG1(void *msg, size_t len, void *dst, size_t dstLen,
+    void *out);
+void CHashToG2(void *msg, size_t len, void *dst, size_t dstLen, void *out);
+void CEncodeToG2(void *msg, size_t len, void *dst, size_t dstLen,
+    void *out);
+
+void CHashToG2Legacy(void *hash, void *out);
+
+#ifdef __cplusplus
+}
+#endif
+#endif  // GO_BINDINGS_HASHTOCURVE_H_
@@ -0,0 +1,39 @@
+package blschia
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// HKDF derives length bytes of key material from secret using HKDF-SHA256, as
+// specified by RFC 5869. salt and info may be nil; info should identify the
+// purpose of the derived key, so that different uses get different keys.
+func HKDF(secret, salt, info []byte, length int) ([]byte, error) {
+	if length < 0 || length > 255*sha256.Size {
+		return nil, &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("HKDF output must be at most %d bytes, got %d", 255*sha256.Size, length),
+		}
+	}
+	okm := make([]byte, length)
+	hkdfSHA256(secret, salt, info, okm)
+	return okm, nil
+}
+
+// DHSharedKey derives a symmetric key of the given length from a shared
+// secret computed by PrivateKey.DHKeyExchange, by applying HKDF to the
+// serialized point. Both parties must use the same salt and info.
+func DHSharedKey(shared PublicKey, salt, info []byte, length int) ([]byte, error) {
+	secret := shared.Serialize()
+	defer Wipe(secret)
+	return HKDF(secret, salt, info, length)
+}
+
+// hkdfSHA256 fills okm with HKDF-SHA256 output. len(okm) must be at most
+// 255*sha256.Size, the limit past which the reader fails.
+func hkdfSHA256(secret, salt, info, okm []byte) {
+	io.ReadFull(hkdf.New(sha256.New, secret, salt, info), okm)
+}
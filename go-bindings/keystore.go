@@ -0,0 +1,410 @@
+package blschia
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// EIP-2335 keystores store a PrivateKey encrypted with a key derived from a
+// password. See https://eips.ethereum.org/EIPS/eip-2335.
+
+// KeystoreKDF selects the function which derives the encryption key of a
+// keystore from its password
+type KeystoreKDF int
+
+const (
+	// ScryptKDF uses scrypt with N = 2^18, r = 8 and p = 1. This is the
+	// default.
+	ScryptKDF KeystoreKDF = iota
+
+	// PBKDF2KDF uses PBKDF2-HMAC-SHA256 with 2^18 iterations. It needs much
+	// less memory than scrypt, but is cheaper to brute force.
+	PBKDF2KDF
+)
+
+// KeystoreOptions holds the optional fields of a keystore
+type KeystoreOptions struct {
+	// KDF is the key derivation function, ScryptKDF by default
+	KDF KeystoreKDF
+	// Path is the derivation path of the key, e.g. "m/12381/3600/0/0/0". It
+	// is only informational, and must be valid for ParsePath if set.
+	Path string
+	// Description is a free form description of the key
+	Description string
+}
+
+const (
+	keystoreVersion = 4
+	// keystoreDKLen is the size of the derived key. Its first half is the
+	// AES-128 key, and the second half authenticates the ciphertext.
+	keystoreDKLen = 32
+
+	scryptN      = 1 << 18
+	scryptR      = 8
+	scryptP      = 1
+	pbkdf2C      = 1 << 18
+	pbkdf2PRF    = "hmac-sha256"
+	kdfSaltSize  = 32
+	cipherIVSize = aes.BlockSize
+
+	// maxScryptMemory bounds the memory a keystore's scrypt parameters may
+	// ask for, so that decrypting an untrusted keystore can't exhaust memory
+	maxScryptMemory = 1 << 30
+	// maxScryptCost bounds n * p, and maxPBKDF2C the iteration count, so that
+	// decrypting an untrusted keystore can't keep a CPU busy for long. Both
+	// allow 16 times the cost of the defaults.
+	maxScryptCost = 1 << 22
+	maxPBKDF2C    = 1 << 22
+)
+
+type keystore struct {
+	Crypto      keystoreCrypto `json:"crypto"`
+	Description string         `json:"description"`
+	PubKey      string         `json:"pubkey"`
+	Path        string         `json:"path"`
+	UUID        string         `json:"uuid"`
+	Version     int            `json:"version"`
+}
+
+type keystoreCrypto struct {
+	KDF      keystoreModule `json:"kdf"`
+	Checksum keystoreModule `json:"checksum"`
+	Cipher   keystoreModule `json:"cipher"`
+}
+
+type keystoreModule struct {
+	Function string          `json:"function"`
+	Params   json.RawMessage `json:"params"`
+	Message  string          `json:"message"`
+}
+
+type scryptParams struct {
+	DKLen int    `json:"dklen"`
+	N     int    `json:"n"`
+	P     int    `json:"p"`
+	R     int    `json:"r"`
+	Salt  string `json:"salt"`
+}
+
+type pbkdf2Params struct {
+	DKLen int    `json:"dklen"`
+	C     int    `json:"c"`
+	PRF   string `json:"prf"`
+	Salt  string `json:"salt"`
+}
+
+type cipherParams struct {
+	IV string `json:"iv"`
+}
+
+// EncryptKeystore encrypts sk with password into an EIP-2335 JSON keystore,
+// using scrypt to derive the encryption key.
+//
+// As EIP-2335 requires, the password is normalized to Unicode NFKD and its
+// control characters are removed, so that keystores with non-ASCII passwords
+// are compatible with other implementations.
+func EncryptKeystore(sk PrivateKey, password string) ([]byte, error) {
+	return EncryptKeystoreWithOptions(sk, password, KeystoreOptions{})
+}
+
+// EncryptKeystoreWithOptions encrypts sk like EncryptKeystore, with the
+// options given by opts. The pubkey field holds sk's public key in the
+// compressed encoding EIP-2335 requires, as written by G1Element.Serialize.
+func EncryptKeystoreWithOptions(sk PrivateKey, password string, opts KeystoreOptions) ([]byte, error) {
+	if opts.Path != "" {
+		if _, err := ParsePath(opts.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	salt, err := randomBytes(kdfSaltSize)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := randomBytes(cipherIVSize)
+	if err != nil {
+		return nil, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	var kdf keystoreModule
+	switch opts.KDF {
+	case ScryptKDF:
+		kdf.Function = "scrypt"
+		kdf.Params, _ = json.Marshal(scryptParams{
+			DKLen: keystoreDKLen, N: scryptN, P: scryptP, R: scryptR,
+			Salt: hex.EncodeToString(salt),
+		})
+	case PBKDF2KDF:
+		kdf.Function = "pbkdf2"
+		kdf.Params, _ = json.Marshal(pbkdf2Params{
+			DKLen: keystoreDKLen, C: pbkdf2C, PRF: pbkdf2PRF,
+			Salt: hex.EncodeToString(salt),
+		})
+	default:
+		return nil, &Error{
+			Err: ErrInvalidKeystore,
+			Msg: fmt.Sprintf("unknown KDF %d", opts.KDF),
+		}
+	}
+	dk, err := deriveKeystoreKey(kdf, password)
+	if err != nil {
+		return nil, err
+	}
+	defer Wipe(dk)
+
+	var secret [PrivateKeySize]byte
+	defer Wipe(secret[:])
+	sk.SerializeInto(secret[:])
+	ciphertext, err := aes128CTR(dk[:16], iv, secret[:])
+	if err != nil {
+		return nil, err
+	}
+
+	cipherParamsJSON, _ := json.Marshal(cipherParams{IV: hex.EncodeToString(iv)})
+	ks := keystore{
+		Crypto: keystoreCrypto{
+			KDF: kdf,
+			Checksum: keystoreModule{
+				Function: "sha256",
+				Params:   json.RawMessage("{}"),
+				Message:  hex.EncodeToString(keystoreChecksum(dk, ciphertext)),
+			},
+			Cipher: keystoreModule{
+				Function: "aes-128-ctr",
+				Params:   cipherParamsJSON,
+				Message:  hex.EncodeToString(ciphertext),
+			},
+		},
+		Description: opts.Description,
+		PubKey:      hex.EncodeToString(sk.G1Element().Serialize()),
+		Path:        opts.Path,
+		UUID:        uuid,
+		Version:     keystoreVersion,
+	}
+	return json.MarshalIndent(ks, "", "    ")
+}
+
+// DecryptKeystore decrypts the PrivateKey in an EIP-2335 JSON keystore with
+// password, which is processed like for EncryptKeystore. It returns
+// ErrInvalidPassword if the checksum does not match, and ErrInvalidKeystore if
+// the keystore is malformed or unsupported, or if its KDF parameters need far
+// more memory or work than the defaults, or if its pubkey field, when
+// present, is not the public key of the decrypted PrivateKey.
+func DecryptKeystore(data []byte, password string) (PrivateKey, error) {
+	var ks keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return PrivateKey{}, &Error{Err: ErrInvalidKeystore, Msg: err.Error()}
+	}
+	if ks.Version != keystoreVersion {
+		return PrivateKey{}, errKeystore("version must be %d, got %d", keystoreVersion, ks.Version)
+	}
+	if ks.Crypto.Checksum.Function != "sha256" {
+		return PrivateKey{}, errKeystore("unsupported checksum function %q", ks.Crypto.Checksum.Function)
+	}
+	if ks.Crypto.Cipher.Function != "aes-128-ctr" {
+		return PrivateKey{}, errKeystore("unsupported cipher function %q", ks.Crypto.Cipher.Function)
+	}
+
+	var cp cipherParams
+	if err := json.Unmarshal(ks.Crypto.Cipher.Params, &cp); err != nil {
+		return PrivateKey{}, errKeystore("invalid cipher params: %v", err)
+	}
+	iv, err := decodeKeystoreHex("cipher iv", cp.IV)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	if len(iv) != cipherIVSize {
+		return PrivateKey{}, errKeystore("cipher iv must be %d bytes, got %d", cipherIVSize, len(iv))
+	}
+	ciphertext, err := decodeKeystoreHex("cipher message", ks.Crypto.Cipher.Message)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	checksum, err := decodeKeystoreHex("checksum message", ks.Crypto.Checksum.Message)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+
+	dk, err := deriveKeystoreKey(ks.Crypto.KDF, password)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	defer Wipe(dk)
+
+	if subtle.ConstantTimeCompare(keystoreChecksum(dk, ciphertext), checksum) != 1 {
+		return PrivateKey{}, &Error{
+			Err: ErrInvalidPassword,
+			Msg: "checksum mismatch, the password is wrong or the keystore corrupted",
+		}
+	}
+
+	secret, err := aes128CTR(dk[:16], iv, ciphertext)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	defer Wipe(secret)
+	sk, err := PrivateKeyFromBytes(secret, false)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+
+	if ks.PubKey != "" {
+		pubKey, err := decodeKeystoreHex("pubkey", ks.PubKey)
+		if err != nil {
+			sk.Free()
+			return PrivateKey{}, err
+		}
+		if !bytes.Equal(pubKey, sk.G1Element().Serialize()) {
+			sk.Free()
+			return PrivateKey{}, errKeystore("pubkey %s does not match the decrypted key", ks.PubKey)
+		}
+	}
+	return sk, nil
+}
+
+// deriveKeystoreKey runs the KDF module on the processed password
+func deriveKeystoreKey(kdf keystoreModule, password string) ([]byte, error) {
+	pw := processPassword(password)
+	defer Wipe(pw)
+
+	switch kdf.Function {
+	case "scrypt":
+		var params scryptParams
+		if err := json.Unmarshal(kdf.Params, &params); err != nil {
+			return nil, errKeystore("invalid scrypt params: %v", err)
+		}
+		if params.DKLen != keystoreDKLen {
+			return nil, errKeystore("dklen must be %d, got %d", keystoreDKLen, params.DKLen)
+		}
+		if params.N <= 1 || params.N&(params.N-1) != 0 {
+			return nil, errKeystore("scrypt n must be a power of two greater than 1, got %d", params.N)
+		}
+		if params.R <= 0 || params.P <= 0 || params.R*params.P >= 1<<30 {
+			return nil, errKeystore("invalid scrypt parameters r = %d, p = %d", params.R, params.P)
+		}
+		if params.N > maxScryptMemory/128/params.R || params.P > maxScryptMemory/128/params.R {
+			return nil, errKeystore("scrypt parameters n = %d, r = %d, p = %d need too much memory", params.N, params.R, params.P)
+		}
+		if params.N > maxScryptCost/params.P {
+			return nil, errKeystore("scrypt parameters n = %d, p = %d are too expensive", params.N, params.P)
+		}
+		salt, err := decodeKeystoreHex("kdf salt", params.Salt)
+		if err != nil {
+			return nil, err
+		}
+		dk, err := scrypt.Key(pw, salt, params.N, params.R, params.P, params.DKLen)
+		if err != nil {
+			return nil, errKeystore("invalid scrypt parameters: %v", err)
+		}
+		return dk, nil
+
+	case "pbkdf2":
+		var params pbkdf2Params
+		if err := json.Unmarshal(kdf.Params, &params); err != nil {
+			return nil, errKeystore("invalid pbkdf2 params: %v", err)
+		}
+		if params.DKLen != keystoreDKLen {
+			return nil, errKeystore("dklen must be %d, got %d", keystoreDKLen, params.DKLen)
+		}
+		if params.PRF != pbkdf2PRF {
+			return nil, errKeystore("unsupported pbkdf2 prf %q", params.PRF)
+		}
+		if params.C <= 0 || params.C > maxPBKDF2C {
+			return nil, errKeystore("pbkdf2 c must be between 1 and %d, got %d", maxPBKDF2C, params.C)
+		}
+		salt, err := decodeKeystoreHex("kdf salt", params.Salt)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(pw, salt, params.C, params.DKLen, sha256.New), nil
+
+	default:
+		return nil, errKeystore("unsupported kdf function %q", kdf.Function)
+	}
+}
+
+// processPassword normalizes password to NFKD and removes the C0, C1 and
+// Delete control codes, as EIP-2335 requires
+func processPassword(password string) []byte {
+	normalized := norm.NFKD.String(password)
+	pw := make([]byte, 0, len(normalized))
+	for _, r := range normalized {
+		if r <= 0x1f || (r >= 0x7f && r <= 0x9f) {
+			continue
+		}
+		var buf [utf8.UTFMax]byte
+		n := utf8.EncodeRune(buf[:], r)
+		pw = append(pw, buf[:n]...)
+	}
+	return pw
+}
+
+// keystoreChecksum authenticates the ciphertext with the second half of the
+// derived key
+func keystoreChecksum(dk, ciphertext []byte) []byte {
+	h := sha256.New()
+	h.Write(dk[16:32])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+func aes128CTR(key, iv, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+func decodeKeystoreHex(what, s string) ([]byte, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, errKeystore("invalid %s: %v", what, err)
+	}
+	return data, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// newUUID returns a random version 4 UUID, as specified by RFC 4122
+func newUUID() (string, error) {
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func errKeystore(format string, a ...interface{}) error {
+	return &Error{
+		Err: ErrInvalidKeystore,
+		Msg: fmt.Sprintf(format, a...),
+	}
+}
@@ -0,0 +1,217 @@
+package blschia_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+// The test vectors of EIP-2335. The password normalizes to "testpassword🔑".
+const (
+	keystorePassword = "𝔱𝔢𝔰𝔱𝔭𝔞𝔰𝔰𝔴𝔬𝔯𝔡🔑"
+	keystoreSecret   = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+)
+
+var keystoreScrypt = `{
+    "crypto": {
+        "kdf": {
+            "function": "scrypt",
+            "params": {
+                "dklen": 32,
+                "n": 262144,
+                "p": 1,
+                "r": 8,
+                "salt": "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"
+            },
+            "message": ""
+        },
+        "checksum": {
+            "function": "sha256",
+            "params": {},
+            "message": "d2217fe5f3e9a1e34581ef8a78f7c9928e436d36dacc5e846690a5581e8ea484"
+        },
+        "cipher": {
+            "function": "aes-128-ctr",
+            "params": {
+                "iv": "264daa3f303d7259501c93d997d84fe6"
+            },
+            "message": "06ae90d55fe0a6e9c5c3bc5b170827b2e5cce3929ed3f116c2811e6366dfe20f"
+        }
+    },
+    "description": "This is a test keystore that uses scrypt to secure the secret.",
+    "pubkey": "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07",
+    "path": "m/12381/60/3141592653/589793238",
+    "uuid": "1d85ae20-35c5-4611-98e8-aa14a633906f",
+    "version": 4
+}`
+
+var keystorePBKDF2 = `{
+    "crypto": {
+        "kdf": {
+            "function": "pbkdf2",
+            "params": {
+                "dklen": 32,
+                "c": 262144,
+                "prf": "hmac-sha256",
+                "salt": "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"
+            },
+            "message": ""
+        },
+        "checksum": {
+            "function": "sha256",
+            "params": {},
+            "message": "8a9f5d9912ed7e75ea794bc5a89bca5f193721d30868ade6f73043c6ea6febf1"
+        },
+        "cipher": {
+            "function": "aes-128-ctr",
+            "params": {
+                "iv": "264daa3f303d7259501c93d997d84fe6"
+            },
+            "message": "cee03fde2af33149775b7223e7845e4fb2c8ae1792e5f99fe9ecf474cc8c16ad"
+        }
+    },
+    "description": "This is a test keystore that uses PBKDF2 to secure the secret.",
+    "pubkey": "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07",
+    "path": "m/12381/60/0/0",
+    "uuid": "64625def-3331-4eea-ab6f-782f3ed16a83",
+    "version": 4
+}`
+
+func TestDecryptKeystoreVectors(t *testing.T) {
+	for name, ks := range map[string]string{"scrypt": keystoreScrypt, "pbkdf2": keystorePBKDF2} {
+		sk, err := bls.DecryptKeystore([]byte(ks), keystorePassword)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if got := hex.EncodeToString(sk.Serialize()); got != keystoreSecret {
+			t.Errorf("%s: got %s, expected %s", name, got, keystoreSecret)
+		}
+		sk.Free()
+	}
+
+	// The password is normalized to NFKD, and control characters are removed
+	for _, password := range []string{"testpassword🔑", "\ttest\x7fpass\u0085word🔑\n"} {
+		sk, err := bls.DecryptKeystore([]byte(keystorePBKDF2), password)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", password, err)
+		}
+		sk.Free()
+	}
+
+	_, err := bls.DecryptKeystore([]byte(keystorePBKDF2), "testpassword")
+	expectError(t, err, bls.ErrInvalidPassword)
+}
+
+func TestEncryptKeystore(t *testing.T) {
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+
+	data, err := bls.EncryptKeystore(sk, keystorePassword)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fields struct {
+		Crypto struct {
+			KDF struct {
+				Function string
+			}
+		}
+		PubKey  string
+		UUID    string
+		Version int
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fields.Crypto.KDF.Function != "scrypt" || fields.Version != 4 {
+		t.Errorf("got %+v, expected a version 4 scrypt keystore", fields)
+	}
+	if expected := hex.EncodeToString(sk.G1Element().Serialize()); fields.PubKey != expected {
+		t.Errorf("got pubkey %s, expected %s", fields.PubKey, expected)
+	}
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuidV4.MatchString(fields.UUID) {
+		t.Errorf("got uuid %s, expected a version 4 UUID", fields.UUID)
+	}
+
+	decrypted, err := bls.DecryptKeystore(data, keystorePassword)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decrypted.Equal(sk) {
+		t.Error("decrypted key should equal the original")
+	}
+
+	// A keystore with options, and fresh randomness each time
+	opts := bls.KeystoreOptions{KDF: bls.PBKDF2KDF, Path: "m/12381/3600/0/0/0", Description: "test"}
+	data1, err := bls.EncryptKeystoreWithOptions(sk, keystorePassword, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data2, _ := bls.EncryptKeystoreWithOptions(sk, keystorePassword, opts)
+	if bytes.Equal(data1, data2) {
+		t.Error("keystores should use random salts, IVs and UUIDs")
+	}
+	for _, field := range []string{`"function": "pbkdf2"`, `"path": "m/12381/3600/0/0/0"`, `"description": "test"`} {
+		if !strings.Contains(string(data1), field) {
+			t.Errorf("expected %s in %s", field, data1)
+		}
+	}
+	decrypted2, err := bls.DecryptKeystore(data1, keystorePassword)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decrypted2.Equal(sk) {
+		t.Error("decrypted key should equal the original")
+	}
+
+	_, err = bls.EncryptKeystoreWithOptions(sk, keystorePassword, bls.KeystoreOptions{KDF: bls.PBKDF2KDF, Path: "12381"})
+	expectError(t, err, bls.ErrInvalidPath)
+	_, err = bls.EncryptKeystoreWithOptions(sk, keystorePassword, bls.KeystoreOptions{KDF: 7})
+	expectError(t, err, bls.ErrInvalidKeystore)
+
+	decrypted2.Free()
+	decrypted.Free()
+	sk.Free()
+}
+
+func TestDecryptKeystoreInvalid(t *testing.T) {
+	replace := func(old, new string) []byte {
+		if !strings.Contains(keystoreScrypt, old) {
+			t.Fatalf("%s is not in the keystore", old)
+		}
+		return []byte(strings.Replace(keystoreScrypt, old, new, 1))
+	}
+
+	for _, data := range [][]byte{
+		[]byte("not json"),
+		replace(`"version": 4`, `"version": 3`),
+		replace(`"function": "scrypt"`, `"function": "argon2"`),
+		replace(`"function": "sha256"`, `"function": "sha3"`),
+		replace(`"function": "aes-128-ctr"`, `"function": "aes-256-gcm"`),
+		replace(`"dklen": 32`, `"dklen": 16`),
+		replace(`"n": 262144`, `"n": 1000`),
+		replace(`"n": 262144`, `"n": 1073741824`),
+		replace(`"p": 1,`, `"p": 32,`),
+		replace(`"r": 8`, `"r": 0`),
+		replace(`"iv": "264daa3f303d7259501c93d997d84fe6"`, `"iv": "264daa3f"`),
+		replace(`"salt": "d4e5`, `"salt": "zz`),
+	} {
+		_, err := bls.DecryptKeystore(data, keystorePassword)
+		expectError(t, err, bls.ErrInvalidKeystore)
+	}
+
+	data := []byte(strings.Replace(keystorePBKDF2, `"c": 262144`, `"c": 8388608`, 1))
+	_, err := bls.DecryptKeystore(data, keystorePassword)
+	expectError(t, err, bls.ErrInvalidKeystore)
+
+	// The pubkey field must match the decrypted key, here it has the
+	// compression flag cleared
+	data = []byte(strings.Replace(keystorePBKDF2, `"pubkey": "9612`, `"pubkey": "1612`, 1))
+	_, err = bls.DecryptKeystore(data, keystorePassword)
+	expectError(t, err, bls.ErrInvalidKeystore)
+}
@@ -0,0 +1,172 @@
+package blschia
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// englishWordlist holds the 2048 words of the BIP-39 English wordlist, each
+// encoding 11 bits
+var englishWordlist = strings.Split(englishWords, "\n")
+
+const (
+	// seedIterations is the number of PBKDF2 iterations used by MnemonicToSeed
+	seedIterations = 2048
+	// seedSize is the size in bytes of the seed returned by MnemonicToSeed
+	seedSize = 64
+)
+
+// NewEntropy returns bits of random entropy, for use with NewMnemonic. bits
+// must be a multiple of 32 from 128 to 256.
+func NewEntropy(bits int) ([]byte, error) {
+	if bits%8 != 0 {
+		return nil, errEntropySize(bits)
+	}
+	if err := checkEntropySize(bits / 8); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+// NewMnemonic encodes entropy as a BIP-39 mnemonic sentence of English words,
+// separated by single spaces. entropy must be 16, 20, 24, 28 or 32 bytes long,
+// giving 12, 15, 18, 21 or 24 words respectively.
+func NewMnemonic(entropy []byte) (string, error) {
+	if err := checkEntropySize(len(entropy)); err != nil {
+		return "", err
+	}
+
+	// The entropy is followed by the first len(entropy)/4 bits of its hash as
+	// a checksum, and every 11 bits of the result select a word
+	hash := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), hash[0])
+	defer Wipe(data)
+	defer Wipe(hash[:])
+
+	numWords := (len(entropy)*8 + len(entropy)/4) / 11
+	words := make([]string, numWords)
+	for i := range words {
+		index := 0
+		for bit := i * 11; bit < (i+1)*11; bit++ {
+			index = index<<1 | int(data[bit/8]>>(7-uint(bit%8))&1)
+		}
+		words[i] = englishWordlist[index]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// GenerateMnemonic returns a new mnemonic sentence encoding bits of random
+// entropy, from 128 for 12 words up to 256 for 24 words.
+func GenerateMnemonic(bits int) (string, error) {
+	entropy, err := NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	defer Wipe(entropy)
+	return NewMnemonic(entropy)
+}
+
+// MnemonicToEntropy decodes a mnemonic sentence created by NewMnemonic. Words
+// may be separated by any whitespace. It returns ErrInvalidMnemonic if the
+// number of words is wrong or a word is not in the wordlist, and
+// ErrChecksumMismatch if the checksum does not match.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, &Error{
+			Err: ErrInvalidMnemonic,
+			Msg: fmt.Sprintf("must have 12, 15, 18, 21 or 24 words, got %d", len(words)),
+		}
+	}
+
+	data := make([]byte, (len(words)*11+7)/8)
+	defer Wipe(data)
+	for i, word := range words {
+		index := sort.SearchStrings(englishWordlist, word)
+		if index == len(englishWordlist) || englishWordlist[index] != word {
+			// Don't include the word, which may be most of a secret
+			return nil, &Error{
+				Err: ErrInvalidMnemonic,
+				Msg: fmt.Sprintf("word %d is not in the wordlist", i+1),
+			}
+		}
+		for bit := 0; bit < 11; bit++ {
+			pos := i*11 + bit
+			data[pos/8] |= byte(index>>(10-uint(bit))&1) << (7 - uint(pos%8))
+		}
+	}
+
+	// Each 3 words hold 32 bits of entropy and 1 bit of checksum
+	entropySize := len(words) / 3 * 4
+	checksumBits := uint(len(words) / 3)
+	hash := sha256.Sum256(data[:entropySize])
+	defer Wipe(hash[:])
+	mask := byte(0xff) << (8 - checksumBits)
+	if hash[0]&mask != data[entropySize]&mask {
+		return nil, &Error{
+			Err: ErrChecksumMismatch,
+			Msg: "the mnemonic is corrupted or mistyped",
+		}
+	}
+	return append([]byte{}, data[:entropySize]...), nil
+}
+
+// ValidateMnemonic checks a mnemonic sentence like MnemonicToEntropy, and
+// returns nil if it is valid.
+func ValidateMnemonic(mnemonic string) error {
+	entropy, err := MnemonicToEntropy(mnemonic)
+	Wipe(entropy)
+	return err
+}
+
+// MnemonicToSeed validates a mnemonic sentence and derives the 64 byte BIP-39
+// seed from it with PBKDF2-HMAC-SHA512, salted with the optional passphrase.
+// Both are normalized to Unicode NFKD first, as BIP-39 requires, so any
+// composition of a non-ASCII passphrase gives the same seed.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic = norm.NFKD.String(mnemonic)
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	password := []byte(strings.Join(strings.Fields(mnemonic), " "))
+	defer Wipe(password)
+	salt := []byte("mnemonic" + norm.NFKD.String(passphrase))
+	defer Wipe(salt)
+	return pbkdf2.Key(password, salt, seedIterations, seedSize, sha512.New), nil
+}
+
+// ExtendedPrivateKeyFromMnemonic derives the master ExtendedPrivateKey from
+// the seed given by MnemonicToSeed.
+func ExtendedPrivateKeyFromMnemonic(mnemonic, passphrase string) (ExtendedPrivateKey, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return ExtendedPrivateKey{}, err
+	}
+	defer Wipe(seed)
+	return ExtendedPrivateKeyFromSeed(seed), nil
+}
+
+func checkEntropySize(size int) error {
+	if size%4 != 0 || size < 16 || size > 32 {
+		return errEntropySize(size * 8)
+	}
+	return nil
+}
+
+func errEntropySize(bits int) error {
+	return &Error{
+		Err: ErrLengthMismatch,
+		Msg: fmt.Sprintf("entropy must be 128, 160, 192, 224 or 256 bits, got %d", bits),
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include "msm.h"
+#include "curve.h"
+
+// Multi-scalar multiplication with Pippenger's bucket method. Each scalar is
+// cut into windows of c bits. For every window, the points are added into the
+// bucket of their digit, and the buckets are summed with weights 1 to 2^c - 1
+// using two running sums. That costs about len + 2^(c+1) additions per window
+// instead of a full double-and-add per point. Scalars are public, so none of
+// this runs in constant time.
+
+namespace {
+
+// The group order is smaller than 2^255
+const size_t SCALAR_BITS = 255;
+
+struct G1Ops {
+    typedef g1_t Point;
+    static void SetInfty(ep_st *p) { g1_set_infty(p); }
+    static void Add(ep_st *r, ep_st *p, ep_st *q) { g1_add(r, p, q); }
+    static void Dbl(ep_st *r, ep_st *p) { g1_dbl(r, p); }
+};
+
+struct G2Ops {
+    typedef g2_t Point;
+    static void SetInfty(ep2_st *p) { g2_set_infty(p); }
+    static void Add(ep2_st *r, ep2_st *p, ep2_st *q) { g2_add(r, p, q); }
+    static void Dbl(ep2_st *r, ep2_st *p) { g2_dbl(r, p); }
+};
+
+// WindowBits picks the window size for len points. Around log2(len) - 3
+// balances the additions into the buckets against summing them.
+size_t WindowBits(size_t len) {
+    size_t log = 0;
+    while ((len >> (log + 1)) != 0) {
+        log++;
+    }
+    return log > 5 ? log - 3 : 2;
+}
+
+// Digit returns the bits [start, start + bits) of a big-endian scalar
+size_t Digit(const uint8_t *scalar, size_t start, size_t bits) {
+    size_t digit = 0;
+    for (size_t i = 0; i < bits && start + i < SCALAR_BITS; i++) {
+        size_t bit = start + i;
+        if ((scalar[SCALAR_SIZE - 1 - bit / 8] >> (bit % 8)) & 1) {
+            digit |= size_t(1) << i;
+        }
+    }
+    return digit;
+}
+
+template <typename Ops>
+void Pippenger(typename Ops::Point out, typename Ops::Point *points,
+    const uint8_t *scalars, size_t len) {
+    Ops::SetInfty(out);
+    if (len == 0) {
+        return;
+    }
+
+    size_t c = WindowBits(len);
+    size_t numBuckets = (size_t(1) << c) - 1;
+    typename Ops::Point *buckets = new typename Ops::Point[numBuckets];
+    typename Ops::Point running, sum;
+
+    size_t numWindows = (SCALAR_BITS + c - 1) / c;
+    for (size_t w = numWindows; w-- > 0;) {
+        for (size_t i = 0; i < c; i++) {
+            Ops::Dbl(out, out);
+        }
+
+        for (size_t i = 0; i < numBuckets; i++) {
+            Ops::SetInfty(buckets[i]);
+        }
+        for (size_t i = 0; i < len; i++) {
+            size_t digit = Digit(scalars + i * SCALAR_SIZE, w * c, c);
+            if (digit != 0) {
+                Ops::Add(buckets[digit - 1], buckets[digit - 1], points[i]);
+            }
+        }
+
+        // sum = sum_k k * bucket[k], as running adds bucket[k] for the k-th
+        // time when going down from the top bucket
+        Ops::SetInfty(running);
+        Ops::SetInfty(sum);
+        for (size_t k = numBuckets; k-- > 0;) {
+            Ops::Add(running, running, buckets[k]);
+            Ops::Add(sum, sum, running);
+        }
+        Ops::Add(out, out, sum);
+    }
+
+    delete[] buckets;
+}
+
+}  // namespace
+
+void G1MultiScalarMul(g1_t out, g1_t *points, const uint8_t *scalars,
+    size_t len) {
+    Pippenger<G1Ops>(out, points, scalars, len);
+}
+
+void G2MultiScalarMul(g2_t out, g2_t *points, const uint8_t *scalars,
+    size_t len) {
+    Pippenger<G2Ops>(out, points, scalars, len);
+}
+
+void CG1MultiScalarMul(void *points, void *scalars, size_t len, void *out) {
+    uint8_t *in = static_cast<uint8_t*>(points);
+    g1_t *native = new g1_t[len];
+    for (size_t i = 0; i < len; i++) {
+        ReadG1Element(native[i], in + i * G1_ELEMENT_SIZE);
+    }
+    g1_t sum;
+    G1MultiScalarMul(sum, native, static_cast<uint8_t*>(scalars), len);
+    WriteG1Element(static_cast<uint8_t*>(out), sum);
+    delete[] native;
+}
+
+void CG2MultiScalarMul(void *points, void *scalars, size_t len, void *out) {
+    uint8_t *in = static_cast<uint8_t*>(points);
+    g2_t *native = new g2_t[len];
+    for (size_t i = 0; i < len; i++) {
+        ReadG2Element(native[i], in + i * G2_ELEMENT_SIZE);
+    }
+    g2_t sum;
+    G2MultiScalarMul(sum, native, static_cast<uint8_t*>(scalars), len);
+    WriteG2Element(static_cast<uint8_t*>(out), sum);
+    delete[] native;
+}
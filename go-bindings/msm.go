@@ -0,0 +1,53 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdbool.h>
+// #include <stdlib.h>
+// #include "msm.h"
+// #include "blschia.h"
+import "C"
+import "fmt"
+
+// G1MultiScalarMul returns the sum of scalars[i] * points[i], computed with
+// Pippenger's method, which is much faster than multiplying every point on
+// its own once there are more than a few dozen of them. It does not run in
+// constant time, so the scalars must not be secret. The sum of no points is
+// the identity.
+func G1MultiScalarMul(points []G1Element, scalars []Fr) (G1Element, error) {
+	if err := checkMultiScalarMul(len(points), len(scalars)); err != nil {
+		return G1Element{}, err
+	}
+	var sum G1Element
+	if len(points) == 0 {
+		return sum, nil
+	}
+	C.CG1MultiScalarMul(points[0].ptr(), scalars[0].ptr(), C.size_t(len(points)), sum.ptr())
+	return sum, nil
+}
+
+// G2MultiScalarMul returns the sum of scalars[i] * points[i] like
+// G1MultiScalarMul
+func G2MultiScalarMul(points []G2Element, scalars []Fr) (G2Element, error) {
+	if err := checkMultiScalarMul(len(points), len(scalars)); err != nil {
+		return G2Element{}, err
+	}
+	var sum G2Element
+	if len(points) == 0 {
+		return sum, nil
+	}
+	C.CG2MultiScalarMul(points[0].ptr(), scalars[0].ptr(), C.size_t(len(points)), sum.ptr())
+	return sum, nil
+}
+
+// checkMultiScalarMul returns an ErrLengthMismatch error unless there are as
+// many points as scalars
+func checkMultiScalarMul(numPoints, numScalars int) error {
+	if numPoints != numScalars {
+		return &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("got %d points and %d scalars", numPoints, numScalars),
+		}
+	}
+	return nil
+}
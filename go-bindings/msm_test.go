@@ -0,0 +1,343 @@
+package blschia_test
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+// testScalars returns n pseudo-random scalars
+func testScalars(n int) []bls.Fr {
+	scalars := make([]bls.Fr, n)
+	for i := range scalars {
+		var seed [8]byte
+		binary.BigEndian.PutUint64(seed[:], uint64(i))
+		h := sha256.Sum256(seed[:])
+		// Clear the top bits, so that the value is smaller than the order
+		h[0] &= 0x3f
+		scalars[i], _ = bls.FrFromBytes(h[:])
+	}
+	return scalars
+}
+
+// testG1Points returns the multiples 1*g to n*g of the G1 generator
+func testG1Points(n int) []bls.G1Element {
+	points := make([]bls.G1Element, n)
+	g := bls.G1Generator()
+	p := g
+	for i := range points {
+		points[i] = p
+		p = p.Add(g)
+	}
+	return points
+}
+
+// testG2Points returns the multiples 1*g to n*g of the G2 generator
+func testG2Points(n int) []bls.G2Element {
+	points := make([]bls.G2Element, n)
+	g := bls.G2Generator()
+	p := g
+	for i := range points {
+		points[i] = p
+		p = p.Add(g)
+	}
+	return points
+}
+
+func TestMultiScalarMul(t *testing.T) {
+	maxScalar, _ := hex.DecodeString(rMinusOne)
+	minusOne, _ := bls.FrFromBytes(maxScalar)
+
+	// The sizes cover several window sizes
+	for _, n := range []int{0, 1, 2, 5, 70, 300} {
+		scalars := testScalars(n)
+		g1s := testG1Points(n)
+		g2s := testG2Points(n)
+		if n >= 5 {
+			// Edge case scalars and repeated points
+			scalars[0] = bls.Fr{}
+			scalars[1] = minusOne
+			g1s[3], g2s[3] = g1s[2], g2s[2]
+			g1s[4], g2s[4] = bls.G1Element{}, bls.G2Element{}
+		}
+
+		var expected1 bls.G1Element
+		var expected2 bls.G2Element
+		for i := range scalars {
+			expected1 = expected1.Add(g1s[i].Mul(scalars[i]))
+			expected2 = expected2.Add(g2s[i].Mul(scalars[i]))
+		}
+
+		got1, err := bls.G1MultiScalarMul(g1s, scalars)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got1 != expected1 {
+			t.Errorf("G1 %d: got %x, expected %x", n, got1.Serialize(), expected1.Serialize())
+		}
+		got2, err := bls.G2MultiScalarMul(g2s, scalars)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got2 != expected2 {
+			t.Errorf("G2 %d: got %x, expected %x", n, got2.Serialize(), expected2.Serialize())
+		}
+	}
+
+	_, err := bls.G1MultiScalarMul(testG1Points(2), testScalars(3))
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.G2MultiScalarMul(testG2Points(3), testScalars(2))
+	expectError(t, err, bls.ErrLengthMismatch)
+}
+
+func TestMultiPairing(t *testing.T) {
+	g1s := testG1Points(4)
+	g2s := testG2Points(4)
+	g1s[2] = bls.G1Element{}
+
+	var expected bls.GTElement
+	for i := range g1s {
+		expected = expected.Mul(bls.Pairing(g1s[i], g2s[i]))
+	}
+	got, err := bls.MultiPairing(g1s, g2s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != expected {
+		t.Error("multi-pairing differs from the product of pairings")
+	}
+
+	// e(-2g, g) * e(g, 2g) == 1
+	got, _ = bls.MultiPairing(
+		[]bls.G1Element{g1s[1].Neg(), g1s[0]},
+		[]bls.G2Element{g2s[0], g2s[1]})
+	if !got.IsIdentity() {
+		t.Error("multi-pairing should be the identity")
+	}
+
+	got, err = bls.MultiPairing(nil, nil)
+	if err != nil || !got.IsIdentity() {
+		t.Errorf("got %v, expected the identity", err)
+	}
+	_, err = bls.MultiPairing(g1s, g2s[:3])
+	expectError(t, err, bls.ErrLengthMismatch)
+}
+
+func TestPublicKeyAggregateMany(t *testing.T) {
+	// Aggregates computed by PublicKey::Aggregate of the library
+	var pks []bls.PublicKey
+	for i := 0; i < 6; i++ {
+		sk := bls.PrivateKeyFromSeed([]byte{byte(i)})
+		pks = append(pks, sk.PublicKey())
+		sk.Free()
+	}
+	pks = append(pks, pks[2])
+
+	agg, err := bls.PublicKeyAggregate(pks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "110a08019eaf5c14cad40d28cd9960bdf783ee337976a1a0d8a78fc25bfae97d2133ed10e23ffdde2c2a3f7b36bde642"
+	if got := hex.EncodeToString(agg.Serialize()); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+
+	agg, _ = bls.PublicKeyAggregate(pks[:1])
+	expected = "8394df848a790ae30edf594b6f1f05e0a558f79dc0716f7d397bdec6b1340a95790eca66092bc0c0d3eafb4bf715748f"
+	if got := hex.EncodeToString(agg.Serialize()); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+}
+
+func TestInsecureSignatureVerifyRepeated(t *testing.T) {
+	// Keys 0 to 3 sign m1, keys 4 and 5 sign m2
+	m1, m2 := []byte("message 1"), []byte("message 2")
+	var sigs []bls.InsecureSignature
+	var hashes [][]byte
+	var pks []bls.PublicKey
+	for i := 0; i < 6; i++ {
+		sk := bls.PrivateKeyFromSeed([]byte{byte(i)})
+		msg := m1
+		if i >= 4 {
+			msg = m2
+		}
+		sigs = append(sigs, sk.SignInsecure(msg))
+		hashes = append(hashes, Sha256(msg))
+		pks = append(pks, sk.PublicKey())
+		sk.Free()
+	}
+	agg, _ := bls.InsecureSignatureAggregate(sigs)
+
+	if !agg.Verify(hashes, pks) {
+		t.Error("aggregate should verify")
+	}
+	if agg.Verify(hashes[:5], pks[:5]) {
+		t.Error("aggregate should not verify without a signer")
+	}
+	swapped := append([]bls.PublicKey{}, pks...)
+	swapped[0], swapped[5] = swapped[5], swapped[0]
+	if agg.Verify(hashes, swapped) {
+		t.Error("aggregate should not verify with swapped keys")
+	}
+	if agg.Verify(hashes, pks[:5]) {
+		t.Error("aggregate should not verify with mismatched lengths")
+	}
+	if sigs[0].Verify(hashes[:1], pks[1:2]) {
+		t.Error("signature should not verify with another key")
+	}
+}
+
+func BenchmarkMultiScalarMul(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		scalars := testScalars(n)
+		g1s := testG1Points(n)
+		b.Run(fmt.Sprintf("G1/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bls.G1MultiScalarMul(g1s, scalars)
+			}
+		})
+		b.Run(fmt.Sprintf("G1Naive/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var sum bls.G1Element
+				for j, p := range g1s {
+					sum = sum.Add(p.Mul(scalars[j]))
+				}
+			}
+		})
+
+		g2s := testG2Points(n)
+		b.Run(fmt.Sprintf("G2/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bls.G2MultiScalarMul(g2s, scalars)
+			}
+		})
+		b.Run(fmt.Sprintf("G2Naive/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var sum bls.G2Element
+				for j, p := range g2s {
+					sum = sum.Add(p.Mul(scalars[j]))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPublicKeyAggregate compares PublicKeyAggregate with what it used
+// to cost, an exponentiation per key
+func BenchmarkPublicKeyAggregate(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		points := testG1Points(n)
+		scalars := testScalars(n)
+		pks := make([]bls.PublicKey, n)
+		for i, p := range points {
+			pks[i] = p.PublicKey()
+		}
+		b.Run(fmt.Sprintf("Aggregate/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				agg, _ := bls.PublicKeyAggregate(pks)
+				agg.Free()
+			}
+		})
+		b.Run(fmt.Sprintf("PerKey/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				exps := make([]bls.PublicKey, n)
+				for j, pk := range pks {
+					exps[j] = pk.G1Element().Mul(scalars[j]).PublicKey()
+				}
+				agg, _ := bls.PublicKeyAggregateInsecure(exps)
+				agg.Free()
+				for _, pk := range exps {
+					pk.Free()
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSignatureAggregate aggregates n signatures of the same message,
+// whose exponents are now applied with a single multi-scalar multiplication,
+// and compares it with what it used to cost, an exponentiation per signature
+func BenchmarkSignatureAggregate(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		points := testG1Points(n)
+		scalars := testScalars(n)
+		hash := Sha256(payload)
+		h := bls.HashToG2Legacy(payload)
+		// The secret keys are 1 to n, so the signatures are 1*H(m) to n*H(m)
+		sigs := make([]bls.Signature, n)
+		ais := make([]bls.AggregationInfo, n)
+		p := h
+		for i, pk := range points {
+			ais[i] = bls.AggregationInfoFromMsgHash(pk.PublicKey(), hash)
+			sigs[i] = bls.SignatureFromInsecureSigWithAggregationInfo(p.InsecureSignature(), ais[i])
+			p = p.Add(h)
+		}
+
+		b.Run(fmt.Sprintf("Aggregate/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				agg, err := bls.SignatureAggregate(sigs)
+				if err != nil {
+					b.Fatalf("got unexpected error: %v", err)
+				}
+				agg.Free()
+			}
+		})
+		b.Run(fmt.Sprintf("PerKey/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var sum bls.G2Element
+				for j, sig := range sigs {
+					insecureSig := sig.GetInsecureSig()
+					q, _ := insecureSig.G2Element()
+					sum = sum.Add(q.Mul(scalars[j]))
+					insecureSig.Free()
+				}
+				ai := bls.MergeAggregationInfos(ais)
+				agg := bls.SignatureFromInsecureSigWithAggregationInfo(sum.InsecureSignature(), ai)
+				agg.Free()
+				ai.Free()
+			}
+		})
+	}
+}
+
+// BenchmarkInsecureSignatureVerify verifies an aggregate of n signatures of
+// the same message, which is now a single pair of pairings
+func BenchmarkInsecureSignatureVerify(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		points := testG1Points(n)
+		hash := Sha256(payload)
+		pks := make([]bls.PublicKey, n)
+		hashes := make([][]byte, n)
+		for i, p := range points {
+			pks[i] = p.PublicKey()
+			hashes[i] = hash
+		}
+		// The secret keys are 1 to n, so the aggregate is n(n+1)/2 * H(m)
+		sig := bls.HashToG2Legacy(payload).Mul(bls.FrFromUint64(uint64(n * (n + 1) / 2))).InsecureSignature()
+		if !sig.Verify(hashes, pks) {
+			b.Fatal("aggregate should verify")
+		}
+
+		b.Run(fmt.Sprintf("Verify/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sig.Verify(hashes, pks)
+			}
+		})
+		// What it used to cost, a pairing per key
+		b.Run(fmt.Sprintf("PerKey/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q, _ := sig.G2Element()
+				target := bls.Pairing(bls.G1Generator(), q)
+				var candidate bls.GTElement
+				for _, pk := range pks {
+					candidate = candidate.Mul(bls.Pairing(pk.G1Element(), bls.HashToG2Legacy(payload)))
+				}
+				candidate.Equal(target)
+			}
+		})
+	}
+}
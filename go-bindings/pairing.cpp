@@ -0,0 +1,105 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include "pairing.h"
+#include <stdexcept>
+#include "curve.h"
+#include "error.h"
+
+// pc_map_sim shares the Miller loop between the pairs and does a single
+// final exponentiation
+void MultiPairing(gt_t r, g1_t *p, g2_t *q, size_t len) {
+    if (len == 0) {
+        gt_set_unity(r);
+        return;
+    }
+    pc_map_sim(r, p, q, len);
+}
+
+void CGTElementFromBytes(void *in, void *out, char **errMsg) {
+    gt_t a;
+    try {
+        DecodeGT(a, static_cast<uint8_t*>(in));
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return;
+    }
+    WriteGTElement(static_cast<uint8_t*>(out), a);
+}
+
+void CGTElementSerialize(void *in, void *out) {
+    gt_t a;
+    ReadGTElement(a, static_cast<uint8_t*>(in));
+    gt_write_bin(static_cast<uint8_t*>(out), GT_ELEMENT_SIZE, a, 0);
+}
+
+void CGTElementGenerator(void *out) {
+    gt_t gen;
+    gt_get_gen(gen);
+    WriteGTElement(static_cast<uint8_t*>(out), gen);
+}
+
+void CGTElementMul(void *a, void *b, void *out) {
+    gt_t x, y;
+    ReadGTElement(x, static_cast<uint8_t*>(a));
+    ReadGTElement(y, static_cast<uint8_t*>(b));
+    gt_mul(x, x, y);
+    WriteGTElement(static_cast<uint8_t*>(out), x);
+}
+
+// Elements of GT are unitary, so the inverse is the conjugate
+void CGTElementInverse(void *in, void *out) {
+    gt_t a;
+    ReadGTElement(a, static_cast<uint8_t*>(in));
+    gt_inv(a, a);
+    WriteGTElement(static_cast<uint8_t*>(out), a);
+}
+
+void CGTElementExp(void *in, void *scalar, void *out) {
+    gt_t a;
+    ReadGTElement(a, static_cast<uint8_t*>(in));
+    bn_t k;
+    bn_new(k);
+    bn_read_bin(k, static_cast<uint8_t*>(scalar), SCALAR_SIZE);
+    gt_exp(a, a, k);
+    bn_free(k);
+    WriteGTElement(static_cast<uint8_t*>(out), a);
+}
+
+void CPairing(void *g1, void *g2, void *out) {
+    g1_t p;
+    g2_t q;
+    gt_t result;
+    ReadG1Element(p, static_cast<uint8_t*>(g1));
+    ReadG2Element(q, static_cast<uint8_t*>(g2));
+    pc_map(result, p, q);
+    WriteGTElement(static_cast<uint8_t*>(out), result);
+}
+
+void CMultiPairing(void *g1s, void *g2s, size_t len, void *out) {
+    uint8_t *g1Bytes = static_cast<uint8_t*>(g1s);
+    uint8_t *g2Bytes = static_cast<uint8_t*>(g2s);
+    g1_t *p = new g1_t[len];
+    g2_t *q = new g2_t[len];
+    for (size_t i = 0; i < len; i++) {
+        ReadG1Element(p[i], g1Bytes + i * G1_ELEMENT_SIZE);
+        ReadG2Element(q[i], g2Bytes + i * G2_ELEMENT_SIZE);
+    }
+    gt_t result;
+    MultiPairing(result, p, q, len);
+    WriteGTElement(static_cast<uint8_t*>(out), result);
+    delete[] p;
+    delete[] q;
+}
@@ -0,0 +1,173 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdbool.h>
+// #include <stdlib.h>
+// #include "pairing.h"
+// #include "blschia.h"
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// GTElementSize is the size in bytes of a serialized GTElement
+const GTElementSize = 576
+
+// GTElement is an element of GT, the order r subgroup of the multiplicative
+// group of Fp12 which is the target of the pairing. It is serialized as the
+// twelve coefficients of the Fp12 value in the order used by relic, which is
+// not compatible with other libraries.
+//
+// A GTElement holds no C memory and can be compared with ==. The zero value is
+// the identity.
+type GTElement struct {
+	// value holds the serialized element, with all zeros for the identity
+	value [GTElementSize]byte
+}
+
+// Pairing computes the optimal ate pairing e(p, q). It is bilinear, so
+// e(a*p, b*q) == e(p, q)^(a*b), and it is the identity if p or q is.
+func Pairing(p G1Element, q G2Element) GTElement {
+	var result GTElement
+	C.CPairing(p.ptr(), q.ptr(), result.ptr())
+	return result
+}
+
+// MultiPairing computes the product of the pairings e(g1s[i], g2s[i]). The
+// Miller loops are run together and share a single final exponentiation, so
+// it is much faster than multiplying the results of Pairing. The product of
+// no pairings is the identity.
+func MultiPairing(g1s []G1Element, g2s []G2Element) (GTElement, error) {
+	if len(g1s) != len(g2s) {
+		return GTElement{}, &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("got %d G1 elements and %d G2 elements", len(g1s), len(g2s)),
+		}
+	}
+	var result GTElement
+	if len(g1s) == 0 {
+		return result, nil
+	}
+	C.CMultiPairing(g1s[0].ptr(), g2s[0].ptr(), C.size_t(len(g1s)), result.ptr())
+	return result, nil
+}
+
+// GTGenerator returns the pairing of the generators of G1 and G2, which
+// generates GT
+func GTGenerator() GTElement {
+	var g GTElement
+	C.CGTElementGenerator(g.ptr())
+	return g
+}
+
+// GTElementFromBytes decodes an element serialized by Serialize. Values which
+// are not in GT are rejected with ErrInvalidGTElement.
+func GTElementFromBytes(data []byte) (GTElement, error) {
+	if err := checkLength("GT element", data, GTElementSize); err != nil {
+		return GTElement{}, err
+	}
+
+	// Get a C pointer to bytes
+	cBytesPtr := C.CBytes(data)
+	defer C.free(cBytesPtr)
+
+	var a GTElement
+	var cErrMsg *C.char
+	C.CGTElementFromBytes(cBytesPtr, a.ptr(), &cErrMsg)
+	if cErrMsg != nil {
+		return GTElement{}, errFromC(ErrInvalidGTElement, cErrMsg)
+	}
+	return a, nil
+}
+
+func (a *GTElement) ptr() unsafe.Pointer {
+	return unsafe.Pointer(&a.value[0])
+}
+
+// Serialize returns the encoding of the element
+func (a GTElement) Serialize() []byte {
+	buf := make([]byte, GTElementSize)
+	C.CGTElementSerialize(a.ptr(), unsafe.Pointer(&buf[0]))
+	return buf
+}
+
+// Mul returns the product of a and b, which is the group operation of GT
+func (a GTElement) Mul(b GTElement) GTElement {
+	var prod GTElement
+	C.CGTElementMul(a.ptr(), b.ptr(), prod.ptr())
+	return prod
+}
+
+// Inverse returns the inverse of a, so that a.Mul(a.Inverse()) is the identity
+func (a GTElement) Inverse() GTElement {
+	var inv GTElement
+	C.CGTElementInverse(a.ptr(), inv.ptr())
+	return inv
+}
+
+// Exp returns a raised to the power s
+func (a GTElement) Exp(s Fr) GTElement {
+	var result GTElement
+	C.CGTElementExp(a.ptr(), s.ptr(), result.ptr())
+	return result
+}
+
+// IsIdentity reports whether the element is the identity of GT
+func (a GTElement) IsIdentity() bool {
+	return a == GTElement{}
+}
+
+// Equal tests if one GTElement is equal to another
+func (a GTElement) Equal(other GTElement) bool {
+	return a == other
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize.
+func (a GTElement) MarshalBinary() ([]byte, error) {
+	return a.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by GTElementFromBytes.
+func (a *GTElement) UnmarshalBinary(data []byte) error {
+	parsed, err := GTElementFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the element as hex.
+func (a GTElement) MarshalText() ([]byte, error) {
+	return marshalHex(a.Serialize()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// element.
+func (a *GTElement) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return a.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the element as a hex string.
+// The zero value is the identity, so it is not encoded as null.
+func (a GTElement) MarshalJSON() ([]byte, error) {
+	return marshalJSON(a.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the element unchanged.
+func (a *GTElement) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return a.UnmarshalText(text)
+}
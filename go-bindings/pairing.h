@@ -0,0 +1,38 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#ifndef GO_BINDINGS_PAIRING_H_
+#define GO_BINDINGS_PAIRING_H_
+#include <stdbool.h>
+#include <stddef.h>
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// GTElement values hold the serialized Fp12 value, with all zeros standing
+// for the identity. Scalars are 32 bytes big-endian.
+void CGTElementFromBytes(void *in, void *out, char **errMsg);
+void CGTElementSerialize(void *in, void *out);
+void CGTElementGenerator(void *out);
+void CGTElementMul(void *a, void *b, void *out);
+void CGTElementInverse(void *in, void *out);
+void CGTElementExp(void *in, void *scalar, void *out);
+
+void CPairing(void *g1, void *g2, void *out);
+void CMultiPairing(void *g1s, void *g2s, size_t len, void *out);
+
+#ifdef __cplusplus
+}
+#endif
+#endif  // GO_BINDINGS_PAIRING_H_
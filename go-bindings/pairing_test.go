@@ -0,0 +1,118 @@
+package blschia_test
+
+import (
+	"bytes"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestPairingBilinear(t *testing.T) {
+	p := bls.G1Generator()
+	q := bls.G2Generator()
+	e := bls.Pairing(p, q)
+	if expected := bls.GTGenerator(); e != expected {
+		t.Error("pairing of the generators should be the GT generator")
+	}
+	if e.IsIdentity() {
+		t.Error("pairing of the generators should not be the identity")
+	}
+
+	a := bls.FrFromUint64(6)
+	b := bls.FrFromUint64(7)
+	ab := bls.FrFromUint64(42)
+	lhs := bls.Pairing(p.Mul(a), q.Mul(b))
+	if rhs := e.Exp(ab); lhs != rhs {
+		t.Error("e(a*p, b*q) should be e(p, q)^(a*b)")
+	}
+	if rhs := bls.Pairing(p.Mul(ab), q); lhs != rhs {
+		t.Error("e(a*p, b*q) should be e(a*b*p, q)")
+	}
+	if rhs := bls.Pairing(p.Mul(a), q).Mul(bls.Pairing(p, q.Mul(a))); rhs != e.Exp(bls.FrFromUint64(12)) {
+		t.Error("e(a*p, q) * e(p, a*q) should be e(p, q)^(2*a)")
+	}
+
+	if got := bls.Pairing(p.Neg(), q).Mul(e); !got.IsIdentity() {
+		t.Error("e(-p, q) * e(p, q) should be the identity")
+	}
+	if got := bls.Pairing(p.Neg(), q); got != e.Inverse() {
+		t.Error("e(-p, q) should be the inverse of e(p, q)")
+	}
+	if got := bls.Pairing(bls.G1Element{}, q); !got.IsIdentity() {
+		t.Error("pairing with the identity of G1 should be the identity")
+	}
+	if got := bls.Pairing(p, bls.G2Element{}); !got.IsIdentity() {
+		t.Error("pairing with the identity of G2 should be the identity")
+	}
+	if got := e.Exp(bls.Fr{}); !got.IsIdentity() {
+		t.Error("e^0 should be the identity")
+	}
+}
+
+func TestPairingVerify(t *testing.T) {
+	// e(pk, H(m)) == e(g1, sig) is the verification equation of BLS
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	h, _ := bls.HashToG2(payload, []byte(bls.BasicSchemeDST))
+	sig := bls.BasicScheme{}.Sign(sk, payload)
+	if bls.Pairing(sk.G1Element(), h) != bls.Pairing(bls.G1Generator(), sig) {
+		t.Error("signature should satisfy the pairing equation")
+	}
+	if bls.Pairing(sk.G1Element(), h) == bls.Pairing(bls.G1Generator(), sig.Neg()) {
+		t.Error("negated signature should not satisfy the pairing equation")
+	}
+	sk.Free()
+}
+
+func TestGTElementSerialize(t *testing.T) {
+	e := bls.GTGenerator().Exp(bls.FrFromUint64(5))
+	data := e.Serialize()
+	if len(data) != bls.GTElementSize {
+		t.Fatalf("got %d bytes, expected %d", len(data), bls.GTElementSize)
+	}
+	decoded, err := bls.GTElementFromBytes(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded != e || !decoded.Equal(e) {
+		t.Error("decoded element differs")
+	}
+
+	// The identity is serialized as the Fp12 value 1
+	var identity bls.GTElement
+	data = identity.Serialize()
+	if bytes.Equal(data, make([]byte, bls.GTElementSize)) {
+		t.Error("identity should not be serialized as zeros")
+	}
+	decoded, err = bls.GTElementFromBytes(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.IsIdentity() {
+		t.Error("decoded element should be the identity")
+	}
+}
+
+func TestGTElementInvalid(t *testing.T) {
+	_, err := bls.GTElementFromBytes(make([]byte, bls.GTElementSize-1))
+	expectError(t, err, bls.ErrLengthMismatch)
+
+	// Zero is not in the multiplicative group
+	_, err = bls.GTElementFromBytes(make([]byte, bls.GTElementSize))
+	expectError(t, err, bls.ErrInvalidGTElement)
+
+	// A value of Fp12 whose order is not r
+	data := (bls.GTElement{}).Serialize()
+	for i := range data {
+		data[i] ^= byte(i)
+	}
+	_, err = bls.GTElementFromBytes(data)
+	expectError(t, err, bls.ErrInvalidGTElement)
+
+	// A coefficient which is not smaller than p
+	data = bls.GTGenerator().Serialize()
+	for i := 0; i < 48; i++ {
+		data[i] = 0xff
+	}
+	_, err = bls.GTElementFromBytes(data)
+	expectError(t, err, bls.ErrInvalidGTElement)
+}
@@ -0,0 +1,99 @@
+package blschia
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HardenedOffset is added to the index of a hardened child
+const HardenedOffset uint32 = 1 << 31
+
+// maxDepth is the depth beyond which extended keys can't be derived
+const maxDepth = 255
+
+// Path is a derivation path, i.e. the indices of the children to derive in
+// turn. Hardened indices have HardenedOffset added.
+type Path []uint32
+
+// ParsePath parses a derivation path such as "m/12381/3600/0'/0". Hardened
+// indices are marked with a trailing ' or h. The leading m stands for the key
+// the path is applied to, so "m" alone is the empty path.
+func ParsePath(s string) (Path, error) {
+	parts := strings.Split(s, "/")
+	if parts[0] != "m" {
+		return nil, errPath(s, "must start with m")
+	}
+
+	path := make(Path, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			part = part[:len(part)-1]
+			offset = HardenedOffset
+		}
+		if part == "" || strings.TrimLeft(part, "0123456789") != "" {
+			return nil, errPath(s, fmt.Sprintf("invalid index %q", part))
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, errPath(s, fmt.Sprintf("index %s is out of range", part))
+		}
+		path = append(path, uint32(index)+offset)
+	}
+
+	return path, nil
+}
+
+// String formats the path like "m/12381/3600/0'/0", marking hardened indices
+// with a trailing '
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		b.WriteString("/")
+		if index >= HardenedOffset {
+			b.WriteString(strconv.FormatUint(uint64(index-HardenedOffset), 10))
+			b.WriteString("'")
+		} else {
+			b.WriteString(strconv.FormatUint(uint64(index), 10))
+		}
+	}
+	return b.String()
+}
+
+// MarshalText implements encoding.TextMarshaler, formatting the path with
+// String.
+func (p Path) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing the path with
+// ParsePath.
+func (p *Path) UnmarshalText(text []byte) error {
+	parsed, err := ParsePath(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+func errPath(path, msg string) error {
+	return &Error{
+		Err: ErrInvalidPath,
+		Msg: fmt.Sprintf("%q %s", path, msg),
+	}
+}
+
+// checkDepth returns an error if deriving path from a key at depth would go
+// beyond maxDepth
+func checkDepth(depth uint8, path Path) error {
+	if int(depth)+len(path) > maxDepth {
+		return &Error{
+			Err: ErrInvalidPath,
+			Msg: fmt.Sprintf("cannot derive %d levels from depth %d, the maximum depth is %d", len(path), depth, maxDepth),
+		}
+	}
+	return nil
+}
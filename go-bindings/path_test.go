@@ -0,0 +1,124 @@
+package blschia_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bls.Path
+		str      string
+	}{
+		{"m", bls.Path{}, "m"},
+		{"m/0", bls.Path{0}, "m/0"},
+		{"m/12381/3600/0'/0", bls.Path{12381, 3600, bls.HardenedOffset, 0}, "m/12381/3600/0'/0"},
+		{"m/44h/1H/2147483647'", bls.Path{44 + bls.HardenedOffset, 1 + bls.HardenedOffset, 0xffffffff}, "m/44'/1'/2147483647'"},
+	}
+	for _, tt := range tests {
+		p, err := bls.ParsePath(tt.path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.path, err)
+		}
+		if !reflect.DeepEqual(p, tt.expected) {
+			t.Errorf("got %v, expected %v", p, tt.expected)
+		}
+		if p.String() != tt.str {
+			t.Errorf("got %s, expected %s", p.String(), tt.str)
+		}
+	}
+
+	for _, path := range []string{
+		"", "0/1", "m/", "m//1", "m/-1", "m/+1", "m/1''", "m/x", "m/0x10",
+		"m/2147483648", "m/4294967296'", "/m/1",
+	} {
+		_, err := bls.ParsePath(path)
+		expectError(t, err, bls.ErrInvalidPath)
+	}
+}
+
+func TestPathJSON(t *testing.T) {
+	type record struct {
+		Path bls.Path
+	}
+
+	in := record{Path: bls.Path{12381, 3600, bls.HardenedOffset, 0}}
+	js, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"Path":"m/12381/3600/0'/0"}`; string(js) != expected {
+		t.Errorf("got %s, expected %s", js, expected)
+	}
+
+	var out record
+	if err := json.Unmarshal(js, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %v, expected %v", out, in)
+	}
+
+	expectError(t, json.Unmarshal([]byte(`{"Path":"1/2"}`), &out), bls.ErrInvalidPath)
+}
+
+func TestDerivePath(t *testing.T) {
+	xprv := bls.ExtendedPrivateKeyFromSeed(xprvSeed)
+
+	child, err := xprv.DerivePath("m/12381/3600/0h/0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c1 := xprv.PrivateChild(12381)
+	c2 := c1.PrivateChild(3600)
+	c3 := c2.PrivateChild(bls.HardenedOffset)
+	expected := c3.PrivateChild(0)
+	if !child.Equal(expected) {
+		t.Error("DerivePath should match deriving each child in turn")
+	}
+	if child.GetDepth() != 4 {
+		t.Errorf("got depth %v, expected 4", child.GetDepth())
+	}
+
+	// Public derivation matches for non-hardened steps
+	xpub, err := c3.GetExtendedPublicKey().DerivePath("m/0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !xpub.Equal(expected.GetExtendedPublicKey()) {
+		t.Error("public derivation should match private derivation")
+	}
+
+	_, err = xprv.GetExtendedPublicKey().DerivePath("m/12381/3600/0'/0")
+	expectError(t, err, bls.ErrHardenedDerivation)
+	_, err = xprv.DerivePath("m/0/")
+	expectError(t, err, bls.ErrInvalidPath)
+
+	// The empty path gives an independent copy
+	same, err := xprv.DerivePath("m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	same.Free()
+	if !xprv.Equal(bls.ExtendedPrivateKeyFromSeed(xprvSeed)) {
+		t.Error("freeing the copy should not affect the original")
+	}
+
+	// Too deep
+	deep := make(bls.Path, 256)
+	_, err = xprv.Derive(deep)
+	expectError(t, err, bls.ErrInvalidPath)
+	_, err = xprv.GetExtendedPublicKey().Derive(deep)
+	expectError(t, err, bls.ErrInvalidPath)
+
+	child.Free()
+	expected.Free()
+	c3.Free()
+	c2.Free()
+	c1.Free()
+	xprv.Free()
+}
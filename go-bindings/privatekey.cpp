@@ -25,7 +25,7 @@ CPrivateKey CPrivateKeyFromSeed(void *p, int size) {
     return skPtr;
 }
 
-CPrivateKey CPrivateKeyFromBytes(void *p, bool modOrder, bool *didErr) {
+CPrivateKey CPrivateKeyFromBytes(void *p, bool modOrder, char **errMsg) {
     bls::PrivateKey* skPtr;
     try {
         skPtr = new bls::PrivateKey(
@@ -33,22 +33,18 @@ CPrivateKey CPrivateKeyFromBytes(void *p, bool modOrder, bool *didErr) {
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
     return skPtr;
 }
 
-void* CPrivateKeySerialize(CPrivateKey inPtr) {
+// Serialize straight into the caller's buffer, so that no copy of the key is
+// left behind
+void CPrivateKeySerialize(CPrivateKey inPtr, void *buffer) {
     bls::PrivateKey* key = (bls::PrivateKey*)inPtr;
-
-    uint8_t* buffer = bls::Util::SecAlloc<uint8_t>(
-        bls::PrivateKey::PRIVATE_KEY_SIZE);
-
-    key->Serialize(buffer);
-    return static_cast<void*>(buffer);
+    key->Serialize(static_cast<uint8_t*>(buffer));
 }
 
 void CPrivateKeyFree(CPrivateKey inPtr) {
@@ -68,6 +64,26 @@ CPublicKey CPrivateKeyGetPublicKey(CPrivateKey inPtr) {
     return pkPtr;
 }
 
+CPublicKey CPrivateKeyDHKeyExchange(CPrivateKey inPtr, CPublicKey pkPtr,
+    char **errMsg) {
+    bls::PrivateKey* key = (bls::PrivateKey*)inPtr;
+    bls::PublicKey* pk = (bls::PublicKey*)pkPtr;
+
+    bls::PublicKey* sharedPtr;
+    try {
+        sharedPtr = new bls::PublicKey(bls::BLS::DHKeyExchange(*key, *pk));
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    } catch (const std::string& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
+    return sharedPtr;
+}
+
 // Hash and Sign a message
 CInsecureSignature CPrivateKeySignInsecure(CPrivateKey inPtr, void *msg,
     size_t len) {
@@ -106,8 +122,28 @@ CSignature CPrivateKeySignPrehashed(CPrivateKey inPtr, void *hash) {
     return sig;
 }
 
+// Hash and Sign a message, prepending the public key
+CPrependSignature CPrivateKeySignPrepend(CPrivateKey inPtr, void *msg,
+    size_t len) {
+    bls::PrivateKey* key = (bls::PrivateKey*)inPtr;
+    bls::PrependSignature* sig = new bls::PrependSignature(
+        key->SignPrepend(static_cast<uint8_t*>(msg), len)
+    );
+    return sig;
+}
+
+// Sign a fixed-length hash of a message, prepending the public key
+CPrependSignature CPrivateKeySignPrependPrehashed(CPrivateKey inPtr,
+    void *hash) {
+    bls::PrivateKey* key = (bls::PrivateKey*)inPtr;
+    bls::PrependSignature* sig = new bls::PrependSignature(
+        key->SignPrependPrehashed(static_cast<uint8_t*>(hash))
+    );
+    return sig;
+}
+
 CPrivateKey CPrivateKeyAggregateInsecure(void **privateKeys,
-    size_t numPrivateKeys, bool *didErr) {
+    size_t numPrivateKeys, char **errMsg) {
     // build privkey vector
     std::vector<bls::PrivateKey> vecPrivKeys;
     for (int i = 0 ; i < numPrivateKeys; ++i) {
@@ -122,8 +158,7 @@ CPrivateKey CPrivateKeyAggregateInsecure(void **privateKeys,
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
@@ -131,7 +166,7 @@ CPrivateKey CPrivateKeyAggregateInsecure(void **privateKeys,
 }
 
 CPrivateKey CPrivateKeyAggregate(void **privateKeys, size_t numPrivateKeys,
-    void **publicKeys, size_t numPublicKeys, bool *didErr) {
+    void **publicKeys, size_t numPublicKeys, char **errMsg) {
     // build privkey vector
     std::vector<bls::PrivateKey> vecPrivKeys;
     for (int i = 0 ; i < numPrivateKeys; ++i) {
@@ -153,27 +188,9 @@ CPrivateKey CPrivateKeyAggregate(void **privateKeys, size_t numPrivateKeys,
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
     return key;
 }
-
-bool CPrivateKeyIsEqual(CPrivateKey aPtr, CPrivateKey bPtr) {
-    bls::PrivateKey* a = (bls::PrivateKey*)aPtr;
-    bls::PrivateKey* b = (bls::PrivateKey*)bPtr;
-
-    return *a == *b;
-}
-
-CPrivateKey CPrivateKeyFromBN(void *bnBytesPtr, size_t bnSize) {
-    bn_t sk;
-    bn_new(sk);
-
-    bn_read_bin(sk, static_cast<uint8_t*>(bnBytesPtr), bnSize);
-
-    bls::PrivateKey *key = new bls::PrivateKey(bls::PrivateKey::FromBN(sk));
-    return key;
-}
@@ -8,97 +8,284 @@ package blschia
 // #include "blschia.h"
 import "C"
 import (
-	"errors"
-	"math/big"
+	"crypto/subtle"
+	"fmt"
 	"runtime"
 	"unsafe"
 )
 
+// PrivateKeySize is the size in bytes of a serialized private key
+const PrivateKeySize = 32
+
 // PrivateKey represents a BLS private key
 type PrivateKey struct {
+	*privateKey
+}
+
+// privateKey owns the C object. It is shared by all copies of the PrivateKey,
+// so the finalizer only runs once none of them is reachable.
+type privateKey struct {
 	sk C.CPrivateKey
 }
 
+func newPrivateKey(ptr C.CPrivateKey) PrivateKey {
+	sk := &privateKey{sk: ptr}
+	runtime.SetFinalizer(sk, (*privateKey).free)
+	return PrivateKey{sk}
+}
+
+func (sk *privateKey) free() {
+	if sk.sk != nil {
+		C.CPrivateKeyFree(sk.sk)
+		sk.sk = nil
+	}
+}
+
 // PrivateKeyFromSeed generates a private key from a seed, similar to HD key
 // generation (hashes the seed), and reduces it mod the group order
 func PrivateKeyFromSeed(seed []byte) PrivateKey {
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(seed)
-	defer C.free(cBytesPtr)
+	defer freeSecret(cBytesPtr, len(seed))
 
-	var sk PrivateKey
-	sk.sk = C.CPrivateKeyFromSeed(cBytesPtr, C.int(len(seed)))
-	runtime.SetFinalizer(&sk, func(p *PrivateKey) { p.Free() })
-	return sk
+	return newPrivateKey(C.CPrivateKeyFromSeed(cBytesPtr, C.int(len(seed))))
 }
 
 // PrivateKeyFromBytes constructs a new private key from bytes
 func PrivateKeyFromBytes(data []byte, modOrder bool) (PrivateKey, error) {
+	if err := checkLength("private key", data, int(C.CPrivateKeySizeBytes())); err != nil {
+		return PrivateKey{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
-	defer C.free(cBytesPtr)
-
-	var sk PrivateKey
-	var cDidErr C.bool
-	sk.sk = C.CPrivateKeyFromBytes(cBytesPtr, C.bool(modOrder), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return PrivateKey{}, err
+	defer freeSecret(cBytesPtr, len(data))
+
+	var cErrMsg *C.char
+	cSk := C.CPrivateKeyFromBytes(cBytesPtr, C.bool(modOrder), &cErrMsg)
+	if cErrMsg != nil {
+		return PrivateKey{}, errFromC(ErrInvalidPrivateKey, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&sk, func(p *PrivateKey) { p.Free() })
-	return sk, nil
+	return newPrivateKey(cSk), nil
 }
 
-// Free releases memory allocated by the key
+// Free releases memory allocated by the key. All copies of the key share that
+// memory, so none of them may be used afterwards. Calling Free again is a
+// no-op.
 func (sk PrivateKey) Free() {
-	C.CPrivateKeyFree(sk.sk)
+	if sk.privateKey == nil {
+		return
+	}
+	runtime.SetFinalizer(sk.privateKey, nil)
+	sk.free()
 }
 
-// Serialize returns the byte representation of the private key
+// Serialize returns the byte representation of the private key. The caller
+// should Wipe it once it is no longer needed.
 func (sk PrivateKey) Serialize() []byte {
-	ptr := C.CPrivateKeySerialize(sk.sk)
-	defer C.SecFree(ptr)
-	return C.GoBytes(ptr, C.CPrivateKeySizeBytes())
+	buf := make([]byte, PrivateKeySize)
+	sk.SerializeInto(buf)
+	return buf
+}
+
+// SerializeInto writes the byte representation of the private key into the
+// first PrivateKeySize bytes of buf, so that the caller controls every copy of
+// the key. It returns ErrLengthMismatch if buf is too short.
+func (sk PrivateKey) SerializeInto(buf []byte) error {
+	defer runtime.KeepAlive(sk)
+
+	if len(buf) < PrivateKeySize {
+		return &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("buffer must be at least %d bytes, got %d", PrivateKeySize, len(buf)),
+		}
+	}
+	C.CPrivateKeySerialize(sk.sk, unsafe.Pointer(&buf[0]))
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize. The zero value can't be encoded, so it returns an
+// ErrZeroValue error.
+func (sk PrivateKey) MarshalBinary() ([]byte, error) {
+	if sk.privateKey == nil {
+		return nil, errZeroValue("PrivateKey")
+	}
+	return sk.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by PrivateKeyFromBytes.
+func (sk *PrivateKey) UnmarshalBinary(data []byte) error {
+	parsed, err := PrivateKeyFromBytes(data, false)
+	if err != nil {
+		return err
+	}
+	*sk = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the key as hex.
+func (sk PrivateKey) MarshalText() ([]byte, error) {
+	data, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	defer Wipe(data)
+	return marshalHex(data), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// key.
+func (sk *PrivateKey) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	defer Wipe(data)
+	return sk.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the key as a hex string.
+// The zero value is encoded as null.
+func (sk PrivateKey) MarshalJSON() ([]byte, error) {
+	if sk.privateKey == nil {
+		return jsonNull, nil
+	}
+	data := sk.Serialize()
+	defer Wipe(data)
+	return marshalJSON(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the key unchanged.
+func (sk *PrivateKey) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return sk.UnmarshalText(text)
 }
 
 // PublicKey returns the public key which corresponds to the private key
 func (sk PrivateKey) PublicKey() PublicKey {
-	var pk PublicKey
-	pk.pk = C.CPrivateKeyGetPublicKey(sk.sk)
-	runtime.SetFinalizer(&pk, func(p *PublicKey) { p.Free() })
-	return pk
+	defer runtime.KeepAlive(sk)
+	return newPublicKey(C.CPrivateKeyGetPublicKey(sk.sk))
+}
+
+// DHKeyExchange computes a Diffie-Hellman shared secret, which is pk
+// multiplied by the private key. The holders of two key pairs get the same
+// point by calling it with their private key and the other's public key. Use
+// DHSharedKey to derive a symmetric key from it.
+func (sk PrivateKey) DHKeyExchange(pk PublicKey) (PublicKey, error) {
+	defer runtime.KeepAlive(sk)
+	defer runtime.KeepAlive(pk)
+
+	var cErrMsg *C.char
+	cShared := C.CPrivateKeyDHKeyExchange(sk.sk, pk.pk, &cErrMsg)
+	if cErrMsg != nil {
+		return PublicKey{}, errFromC(ErrInvalidPublicKey, cErrMsg)
+	}
+
+	return newPublicKey(cShared), nil
 }
 
 // SignInsecure signs a message without setting aggreagation info
 func (sk PrivateKey) SignInsecure(message []byte) InsecureSignature {
+	defer runtime.KeepAlive(sk)
+
 	// Get a C pointer to bytes
 	cMessagePtr := C.CBytes(message)
 	defer C.free(cMessagePtr)
 
-	var sig InsecureSignature
-	sig.sig = C.CPrivateKeySignInsecure(sk.sk, cMessagePtr, C.size_t(len(message)))
-	runtime.SetFinalizer(&sig, func(p *InsecureSignature) { p.Free() })
-	return sig
+	return newInsecureSignature(C.CPrivateKeySignInsecure(sk.sk, cMessagePtr, C.size_t(len(message))))
+}
+
+// SignInsecurePrehashed signs a 32-byte message hash without setting
+// aggregation info. Hashes of any other length are rejected with
+// ErrLengthMismatch.
+func (sk PrivateKey) SignInsecurePrehashed(hash []byte) (InsecureSignature, error) {
+	defer runtime.KeepAlive(sk)
+
+	if err := checkLength("message hash", hash, MessageHashSize); err != nil {
+		return InsecureSignature{}, err
+	}
+
+	// Get a C pointer to bytes
+	cHashPtr := C.CBytes(hash)
+	defer C.free(cHashPtr)
+
+	return newInsecureSignature(C.CPrivateKeySignInsecurePrehashed(sk.sk, cHashPtr)), nil
 }
 
 // Sign securely signs a message, and sets and returns appropriate aggregation
 // info
 func (sk PrivateKey) Sign(message []byte) Signature {
+	defer runtime.KeepAlive(sk)
+
 	// Get a C pointer to bytes
 	cMessagePtr := C.CBytes(message)
 	defer C.free(cMessagePtr)
 
-	var sig Signature
-	sig.sig = C.CPrivateKeySign(sk.sk, cMessagePtr, C.size_t(len(message)))
-	runtime.SetFinalizer(&sig, func(p *Signature) { p.Free() })
-	return sig
+	return newSignature(C.CPrivateKeySign(sk.sk, cMessagePtr, C.size_t(len(message))))
+}
+
+// SignPrehashed securely signs a 32-byte message hash, and sets and returns
+// appropriate aggregation info. Hashes of any other length are rejected with
+// ErrLengthMismatch.
+func (sk PrivateKey) SignPrehashed(hash []byte) (Signature, error) {
+	defer runtime.KeepAlive(sk)
+
+	if err := checkLength("message hash", hash, MessageHashSize); err != nil {
+		return Signature{}, err
+	}
+
+	// Get a C pointer to bytes
+	cHashPtr := C.CBytes(hash)
+	defer C.free(cHashPtr)
+
+	return newSignature(C.CPrivateKeySignPrehashed(sk.sk, cHashPtr)), nil
+}
+
+// SignPrepend signs a message with the public key prepended to the message
+// hash, allowing secure aggregation with other prepend signatures
+func (sk PrivateKey) SignPrepend(message []byte) PrependSignature {
+	defer runtime.KeepAlive(sk)
+
+	// Get a C pointer to bytes
+	cMessagePtr := C.CBytes(message)
+	defer C.free(cMessagePtr)
+
+	return newPrependSignature(C.CPrivateKeySignPrepend(sk.sk, cMessagePtr, C.size_t(len(message))))
+}
+
+// SignPrependPrehashed signs a 32-byte message hash with the public key
+// prepended to it. Hashes of any other length are rejected with
+// ErrLengthMismatch.
+func (sk PrivateKey) SignPrependPrehashed(hash []byte) (PrependSignature, error) {
+	defer runtime.KeepAlive(sk)
+
+	if err := checkLength("message hash", hash, MessageHashSize); err != nil {
+		return PrependSignature{}, err
+	}
+
+	// Get a C pointer to bytes
+	cHashPtr := C.CBytes(hash)
+	defer C.free(cHashPtr)
+
+	return newPrependSignature(C.CPrivateKeySignPrependPrehashed(sk.sk, cHashPtr)), nil
 }
 
 // PrivateKeyAggregateInsecure insecurely aggregates multiple private keys into
 // one.
 func PrivateKeyAggregateInsecure(privateKeys []PrivateKey) (PrivateKey, error) {
+	defer runtime.KeepAlive(privateKeys)
+
+	if len(privateKeys) == 0 {
+		return PrivateKey{}, &Error{Err: ErrEmptyAggregation, Msg: "no private keys given"}
+	}
+
 	// Get a C pointer to an array of private keys
 	cPrivKeyArrPtr := C.AllocPtrArray(C.size_t(len(privateKeys)))
 	defer C.FreePtrArray(cPrivKeyArrPtr)
@@ -107,22 +294,31 @@ func PrivateKeyAggregateInsecure(privateKeys []PrivateKey) (PrivateKey, error) {
 		C.SetPtrArray(cPrivKeyArrPtr, unsafe.Pointer(privKey.sk), C.int(i))
 	}
 
-	var sk PrivateKey
-	var cDidErr C.bool
-	sk.sk = C.CPrivateKeyAggregateInsecure(cPrivKeyArrPtr, C.size_t(len(privateKeys)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return PrivateKey{}, err
+	var cErrMsg *C.char
+	cSk := C.CPrivateKeyAggregateInsecure(cPrivKeyArrPtr, C.size_t(len(privateKeys)), &cErrMsg)
+	if cErrMsg != nil {
+		return PrivateKey{}, errFromC(ErrInvalidPrivateKey, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&sk, func(p *PrivateKey) { p.Free() })
-	return sk, nil
+	return newPrivateKey(cSk), nil
 }
 
 // PrivateKeyAggregate securely aggregates multiple private keys into one by
 // exponentiating the keys with the pubKey hashes first
 func PrivateKeyAggregate(privateKeys []PrivateKey, publicKeys []PublicKey) (PrivateKey, error) {
+	defer runtime.KeepAlive(privateKeys)
+	defer runtime.KeepAlive(publicKeys)
+
+	if len(privateKeys) == 0 {
+		return PrivateKey{}, &Error{Err: ErrEmptyAggregation, Msg: "no private keys given"}
+	}
+	if len(privateKeys) != len(publicKeys) {
+		return PrivateKey{}, &Error{
+			Err: ErrLengthMismatch,
+			Msg: "number of private keys and public keys must match",
+		}
+	}
+
 	// Get a C pointer to an array of private keys
 	cPrivKeyArrPtr := C.AllocPtrArray(C.size_t(len(privateKeys)))
 	defer C.FreePtrArray(cPrivKeyArrPtr)
@@ -139,34 +335,24 @@ func PrivateKeyAggregate(privateKeys []PrivateKey, publicKeys []PublicKey) (Priv
 		C.SetPtrArray(cPubKeyArrPtr, unsafe.Pointer(pubKey.pk), C.int(i))
 	}
 
-	var cDidErr C.bool
-	var sk PrivateKey
-	sk.sk = C.CPrivateKeyAggregate(cPrivKeyArrPtr, C.size_t(len(privateKeys)),
-		cPubKeyArrPtr, C.size_t(len(publicKeys)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return PrivateKey{}, err
+	var cErrMsg *C.char
+	cSk := C.CPrivateKeyAggregate(cPrivKeyArrPtr, C.size_t(len(privateKeys)),
+		cPubKeyArrPtr, C.size_t(len(publicKeys)), &cErrMsg)
+	if cErrMsg != nil {
+		return PrivateKey{}, errFromC(ErrInvalidPrivateKey, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&sk, func(p *PrivateKey) { p.Free() })
-	return sk, nil
+	return newPrivateKey(cSk), nil
 }
 
-// Equal tests if one PrivateKey object is equal to another
+// Equal tests if one PrivateKey object is equal to another. It runs in
+// constant time, so it doesn't leak where the keys differ.
 func (sk PrivateKey) Equal(other PrivateKey) bool {
-	return bool(C.CPrivateKeyIsEqual(sk.sk, other.sk))
-}
+	var a, b [PrivateKeySize]byte
+	defer Wipe(a[:])
+	defer Wipe(b[:])
 
-// PrivateKeyFromBN constructs a new private key from a *big.Int
-func PrivateKeyFromBN(bn *big.Int) PrivateKey {
-	// Get a C pointer to bytes
-	bnBytes := bn.Bytes()
-	cBNBytesPtr := C.CBytes(bnBytes)
-	defer C.free(cBNBytesPtr)
-
-	var sk PrivateKey
-	sk.sk = C.CPrivateKeyFromBN(cBNBytesPtr, C.size_t(len(bnBytes)))
-	runtime.SetFinalizer(&sk, func(p *PrivateKey) { p.Free() })
-	return sk
+	sk.SerializeInto(a[:])
+	other.SerializeInto(b[:])
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
 }
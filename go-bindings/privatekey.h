@@ -25,19 +25,19 @@ typedef void* CPrivateKey;
 
 CPrivateKey CPrivateKeyFromSeed(void *p, int size);
 
-CPrivateKey CPrivateKeyFromBytes(void *p, bool modOrder, bool *didErr);
-
-CPrivateKey CPrivateKeyFromBN(void *bnBytesPtr, size_t bnSize);
+CPrivateKey CPrivateKeyFromBytes(void *p, bool modOrder, char **errMsg);
 
 CPublicKey CPrivateKeyGetPublicKey(CPrivateKey inPtr);
 
+CPublicKey CPrivateKeyDHKeyExchange(CPrivateKey inPtr, CPublicKey pkPtr,
+    char **errMsg);
+
 CPrivateKey CPrivateKeyAggregateInsecure(void **privateKeys,
-    size_t numPrivateKeys, bool *didErr);
+    size_t numPrivateKeys, char **errMsg);
 
 CPrivateKey CPrivateKeyAggregate(void **privateKeys, size_t numPrivateKeys,
-    void **publicKeys, size_t numPublicKeys, bool *didErr);
+    void **publicKeys, size_t numPublicKeys, char **errMsg);
 
-bool CPrivateKeyIsEqual(CPrivateKey aPtr, CPrivateKey bPtr);
 
 CInsecureSignature CPrivateKeySignInsecure(CPrivateKey inPtr, void *msg,
     size_t len);
@@ -47,7 +47,12 @@ CInsecureSignature CPrivateKeySignInsecurePrehashed(CPrivateKey inPtr,
 CSignature CPrivateKeySign(CPrivateKey inPtr, void *msg, size_t len);
 CSignature CPrivateKeySignPrehashed(CPrivateKey inPtr, void* hash);
 
-void* CPrivateKeySerialize(CPrivateKey inPtr);
+CPrependSignature CPrivateKeySignPrepend(CPrivateKey inPtr, void *msg,
+    size_t len);
+CPrependSignature CPrivateKeySignPrependPrehashed(CPrivateKey inPtr,
+    void *hash);
+
+void CPrivateKeySerialize(CPrivateKey inPtr, void *buffer);
 void CPrivateKeyFree(CPrivateKey inPtr);
 int CPrivateKeySizeBytes();
 
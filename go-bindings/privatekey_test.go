@@ -2,7 +2,6 @@ package blschia_test
 
 import (
 	"bytes"
-	"math/big"
 	"testing"
 
 	bls "github.com/nmarley/bls-signatures/go-bindings"
@@ -115,13 +114,16 @@ func TestPrivateKey(t *testing.T) {
 		t.Errorf("got %v, expected %v", aggSkInsBytes, aggSkInsExpectedBytes)
 	}
 
-	bn1 := new(big.Int).SetBytes([]byte{
+	fr, err := bls.FrFromBytes([]byte{
 		0x2a, 0xc1, 0x24, 0xc0, 0xaa, 0x18, 0x08, 0xe5,
 		0x90, 0xff, 0x1f, 0x94, 0xd6, 0x7a, 0x53, 0x97,
 		0x0a, 0xe9, 0x82, 0xaa, 0x30, 0xbb, 0xe2, 0x61,
 		0xff, 0x1c, 0xb2, 0xad, 0x15, 0xb7, 0x45, 0x2a,
 	})
-	sk3 := bls.PrivateKeyFromBN(bn1)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	sk3 := bls.PrivateKeyFromFr(fr)
 	sk3Bytes := sk3.Serialize()
 	sk3Expected := []byte{
 		0x2a, 0xc1, 0x24, 0xc0, 0xaa, 0x18, 0x08, 0xe5,
@@ -133,20 +135,14 @@ func TestPrivateKey(t *testing.T) {
 		t.Errorf("got %v, expected %v", sk3Bytes, sk3Expected)
 	}
 
-	// test smaller bignum (ensure padded w/zeroes)
-	bn2 := new(big.Int).SetBytes([]byte{
-		0x2a, 0xc1, 0x24, 0xc0, 0xaa, 0x18, 0x08, 0xe5,
-		0x90, 0xff, 0x1f, 0x94, 0xd6, 0x7a, 0x53, 0x97,
-		0x0a, 0xe9, 0x82, 0xaa, 0x30, 0xbb, 0xe2, 0x61,
-		0xff, 0x1c, 0xb2, 0xad, 0x15, 0xb7,
-	})
-	sk4 := bls.PrivateKeyFromBN(bn2)
+	// test a small value (ensure padded w/zeroes)
+	sk4 := bls.PrivateKeyFromFr(bls.FrFromUint64(0x2ac124c0aa1808e5))
 	sk4Bytes := sk4.Serialize()
 	sk4Expected := []byte{
-		0x00, 0x00, 0x2a, 0xc1, 0x24, 0xc0, 0xaa, 0x18,
-		0x08, 0xe5, 0x90, 0xff, 0x1f, 0x94, 0xd6, 0x7a,
-		0x53, 0x97, 0x0a, 0xe9, 0x82, 0xaa, 0x30, 0xbb,
-		0xe2, 0x61, 0xff, 0x1c, 0xb2, 0xad, 0x15, 0xb7,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x2a, 0xc1, 0x24, 0xc0, 0xaa, 0x18, 0x08, 0xe5,
 	}
 	if !bytes.Equal(sk4Bytes, sk4Expected) {
 		t.Errorf("got %v, expected %v", sk4Bytes, sk4Expected)
@@ -162,3 +158,116 @@ func TestPrivateKey(t *testing.T) {
 	pk.Free()
 	sk.Free()
 }
+
+func TestPrivateKeySignPrehashed(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, true)
+	hash := Sha256(payload)
+
+	sig, err := sk1.SignPrehashed(hash)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !bytes.Equal(sig.Serialize(), sig1Bytes) {
+		t.Errorf("got %v, expected %v", sig.Serialize(), sig1Bytes)
+	}
+	if !sig.Verify() {
+		t.Error("sig should verify")
+	}
+	ai := bls.AggregationInfoFromMsgHash(sk1.PublicKey(), hash)
+	if !sig.GetAggregationInfo().Equal(ai) {
+		t.Error("sig AggInfo should be equal to ai")
+	}
+
+	insecureSig, err := sk1.SignInsecurePrehashed(hash)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !bytes.Equal(insecureSig.Serialize(), sig1Bytes) {
+		t.Errorf("got %v, expected %v", insecureSig.Serialize(), sig1Bytes)
+	}
+	if !insecureSig.Verify([][]byte{hash}, []bls.PublicKey{sk1.PublicKey()}) {
+		t.Error("insecureSig should verify")
+	}
+
+	// Short hashes must not be read past their end
+	_, err = sk1.SignPrehashed(hash[:31])
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = sk1.SignInsecurePrehashed(hash[:31])
+	expectError(t, err, bls.ErrLengthMismatch)
+
+	insecureSig.Free()
+	sig.Free()
+	sk1.Free()
+}
+
+func TestDHKeyExchange(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, false)
+	pk1 := sk1.PublicKey()
+	pk2 := sk2.PublicKey()
+
+	shared1, err := sk1.DHKeyExchange(pk2)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	shared2, err := sk2.DHKeyExchange(pk1)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !shared1.Equal(shared2) {
+		t.Error("shared1 should be equal to shared2")
+	}
+
+	other, _ := sk1.DHKeyExchange(pk1)
+	if shared1.Equal(other) {
+		t.Error("shared1 should NOT be equal to other")
+	}
+
+	info := []byte("dkg secret fragment")
+	key1, err := bls.DHSharedKey(shared1, nil, info, 32)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	key2, _ := bls.DHSharedKey(shared2, nil, info, 32)
+	if len(key1) != 32 || !bytes.Equal(key1, key2) {
+		t.Errorf("got %x and %x, expected equal 32 byte keys", key1, key2)
+	}
+	key3, _ := bls.DHSharedKey(shared1, nil, []byte("something else"), 32)
+	if bytes.Equal(key1, key3) {
+		t.Error("keys for different purposes should differ")
+	}
+
+	other.Free()
+	shared2.Free()
+	shared1.Free()
+	sk2.Free()
+	sk1.Free()
+}
+
+func TestPrivateKeySerializeInto(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, false)
+
+	buf := make([]byte, bls.PrivateKeySize+1)
+	if err := sk1.SerializeInto(buf); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf[:bls.PrivateKeySize], sk1Bytes) || buf[bls.PrivateKeySize] != 0 {
+		t.Errorf("got %v, expected %v followed by 0", buf, sk1Bytes)
+	}
+	expectError(t, sk1.SerializeInto(buf[:bls.PrivateKeySize-1]), bls.ErrLengthMismatch)
+
+	bls.Wipe(buf)
+	if !bytes.Equal(buf, make([]byte, len(buf))) {
+		t.Errorf("got %v, expected zeros", buf)
+	}
+
+	sk3, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	if !sk1.Equal(sk3) || sk1.Equal(sk2) {
+		t.Error("expected sk1 to equal sk3 only")
+	}
+
+	sk3.Free()
+	sk2.Free()
+	sk1.Free()
+}
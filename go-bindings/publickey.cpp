@@ -13,9 +13,12 @@
 // limitations under the License.
 
 #include "publickey.h"
-#include <vector>
+#include <algorithm>
 #include <cstring>
+#include <stdexcept>
+#include <vector>
 #include "bls.hpp"
+#include "curve.h"
 #include "error.h"
 
 void* CPublicKeySerialize(CPublicKey inPtr) {
@@ -42,43 +45,73 @@ uint32_t CPublicKeyGetFingerprint(CPublicKey inPtr) {
     return key->GetFingerprint();
 }
 
-CPublicKey CPublicKeyFromBytes(void *p, bool *didErr)  {
+bool CPublicKeyIsInSubgroup(CPublicKey inPtr) {
+    bls::PublicKey* key = (bls::PublicKey*)inPtr;
+    g1_t point;
+    ReadPublicKey(point, *key);
+    return G1InSubgroup(point);
+}
+
+CPublicKey CPublicKeyFromBytes(void *p, char **errMsg)  {
     bls::PublicKey* pkPtr;
     try {
         pkPtr = new bls::PublicKey(bls::PublicKey::FromBytes(
             static_cast<uint8_t*>(p)));
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
     return pkPtr;
 }
 
-CPublicKey CPublicKeyAggregate(void **keys, size_t len, bool *didErr) {
-    std::vector<bls::PublicKey> vecKeys;
-    for (int i = 0; i < len; i++) {
-        bls::PublicKey* key = (bls::PublicKey*)keys[i];
-        vecKeys.push_back(*key);
+// CPublicKeyAggregate computes the same key as PublicKey::Aggregate, the sum
+// of t_i * pk_i with the exponents of BLS::HashPubKeys over the sorted keys,
+// but with one multi-scalar multiplication instead of an exponentiation per
+// key
+CPublicKey CPublicKeyAggregate(void **keys, size_t len, char **errMsg) {
+    if (len < 1) {
+        SetErrorMsg(errMsg,
+            std::length_error("Number of public keys must be at least 1"));
+        return nullptr;
     }
 
-    bls::PublicKey* kPtr;
-    try {
-        kPtr = new bls::PublicKey(
-            bls::PublicKey::Aggregate(vecKeys)
-        );
-    } catch (const std::exception& ex) {
-        // set err
-        gErrMsg = ex.what();
-        *didErr = true;
-        return nullptr;
+    const size_t size = bls::PublicKey::PUBLIC_KEY_SIZE;
+    std::vector<uint8_t> serialized(len * size);
+    std::vector<uint8_t*> serPubKeys(len);
+    std::vector<size_t> sorted(len);
+    for (size_t i = 0; i < len; i++) {
+        bls::PublicKey* key = (bls::PublicKey*)keys[i];
+        serPubKeys[i] = serialized.data() + i * size;
+        key->Serialize(serPubKeys[i]);
+        sorted[i] = i;
+    }
+    std::sort(sorted.begin(), sorted.end(), [&serPubKeys](size_t a, size_t b) {
+        return memcmp(serPubKeys[a], serPubKeys[b], size) < 0;
+    });
+
+    bn_t *ts = new bn_t[len];
+    g1_t *points = new g1_t[len];
+    std::vector<uint8_t> scalars(len * SCALAR_SIZE);
+    for (size_t i = 0; i < len; i++) {
+        bn_new(ts[i]);
+    }
+    bls::BLS::HashPubKeys(ts, len, serPubKeys, sorted);
+    for (size_t i = 0; i < len; i++) {
+        bls::PublicKey* key = (bls::PublicKey*)keys[sorted[i]];
+        ReadPublicKey(points[i], *key);
+        bn_write_bin(scalars.data() + i * SCALAR_SIZE, SCALAR_SIZE, ts[i]);
+        bn_free(ts[i]);
     }
 
-    return kPtr;
+    g1_t aggregate;
+    G1MultiScalarMul(aggregate, points, scalars.data(), len);
+    delete[] points;
+    delete[] ts;
+    return new bls::PublicKey(bls::PublicKey::FromG1(&aggregate));
 }
 
-CPublicKey CPublicKeyAggregateInsecure(void **keys, size_t len, bool *didErr) {
+CPublicKey CPublicKeyAggregateInsecure(void **keys, size_t len, char **errMsg) {
     std::vector<bls::PublicKey> vecKeys;
     for (int i = 0; i < len; i++) {
         bls::PublicKey* key = (bls::PublicKey*)keys[i];
@@ -92,8 +125,7 @@ CPublicKey CPublicKeyAggregateInsecure(void **keys, size_t len, bool *didErr) {
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
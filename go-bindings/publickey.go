@@ -8,55 +8,159 @@ package blschia
 // #include "blschia.h"
 import "C"
 import (
-	"errors"
 	"runtime"
 	"unsafe"
 )
 
 // PublicKey represents a BLS public key
 type PublicKey struct {
+	*publicKey
+}
+
+// publicKey owns the C object. It is shared by all copies of the PublicKey, so
+// the finalizer only runs once none of them is reachable.
+type publicKey struct {
 	pk C.CPublicKey
 }
 
-// PublicKeyFromBytes constructs a new public key from bytes
+func newPublicKey(ptr C.CPublicKey) PublicKey {
+	pk := &publicKey{pk: ptr}
+	runtime.SetFinalizer(pk, (*publicKey).free)
+	return PublicKey{pk}
+}
+
+func (pk *publicKey) free() {
+	if pk.pk != nil {
+		C.CPublicKeyFree(pk.pk)
+		pk.pk = nil
+	}
+}
+
+// PublicKeyFromBytes constructs a new public key from bytes. Keys which are
+// not in the G1 subgroup are rejected with ErrKeyNotInSubgroup.
 func PublicKeyFromBytes(data []byte) (PublicKey, error) {
+	if err := checkLength("public key", data, int(C.CPublicKeySizeBytes())); err != nil {
+		return PublicKey{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
 	defer C.free(cBytesPtr)
 
-	var pk PublicKey
-	var cDidErr C.bool
-	pk.pk = C.CPublicKeyFromBytes(cBytesPtr, &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return PublicKey{}, err
+	var cErrMsg *C.char
+	cPk := C.CPublicKeyFromBytes(cBytesPtr, &cErrMsg)
+	if cErrMsg != nil {
+		return PublicKey{}, errFromC(ErrInvalidPublicKey, cErrMsg)
+	}
+
+	pk := newPublicKey(cPk)
+	if !pk.inSubgroup() {
+		pk.Free()
+		return PublicKey{}, errNotInSubgroup()
 	}
 
-	runtime.SetFinalizer(&pk, func(p *PublicKey) { p.Free() })
 	return pk, nil
 }
 
-// Free releases memory allocated by the key
+// inSubgroup tests whether the key lies in the prime order subgroup of G1
+func (pk PublicKey) inSubgroup() bool {
+	defer runtime.KeepAlive(pk)
+	return bool(C.CPublicKeyIsInSubgroup(pk.pk))
+}
+
+// Free releases memory allocated by the key. All copies of the key share that
+// memory, so none of them may be used afterwards. Calling Free again is a
+// no-op.
 func (pk PublicKey) Free() {
-	C.CPublicKeyFree(pk.pk)
+	if pk.publicKey == nil {
+		return
+	}
+	runtime.SetFinalizer(pk.publicKey, nil)
+	pk.free()
 }
 
 // Serialize returns the byte representation of the public key
 func (pk PublicKey) Serialize() []byte {
+	defer runtime.KeepAlive(pk)
 	ptr := C.CPublicKeySerialize(pk.pk)
 	defer C.free(ptr)
 	return C.GoBytes(ptr, C.CPublicKeySizeBytes())
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize. The zero value can't be encoded, so it returns an
+// ErrZeroValue error.
+func (pk PublicKey) MarshalBinary() ([]byte, error) {
+	if pk.publicKey == nil {
+		return nil, errZeroValue("PublicKey")
+	}
+	return pk.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by PublicKeyFromBytes.
+func (pk *PublicKey) UnmarshalBinary(data []byte) error {
+	parsed, err := PublicKeyFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*pk = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the key as hex.
+func (pk PublicKey) MarshalText() ([]byte, error) {
+	data, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return marshalHex(data), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// key.
+func (pk *PublicKey) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return pk.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the key as a hex string.
+// The zero value is encoded as null.
+func (pk PublicKey) MarshalJSON() ([]byte, error) {
+	if pk.publicKey == nil {
+		return jsonNull, nil
+	}
+	return marshalJSON(pk.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the key unchanged.
+func (pk *PublicKey) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return pk.UnmarshalText(text)
+}
+
 // Fingerprint returns the first 4 bytes of the serialized key
 func (pk PublicKey) Fingerprint() uint32 {
+	defer runtime.KeepAlive(pk)
 	return uint32(C.CPublicKeyGetFingerprint(pk.pk))
 }
 
 // PublicKeyAggregate securely aggregates multiple public keys into one by
 // exponentiating the keys with the pubKey hashes first
 func PublicKeyAggregate(keys []PublicKey) (PublicKey, error) {
+	defer runtime.KeepAlive(keys)
+
+	if len(keys) == 0 {
+		return PublicKey{}, &Error{Err: ErrEmptyAggregation, Msg: "no public keys given"}
+	}
+
 	// Get a C pointer to an array of public keys
 	cPublicKeyArrayPtr := C.AllocPtrArray(C.size_t(len(keys)))
 	defer C.FreePtrArray(cPublicKeyArrayPtr)
@@ -66,22 +170,24 @@ func PublicKeyAggregate(keys []PublicKey) (PublicKey, error) {
 		C.SetPtrArray(cPublicKeyArrayPtr, unsafe.Pointer(k.pk), C.int(i))
 	}
 
-	var key PublicKey
-	var cDidErr C.bool
-	key.pk = C.CPublicKeyAggregate(cPublicKeyArrayPtr, C.size_t(len(keys)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return PublicKey{}, err
+	var cErrMsg *C.char
+	cPk := C.CPublicKeyAggregate(cPublicKeyArrayPtr, C.size_t(len(keys)), &cErrMsg)
+	if cErrMsg != nil {
+		return PublicKey{}, errFromC(ErrInvalidPublicKey, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&key, func(p *PublicKey) { p.Free() })
-	return key, nil
+	return newPublicKey(cPk), nil
 }
 
 // PublicKeyAggregateInsecure insecurely aggregates multiple public keys into
 // one
 func PublicKeyAggregateInsecure(keys []PublicKey) (PublicKey, error) {
+	defer runtime.KeepAlive(keys)
+
+	if len(keys) == 0 {
+		return PublicKey{}, &Error{Err: ErrEmptyAggregation, Msg: "no public keys given"}
+	}
+
 	// Get a C pointer to an array of public keys
 	cPublicKeyArrayPtr := C.AllocPtrArray(C.size_t(len(keys)))
 	defer C.FreePtrArray(cPublicKeyArrayPtr)
@@ -91,20 +197,18 @@ func PublicKeyAggregateInsecure(keys []PublicKey) (PublicKey, error) {
 		C.SetPtrArray(cPublicKeyArrayPtr, unsafe.Pointer(k.pk), C.int(i))
 	}
 
-	var key PublicKey
-	var cDidErr C.bool
-	key.pk = C.CPublicKeyAggregateInsecure(cPublicKeyArrayPtr, C.size_t(len(keys)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return PublicKey{}, err
+	var cErrMsg *C.char
+	cPk := C.CPublicKeyAggregateInsecure(cPublicKeyArrayPtr, C.size_t(len(keys)), &cErrMsg)
+	if cErrMsg != nil {
+		return PublicKey{}, errFromC(ErrInvalidPublicKey, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&key, func(p *PublicKey) { p.Free() })
-	return key, nil
+	return newPublicKey(cPk), nil
 }
 
 // Equal tests if one PublicKey object is equal to another
 func (pk PublicKey) Equal(other PublicKey) bool {
+	defer runtime.KeepAlive(pk)
+	defer runtime.KeepAlive(other)
 	return bool(C.CPublicKeyIsEqual(pk.pk, other.pk))
 }
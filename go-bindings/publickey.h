@@ -23,11 +23,11 @@ extern "C" {
 
 typedef void* CPublicKey;
 
-CPublicKey CPublicKeyFromBytes(void *p, bool *didErr);
+CPublicKey CPublicKeyFromBytes(void *p, char **errMsg);
 
-CPublicKey CPublicKeyAggregateInsecure(void **keys, size_t len, bool *didErr);
+CPublicKey CPublicKeyAggregateInsecure(void **keys, size_t len, char **errMsg);
 
-CPublicKey CPublicKeyAggregate(void **keys, size_t len, bool *didErr);
+CPublicKey CPublicKeyAggregate(void **keys, size_t len, char **errMsg);
 
 bool CPublicKeyIsEqual(CPublicKey aPtr, CPublicKey bPtr);
 
@@ -37,6 +37,8 @@ int CPublicKeySizeBytes();
 
 uint32_t CPublicKeyGetFingerprint(CPublicKey inPtr);
 
+bool CPublicKeyIsInSubgroup(CPublicKey inPtr);
+
 #ifdef __cplusplus
 }
 #endif
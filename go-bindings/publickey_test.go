@@ -70,3 +70,38 @@ func TestPublicKey(t *testing.T) {
 	pk2.Free()
 	pk1.Free()
 }
+
+func TestPublicKeyLifetime(t *testing.T) {
+	// Only copies of the keys survive the constructors
+	keys := make([]bls.PublicKey, 0, 20)
+	for i := 0; i < 10; i++ {
+		pk1, _ := bls.PublicKeyFromBytes(pk1Bytes)
+		pk2, _ := bls.PublicKeyFromBytes(pk2Bytes)
+		keys = append(keys, pk1, pk2)
+	}
+	collectGarbage()
+
+	// Allocate (and drop) more keys, which would reuse any memory freed too
+	// early
+	for i := 0; i < 100; i++ {
+		bls.PrivateKeyFromSeed([]byte{byte(i)}).PublicKey()
+	}
+	collectGarbage()
+
+	for i, pk := range keys {
+		expected := pk1Bytes
+		if i%2 == 1 {
+			expected = pk2Bytes
+		}
+		if !bytes.Equal(pk.Serialize(), expected) {
+			t.Errorf("got %v, expected %v", pk.Serialize(), expected)
+		}
+	}
+
+	// Free is shared by all copies, and is safe to call repeatedly
+	pk := keys[0]
+	keys[0].Free()
+	keys[0].Free()
+	pk.Free()
+	bls.PublicKey{}.Free()
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+#include "scheme.h"
+#include "blschia.h"
+#include "curve.h"
+
+// ReadSecret reads the private key into a scalar, wiping the serialized copy
+static void ReadSecret(bn_t k, CPrivateKey sk) {
+    bls::PrivateKey* key = (bls::PrivateKey*)sk;
+    uint8_t buf[bls::PrivateKey::PRIVATE_KEY_SIZE];
+    key->Serialize(buf);
+    bn_read_bin(k, buf, sizeof(buf));
+    SecWipe(buf, sizeof(buf));
+}
+
+void CCoreSkToPk(CPrivateKey sk, void *out) {
+    bn_t k;
+    bn_new(k);
+    ReadSecret(k, sk);
+    g1_t pk;
+    g1_mul_gen(pk, k);
+    bn_zero(k);
+    bn_free(k);
+    WriteG1Element(static_cast<uint8_t*>(out), pk);
+}
+
+void CCoreSign(CPrivateKey sk, void *msg, size_t len, void *dst,
+    size_t dstLen, void *out) {
+    g2_t sig;
+    HashToG2(sig, static_cast<uint8_t*>(msg), len,
+        static_cast<uint8_t*>(dst), dstLen);
+
+    bn_t k;
+    bn_new(k);
+    ReadSecret(k, sk);
+    g2_mul(sig, sig, k);
+    bn_zero(k);
+    bn_free(k);
+    WriteG2Element(static_cast<uint8_t*>(out), sig);
+}
+
+// CCoreAggregateVerify checks
+//   e(-g1, sig) * prod e(pk_i, H(msg_i)) == 1
+// with a single multi-pairing
+bool CCoreAggregateVerify(void *publicKeys, void *msgs, size_t *msgLens,
+    size_t len, void *dst, size_t dstLen, void *sig) {
+    uint8_t *pkBytes = static_cast<uint8_t*>(publicKeys);
+    uint8_t *msg = static_cast<uint8_t*>(msgs);
+    g1_t *pks = new g1_t[len + 1];
+    g2_t *hashes = new g2_t[len + 1];
+
+    g1_get_gen(pks[0]);
+    g1_neg(pks[0], pks[0]);
+    g1_norm(pks[0], pks[0]);
+    ReadG2Element(hashes[0], static_cast<uint8_t*>(sig));
+    for (size_t i = 0; i < len; i++) {
+        ReadG1Element(pks[i + 1], pkBytes + i * G1_ELEMENT_SIZE);
+        HashToG2(hashes[i + 1], msg, msgLens[i],
+            static_cast<uint8_t*>(dst), dstLen);
+        msg += msgLens[i];
+    }
+
+    gt_t result;
+    MultiPairing(result, pks, hashes, len + 1);
+    bool valid = gt_is_unity(result) && core_get()->code == STS_OK;
+    core_get()->code = STS_OK;
+
+    delete[] pks;
+    delete[] hashes;
+    return valid;
+}
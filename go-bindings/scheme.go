@@ -0,0 +1,236 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdbool.h>
+// #include <stdlib.h>
+// #include "scheme.h"
+// #include "blschia.h"
+import "C"
+import (
+	"runtime"
+	"unsafe"
+)
+
+// Domain separation tags of the ciphersuites of the IETF BLS signature draft
+// with signatures in G2. Messages are hashed to G2 with the hash_to_curve
+// suite BLS12381G2_XMD:SHA-256_SSWU_RO_ of RFC 9380.
+const (
+	BasicSchemeDST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_"
+	AugSchemeDST   = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_AUG_"
+	PopSchemeDST   = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+	// PopProofDST is used by PopScheme to sign proofs of possession
+	PopProofDST = "BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+)
+
+// Scheme is implemented by the three signature schemes of the IETF BLS
+// signature draft. They differ in how they prevent rogue key attacks on
+// aggregate signatures:
+//
+//   - BasicScheme requires the aggregated messages to be distinct
+//   - AugScheme prepends the public key to each message
+//   - PopScheme requires a proof of possession of each key, which allows
+//     fast verification of signatures on a single message
+//
+// The schemes are not compatible with each other, nor with the signatures of
+// PrivateKey.Sign, which remain available for existing data. Public keys are
+// G1Elements and signatures G2Elements.
+type Scheme interface {
+	// Sign signs msg with sk
+	Sign(sk PrivateKey, msg []byte) G2Element
+	// Verify checks a signature of msg by pk
+	Verify(pk G1Element, msg []byte, sig G2Element) bool
+	// Aggregate combines signatures into one. It returns ErrEmptyAggregation
+	// if there are none.
+	Aggregate(sigs []G2Element) (G2Element, error)
+	// AggregateVerify checks an aggregate signature of msgs[i] by pks[i]
+	AggregateVerify(pks []G1Element, msgs [][]byte, sig G2Element) bool
+}
+
+// BasicScheme is the basic scheme of the IETF BLS signature draft, which
+// rejects aggregate signatures of repeated messages
+type BasicScheme struct{}
+
+// AugScheme is the message augmentation scheme of the IETF BLS signature
+// draft, which signs the public key followed by the message
+type AugScheme struct{}
+
+// PopScheme is the proof of possession scheme of the IETF BLS signature
+// draft. Public keys must only be accepted along with a valid proof of
+// possession, checked by PopVerify.
+type PopScheme struct{}
+
+var (
+	_ Scheme = BasicScheme{}
+	_ Scheme = AugScheme{}
+	_ Scheme = PopScheme{}
+)
+
+// G1Element returns the public key of the IETF signature schemes which
+// corresponds to the private key. It is the same point as PublicKey, but
+// serialized differently.
+func (sk PrivateKey) G1Element() G1Element {
+	defer runtime.KeepAlive(sk)
+	var pk G1Element
+	C.CCoreSkToPk(sk.sk, pk.ptr())
+	return pk
+}
+
+// Sign implements Scheme
+func (BasicScheme) Sign(sk PrivateKey, msg []byte) G2Element {
+	return coreSign(sk, msg, BasicSchemeDST)
+}
+
+// Verify implements Scheme
+func (BasicScheme) Verify(pk G1Element, msg []byte, sig G2Element) bool {
+	return coreAggregateVerify([]G1Element{pk}, [][]byte{msg}, sig, BasicSchemeDST)
+}
+
+// Aggregate implements Scheme
+func (BasicScheme) Aggregate(sigs []G2Element) (G2Element, error) {
+	return aggregateSignatures(sigs)
+}
+
+// AggregateVerify implements Scheme. It returns false if any two messages
+// are equal.
+func (BasicScheme) AggregateVerify(pks []G1Element, msgs [][]byte, sig G2Element) bool {
+	seen := make(map[string]struct{}, len(msgs))
+	for _, msg := range msgs {
+		if _, ok := seen[string(msg)]; ok {
+			return false
+		}
+		seen[string(msg)] = struct{}{}
+	}
+	return coreAggregateVerify(pks, msgs, sig, BasicSchemeDST)
+}
+
+// Sign implements Scheme
+func (AugScheme) Sign(sk PrivateKey, msg []byte) G2Element {
+	return coreSign(sk, augment(sk.G1Element(), msg), AugSchemeDST)
+}
+
+// Verify implements Scheme
+func (AugScheme) Verify(pk G1Element, msg []byte, sig G2Element) bool {
+	return coreAggregateVerify([]G1Element{pk}, [][]byte{augment(pk, msg)}, sig, AugSchemeDST)
+}
+
+// Aggregate implements Scheme
+func (AugScheme) Aggregate(sigs []G2Element) (G2Element, error) {
+	return aggregateSignatures(sigs)
+}
+
+// AggregateVerify implements Scheme
+func (AugScheme) AggregateVerify(pks []G1Element, msgs [][]byte, sig G2Element) bool {
+	if len(pks) != len(msgs) {
+		return false
+	}
+	augmented := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		augmented[i] = augment(pks[i], msg)
+	}
+	return coreAggregateVerify(pks, augmented, sig, AugSchemeDST)
+}
+
+// augment prepends the compressed public key to msg
+func augment(pk G1Element, msg []byte) []byte {
+	return append(pk.Serialize(), msg...)
+}
+
+// Sign implements Scheme
+func (PopScheme) Sign(sk PrivateKey, msg []byte) G2Element {
+	return coreSign(sk, msg, PopSchemeDST)
+}
+
+// Verify implements Scheme
+func (PopScheme) Verify(pk G1Element, msg []byte, sig G2Element) bool {
+	return coreAggregateVerify([]G1Element{pk}, [][]byte{msg}, sig, PopSchemeDST)
+}
+
+// Aggregate implements Scheme
+func (PopScheme) Aggregate(sigs []G2Element) (G2Element, error) {
+	return aggregateSignatures(sigs)
+}
+
+// AggregateVerify implements Scheme
+func (PopScheme) AggregateVerify(pks []G1Element, msgs [][]byte, sig G2Element) bool {
+	return coreAggregateVerify(pks, msgs, sig, PopSchemeDST)
+}
+
+// FastAggregateVerify checks an aggregate signature of the same message by
+// all of pks, with a single pairing check against the sum of the keys. It is
+// only secure if the proof of possession of every key was verified.
+//
+// The other schemes have no such operation, because with them the sum of the
+// keys could be the key of a rogue signer.
+func (PopScheme) FastAggregateVerify(pks []G1Element, msg []byte, sig G2Element) bool {
+	if len(pks) == 0 {
+		return false
+	}
+	return coreAggregateVerify([]G1Element{aggregateG1(pks)}, [][]byte{msg}, sig, PopSchemeDST)
+}
+
+// PopProve returns a proof of possession of sk, which is a signature of its
+// serialized public key
+func (PopScheme) PopProve(sk PrivateKey) G2Element {
+	return coreSign(sk, sk.G1Element().Serialize(), PopProofDST)
+}
+
+// PopVerify checks a proof of possession of the private key of pk
+func (PopScheme) PopVerify(pk G1Element, proof G2Element) bool {
+	return coreAggregateVerify([]G1Element{pk}, [][]byte{pk.Serialize()}, proof, PopProofDST)
+}
+
+// aggregateSignatures returns the sum of sigs
+func aggregateSignatures(sigs []G2Element) (G2Element, error) {
+	if len(sigs) == 0 {
+		return G2Element{}, &Error{Err: ErrEmptyAggregation, Msg: "no signatures given"}
+	}
+	return aggregateG2(sigs), nil
+}
+
+// coreSign implements CoreSign of the draft, hashing msg to G2 with dst
+func coreSign(sk PrivateKey, msg []byte, dst string) G2Element {
+	defer runtime.KeepAlive(sk)
+
+	// Get C pointers to bytes
+	cMessagePtr := C.CBytes(msg)
+	defer C.free(cMessagePtr)
+	cDSTPtr := C.CBytes([]byte(dst))
+	defer C.free(cDSTPtr)
+
+	var sig G2Element
+	C.CCoreSign(sk.sk, cMessagePtr, C.size_t(len(msg)), cDSTPtr,
+		C.size_t(len(dst)), sig.ptr())
+	return sig
+}
+
+// coreAggregateVerify implements CoreAggregateVerify of the draft. Keys at
+// infinity fail KeyValidate, so they make the signature invalid. All
+// G1Elements are in the subgroup, as they are checked when decoded.
+func coreAggregateVerify(pks []G1Element, msgs [][]byte, sig G2Element, dst string) bool {
+	if len(pks) == 0 || len(pks) != len(msgs) {
+		return false
+	}
+	for _, pk := range pks {
+		if pk.IsIdentity() {
+			return false
+		}
+	}
+
+	// Get a C pointer to the concatenated messages and their lengths
+	var concat []byte
+	cMsgLensPtr := C.AllocIntPtr(C.size_t(len(msgs)))
+	defer C.FreeIntPtr(cMsgLensPtr)
+	for i, msg := range msgs {
+		concat = append(concat, msg...)
+		C.SetIntPtrVal(cMsgLensPtr, C.size_t(len(msg)), C.int(i))
+	}
+	cMessagesPtr := C.CBytes(concat)
+	defer C.free(cMessagesPtr)
+	cDSTPtr := C.CBytes([]byte(dst))
+	defer C.free(cDSTPtr)
+
+	return bool(C.CCoreAggregateVerify(unsafe.Pointer(&pks[0].point[0]),
+		cMessagesPtr, cMsgLensPtr, C.size_t(len(pks)), cDSTPtr,
+		C.size_t(len(dst)), sig.ptr()))
+}
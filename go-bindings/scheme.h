@@ -0,0 +1,38 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#ifndef GO_BINDINGS_SCHEME_H_
+#define GO_BINDINGS_SCHEME_H_
+#include <stdbool.h>
+#include <stddef.h>
+#include "privatekey.h"
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// The core operations of the IETF signature schemes. Public keys and
+// signatures are passed as G1Element and G2Element values.
+void CCoreSkToPk(CPrivateKey sk, void *out);
+
+void CCoreSign(CPrivateKey sk, void *msg, size_t len, void *dst,
+    size_t dstLen, void *out);
+
+// The messages are concatenated, and msgLens holds the length of each
+bool CCoreAggregateVerify(void *publicKeys, void *msgs, size_t *msgLens,
+    size_t len, void *dst, size_t dstLen, void *sig);
+
+#ifdef __cplusplus
+}
+#endif
+#endif  // GO_BINDINGS_SCHEME_H_
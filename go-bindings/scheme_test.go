@@ -0,0 +1,280 @@
+package blschia_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+// Secret keys of the Ethereum consensus spec BLS tests, with their public keys
+var (
+	schemeSecrets = []string{
+		"263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3",
+		"47b8192d77bf871b62e87859d653922725724a5c031afeabc60bcef5ff665138",
+		"328388aff0d4a5b7dc9205abd374e7e98f3cd9f3418edb4eafda5fb16473d216",
+	}
+	schemePublicKeys = []string{
+		"a491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a",
+		"b301803f8b5ac4a1133581fc676dfedc60d891dd5fa99028805e5ea5b08d3491af75d0707adab3b70c6a6a580217bf81",
+		"b53d21a4cfd562c469cc81514d4ce5a6b577d8403d32a394dc265dd190b47fa9f829fdd7963afdf972e5e77854051f6f",
+	}
+	schemeMessages = [][]byte{[]byte("abc"), []byte("message 2"), {}}
+)
+
+// Signatures of schemeMessages by the keys above, and their aggregate. They
+// were cross-checked with blst.
+var schemeVectors = []struct {
+	name   string
+	scheme bls.Scheme
+	sigs   []string
+	agg    string
+}{
+	{
+		name:   "Basic",
+		scheme: bls.BasicScheme{},
+		sigs: []string{
+			"ac9a8f6a3980f799f9b428f41e1864ca36bf424dec971842e3aafdbf0949b6fe73f49b2ca4b8f1e632d3007b0ed3fbbb" +
+				"06e0287656a3e57130865df5409ece5c251f92a3ca801a096c719b2fc9c9ccd2ab6c6292fee166e424ff5498d90126c9",
+			"b8864a326ef2dd6e0904c360841ff9d5dfd9841596f6a55c13de7a50cb74a576516aadb27b9b160c376f71d16e2dcfe8" +
+				"006d916274215a91f3476b72e074b05cb2a8de7519fcbfbdd2007116b483f26b3e66c30599bae23e4fdac21862440bd3",
+			"b195ea160e56877d0f2f6b20dec682228563b282d030d109a23eb2e90f98139fc3744e45d43e1fcc9e6d9d7c103c4d6d" +
+				"13b1c3c555b8feb0e897f5795288bffa28f83689348bb98ea6c35b6106b54f3daecbb14b698c82e2e5e69276eabcd458",
+		},
+		agg: "aa56f320da3bc32c06c4801c58b979d44cddb81548ddd71722aecdfe101164e00392cbd27c4a6b23787bd8dff269555c" +
+			"09130e3f84fe0d0050977c4cff7cefbeea6497463a58d9904d6d7878a83bfe62544b7a0efa0de17ea06bb89388040d9c",
+	},
+	{
+		name:   "Aug",
+		scheme: bls.AugScheme{},
+		sigs: []string{
+			"97b4ace28d9560e9366700621f49a740410bc9039c42852f136e33d7c057a9766d1da6442ce9783750fd1b788b08ad3b" +
+				"13a489088b5eaefa723a8fc7b5d50a32bde5332ed53eccbad898570d41bd42bfd6c5c6fe2fe0d15bb771636857ce4144",
+			"94d5d2c023143f7bac486ed93e79c956e0fee1f3ce596886c12fc5c3c3dc71bcd41cea39aabeef886631d56bdcafdfb7" +
+				"100e686bcc2398a73ad40efedd2e3a76089f72f621c243de503dc6263f8d64c5fec38813e6c1461ae86d2d854839e87a",
+			"af093b0ef01a82ef1adb5ffa43ed99ffbc5e2a8804934c4087164d0dd168acc69acf40cd9a3890042a5af6de07f6f101" +
+				"17f6b36e0410bff8a350ed15918710715774f7fdf1ec2383766919e967b3fa03312c81350f5acd728888f4cb8078efc8",
+		},
+		agg: "b8fff57beed38e3f652087878b79fbcb5a09bf42f3ccb7d5f9d91a1888e65f246093c708aef20a57d511173bbddcf7d1" +
+			"1505461810824ef05807c48d6ca604bdc444c02e4cfd0f5ecb6ed871edce51f6b187c0cd32dedd3bbd55ab8753441391",
+	},
+	{
+		name:   "Pop",
+		scheme: bls.PopScheme{},
+		sigs: []string{
+			"a31751779876b59bddbd8896f966ab41b07556c0f020fbac55e862e027d48e79e57caba6153d7ec47db1219dca1b070d" +
+				"13a6469139855bd90ed9bb08b6686ee07836703f90547be20e7715a76de94115280b07b9238da2ea23704a1e1a71c2fe",
+			"a126206c63ec388ceee45ee0e8ea24985d390befaea246ed7e7d59da625f67a1250968cbb47948c1781774ec94f9a104" +
+				"166df22f4df7ed70cf841811ce2638dee2518c03cb49d73bdaebee00aed0aa84abad7f60145e71d709fa0c70dfa08345",
+			"b363a6833de5778171495cd94b3b82bb04b7ea52d848ef09dbf0bb34c52efdeb56aa2c32251c037b5437f035d13e2e84" +
+				"131575af16dec65970592dad1fc8713aaf6805be9121677924919d3433bafd8252a3c5655b41301424ef5208eb6687a7",
+		},
+		agg: "b3d62ea554d5e0a2003679701a45d723925d34dcd7e91efc9078f93c238d3de41fcba89c0a0d5c23365965664a6e79b9" +
+			"09f8aedc6c9cccac381968fb39a50c696f84cf4e2ad32eb0b6014cac88636c1de5f21bf1c620e4331b315f863921ee44",
+	},
+}
+
+func schemeKeys(t *testing.T) ([]bls.PrivateKey, []bls.G1Element) {
+	sks := make([]bls.PrivateKey, len(schemeSecrets))
+	pks := make([]bls.G1Element, len(schemeSecrets))
+	for i, secret := range schemeSecrets {
+		data, _ := hex.DecodeString(secret)
+		sk, err := bls.PrivateKeyFromBytes(data, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sks[i] = sk
+		pks[i] = sk.G1Element()
+		if got := hex.EncodeToString(pks[i].Serialize()); got != schemePublicKeys[i] {
+			t.Errorf("got %s, expected %s", got, schemePublicKeys[i])
+		}
+	}
+	return sks, pks
+}
+
+func freeKeys(sks []bls.PrivateKey) {
+	for _, sk := range sks {
+		sk.Free()
+	}
+}
+
+func TestSchemeVectors(t *testing.T) {
+	sks, pks := schemeKeys(t)
+	defer freeKeys(sks)
+
+	for _, v := range schemeVectors {
+		t.Run(v.name, func(t *testing.T) {
+			sigs := make([]bls.G2Element, len(sks))
+			for i, sk := range sks {
+				sigs[i] = v.scheme.Sign(sk, schemeMessages[i])
+				if got := hex.EncodeToString(sigs[i].Serialize()); got != v.sigs[i] {
+					t.Errorf("got %s, expected %s", got, v.sigs[i])
+				}
+				if !v.scheme.Verify(pks[i], schemeMessages[i], sigs[i]) {
+					t.Errorf("signature %d should verify", i)
+				}
+				other := (i + 1) % len(sks)
+				if v.scheme.Verify(pks[other], schemeMessages[i], sigs[i]) {
+					t.Errorf("signature %d should not verify with key %d", i, other)
+				}
+				if v.scheme.Verify(pks[i], schemeMessages[other], sigs[i]) {
+					t.Errorf("signature %d should not verify with message %d", i, other)
+				}
+			}
+
+			agg, err := v.scheme.Aggregate(sigs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := hex.EncodeToString(agg.Serialize()); got != v.agg {
+				t.Errorf("got %s, expected %s", got, v.agg)
+			}
+			if !v.scheme.AggregateVerify(pks, schemeMessages, agg) {
+				t.Error("aggregate signature should verify")
+			}
+			if v.scheme.AggregateVerify(pks[:2], schemeMessages[:2], agg) {
+				t.Error("aggregate signature should not verify with a missing signer")
+			}
+			swapped := [][]byte{schemeMessages[1], schemeMessages[0], schemeMessages[2]}
+			if v.scheme.AggregateVerify(pks, swapped, agg) {
+				t.Error("aggregate signature should not verify with swapped messages")
+			}
+			if v.scheme.AggregateVerify(pks, schemeMessages[:2], agg) {
+				t.Error("mismatched lengths should not verify")
+			}
+
+			_, err = v.scheme.Aggregate(nil)
+			expectError(t, err, bls.ErrEmptyAggregation)
+		})
+	}
+}
+
+func TestSchemeKeyValidate(t *testing.T) {
+	sks, pks := schemeKeys(t)
+	defer freeKeys(sks)
+
+	var identity bls.G1Element
+	var sigIdentity bls.G2Element
+	for _, v := range schemeVectors {
+		// e(identity, H(msg)) == e(g1, identity) for any message
+		if v.scheme.Verify(identity, schemeMessages[0], sigIdentity) {
+			t.Errorf("%s: the identity key should not verify", v.name)
+		}
+		if v.scheme.AggregateVerify([]bls.G1Element{pks[0], identity},
+			[][]byte{schemeMessages[0], schemeMessages[1]},
+			v.scheme.Sign(sks[0], schemeMessages[0])) {
+			t.Errorf("%s: the identity key should not verify", v.name)
+		}
+		if v.scheme.AggregateVerify(nil, nil, sigIdentity) {
+			t.Errorf("%s: an empty aggregate should not verify", v.name)
+		}
+	}
+}
+
+func TestBasicSchemeDistinctMessages(t *testing.T) {
+	sks, pks := schemeKeys(t)
+	defer freeKeys(sks)
+
+	var scheme bls.BasicScheme
+	msgs := [][]byte{payload, payload}
+	sigs := []bls.G2Element{scheme.Sign(sks[0], payload), scheme.Sign(sks[1], payload)}
+	agg, _ := scheme.Aggregate(sigs)
+	if scheme.AggregateVerify(pks[:2], msgs, agg) {
+		t.Error("repeated messages should not verify")
+	}
+
+	// The other schemes allow them
+	var aug bls.AugScheme
+	sigs = []bls.G2Element{aug.Sign(sks[0], payload), aug.Sign(sks[1], payload)}
+	agg, _ = aug.Aggregate(sigs)
+	if !aug.AggregateVerify(pks[:2], msgs, agg) {
+		t.Error("repeated messages should verify with AugScheme")
+	}
+}
+
+// Signatures by the first key in the format of the Ethereum consensus spec
+// tests, which use the PoP scheme
+var popSignVectors = []struct {
+	msg byte
+	sig string
+}{
+	{0x56, "882730e5d03f6b42c3abc26d3372625034e1d871b65a8a6b900a56dae22da98abbe1b68f85e49fe7652a55ec3d0591c2" +
+		"0767677e33e5cbb1207315c41a9ac03be39c2e7668edc043d6cb1d9fd93033caa8a1c5b0e84bedaeb6c64972503a43eb"},
+	{0xab, "91347bccf740d859038fcdcaf233eeceb2a436bcaaee9b2aa3bfb70efe29dfb2677562ccbea1c8e061fb9971b0753c24" +
+		"0622fab78489ce96768259fc01360346da5b9f579e5da0d941e4c6ba18a0e64906082375394f337fa1af2b7127b0d121"},
+	{0x00, "b6ed936746e01f8ecf281f020953fbf1f01debd5657c4a383940b020b26507f6076334f91e2366c96e9ab279fb515809" +
+		"0352ea1c5b0c9274504f4f0e7053af24802e51e4568d164fe986834f41e55c8e850ce1f98458c0cfc9ab380b55285a55"},
+}
+
+func TestPopSchemeSign(t *testing.T) {
+	sks, pks := schemeKeys(t)
+	defer freeKeys(sks)
+
+	var scheme bls.PopScheme
+	for _, v := range popSignVectors {
+		msg := make([]byte, 32)
+		for i := range msg {
+			msg[i] = v.msg
+		}
+		sig := scheme.Sign(sks[0], msg)
+		if got := hex.EncodeToString(sig.Serialize()); got != v.sig {
+			t.Errorf("got %s, expected %s", got, v.sig)
+		}
+		if !scheme.Verify(pks[0], msg, sig) {
+			t.Error("sig should verify")
+		}
+	}
+}
+
+func TestPopScheme(t *testing.T) {
+	sks, pks := schemeKeys(t)
+	defer freeKeys(sks)
+
+	proofs := []string{
+		"b803eb0ed93ea10224a73b6b9c725796be9f5fefd215ef7a5b97234cc956cf6870db6127b7e4d824ec62276078e787db" +
+			"05584ce1adbf076bc0808ca0f15b73d59060254b25393d95dfc7abe3cda566842aaedf50bbb062aae1bbb6ef3b1f77e1",
+		"88bb31b27eae23038e14f9d9d1b628a39f5881b5278c3c6f0249f81ba0deb1f68aa5f8847854d6554051aa810fdf1cdb" +
+			"02df4af7a5647b1aa4afb60ec6d446ee17af24a8a50876ffdaf9bf475038ec5f8ebeda1c1c6a3220293e23b13a9a5d26",
+		"88873ea58f5017a33facc9bf04efaf5e2f34f7bc9ce564d0481dd469326c04ef43552f50e99de8a13315dcd37a4fb9ef" +
+			"036d1a54e5febf5d20b6aa488f3e3c917e6a96ce6461f609ec7e0a1fd8950380922e46c3654fa7542436603f833462da",
+	}
+	var scheme bls.PopScheme
+	for i, sk := range sks {
+		proof := scheme.PopProve(sk)
+		if got := hex.EncodeToString(proof.Serialize()); got != proofs[i] {
+			t.Errorf("got %s, expected %s", got, proofs[i])
+		}
+		if !scheme.PopVerify(pks[i], proof) {
+			t.Errorf("proof %d should verify", i)
+		}
+		if scheme.PopVerify(pks[(i+1)%len(pks)], proof) {
+			t.Errorf("proof %d should not verify with another key", i)
+		}
+		// A proof is not a signature of the serialized key
+		if scheme.Verify(pks[i], pks[i].Serialize(), proof) {
+			t.Errorf("proof %d should not verify as a signature", i)
+		}
+	}
+
+	msg := []byte("same")
+	sigs := make([]bls.G2Element, len(sks))
+	for i, sk := range sks {
+		sigs[i] = scheme.Sign(sk, msg)
+	}
+	agg, _ := scheme.Aggregate(sigs)
+	if !scheme.FastAggregateVerify(pks, msg, agg) {
+		t.Error("aggregate signature should verify")
+	}
+	if !scheme.AggregateVerify(pks, [][]byte{msg, msg, msg}, agg) {
+		t.Error("aggregate signature should verify with AggregateVerify")
+	}
+	if scheme.FastAggregateVerify(pks[:2], msg, agg) {
+		t.Error("aggregate signature should not verify with a missing signer")
+	}
+	if scheme.FastAggregateVerify(pks, payload, agg) {
+		t.Error("aggregate signature should not verify with another message")
+	}
+	if scheme.FastAggregateVerify(nil, msg, agg) {
+		t.Error("no keys should not verify")
+	}
+}
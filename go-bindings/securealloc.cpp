@@ -0,0 +1,167 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+#include "securealloc.h"
+#include <atomic>
+#include <cstdint>
+#include <cstdlib>
+#include <mutex>
+#include <unordered_map>
+#include "blschia.h"
+#include "bls.hpp"
+
+#ifdef __linux__
+#include <sys/mman.h>
+#include <unistd.h>
+#endif
+
+namespace {
+
+struct Allocation {
+    bool locked;
+    // the whole mapping of a locked allocation, including its guard pages
+    uint8_t *base;
+    size_t len;
+};
+
+std::atomic<bool> useLocked(false);
+std::atomic<bool> debug(false);
+std::atomic<size_t> lockFailures(0);
+
+// live holds every allocation which has to be freed specially, or counted.
+// Anything else was allocated with malloc, which BLS::Init installs.
+std::mutex liveMutex;
+std::unordered_map<void*, Allocation> live;
+
+#ifdef __linux__
+const size_t alignment = 16;
+
+// LockedAlloc gives each allocation its own pages, with an inaccessible guard
+// page on either side. The allocation is placed at the end of its pages, so
+// that overflows fault on the guard page. The pages are locked into memory and
+// excluded from core dumps.
+void* LockedAlloc(size_t size, Allocation *alloc) {
+    size_t page = static_cast<size_t>(sysconf(_SC_PAGESIZE));
+    size_t dataLen = (size + alignment + page - 1) / page * page;
+    size_t len = dataLen + 2 * page;
+
+    void *base = mmap(nullptr, len, PROT_NONE, MAP_PRIVATE | MAP_ANONYMOUS,
+        -1, 0);
+    if (base == MAP_FAILED) {
+        return nullptr;
+    }
+    uint8_t *data = static_cast<uint8_t*>(base) + page;
+    if (mprotect(data, dataLen, PROT_READ | PROT_WRITE) != 0) {
+        munmap(base, len);
+        return nullptr;
+    }
+    // Locking fails when RLIMIT_MEMLOCK is exhausted. The memory is still
+    // guarded and wiped, so carry on like libsodium does.
+    if (mlock(data, dataLen) != 0) {
+        lockFailures++;
+    }
+    madvise(data, dataLen, MADV_DONTDUMP);
+
+    alloc->locked = true;
+    alloc->base = static_cast<uint8_t*>(base);
+    alloc->len = len;
+
+    uintptr_t end = reinterpret_cast<uintptr_t>(data + dataLen);
+    return reinterpret_cast<void*>((end - size) & ~(alignment - 1));
+}
+
+void LockedFree(const Allocation &alloc) {
+    size_t page = static_cast<size_t>(sysconf(_SC_PAGESIZE));
+    uint8_t *data = alloc.base + page;
+    size_t dataLen = alloc.len - 2 * page;
+
+    SecWipe(data, dataLen);
+    munlock(data, dataLen);
+    munmap(alloc.base, alloc.len);
+}
+#endif
+
+void* SecureAlloc(size_t size) {
+    if (size == 0) {
+        size = 1;
+    }
+    Allocation alloc = {false, nullptr, 0};
+    void *p;
+#ifdef __linux__
+    if (useLocked) {
+        p = LockedAlloc(size, &alloc);
+    } else {
+        p = malloc(size);
+    }
+#else
+    p = malloc(size);
+#endif
+    if (p != nullptr && (alloc.locked || debug)) {
+        std::lock_guard<std::mutex> lock(liveMutex);
+        live[p] = alloc;
+    }
+    return p;
+}
+
+void SecureFree(void *p) {
+    if (p == nullptr) {
+        return;
+    }
+    Allocation alloc = {false, nullptr, 0};
+    {
+        std::lock_guard<std::mutex> lock(liveMutex);
+        auto it = live.find(p);
+        if (it != live.end()) {
+            alloc = it->second;
+            live.erase(it);
+        }
+    }
+#ifdef __linux__
+    if (alloc.locked) {
+        LockedFree(alloc);
+        return;
+    }
+#endif
+    free(p);
+}
+
+}  // namespace
+
+void CSecureAllocInstall() {
+    static std::once_flag installed;
+    std::call_once(installed, []() {
+        bls::BLS::SetSecureAllocator(SecureAlloc, SecureFree);
+    });
+}
+
+bool CSecureAllocSetLocked(bool locked) {
+#ifdef __linux__
+    useLocked = locked;
+    return true;
+#else
+    return !locked;
+#endif
+}
+
+void CSecureAllocSetDebug(bool enabled) {
+    debug = enabled;
+}
+
+size_t CSecureAllocLiveCount() {
+    std::lock_guard<std::mutex> lock(liveMutex);
+    return live.size();
+}
+
+size_t CSecureAllocLockFailures() {
+    return lockFailures;
+}
@@ -0,0 +1,95 @@
+package blschia
+
+// #cgo LDFLAGS: -L../build -lbls -lstdc++
+// #cgo CXXFLAGS: -std=c++14 -I../src -I../build/contrib/relic/include -I../contrib/relic/include
+// #include <stdbool.h>
+// #include <stdlib.h>
+// #include "securealloc.h"
+// #include "blschia.h"
+import "C"
+import (
+	"runtime"
+	"unsafe"
+)
+
+// SecureAllocator selects how the library allocates memory for secret data,
+// such as private keys and the temporaries used to derive them.
+type SecureAllocator int
+
+const (
+	// PlainAllocator uses malloc and free. This is the library default.
+	PlainAllocator SecureAllocator = iota
+
+	// LockedAllocator gives each allocation its own mlock-ed pages, excluded
+	// from core dumps and surrounded by inaccessible guard pages, and wipes
+	// them when freed. It is only supported on Linux.
+	LockedAllocator
+)
+
+// SecureAllocStats describes the allocations made for secret data
+type SecureAllocStats struct {
+	// Live is the number of allocations which have not been freed. It counts
+	// allocations made while debug mode was enabled or the LockedAllocator
+	// was in use.
+	Live int
+	// LockFailures is the number of allocations whose pages could not be
+	// locked into memory, e.g. because RLIMIT_MEMLOCK was exhausted. They
+	// are still guarded and wiped.
+	LockFailures int
+}
+
+func init() {
+	// The allocator is only installed once, so that memory is never freed by
+	// a different allocator than the one which allocated it. Switching between
+	// SecureAllocators only changes how new allocations are made.
+	C.CSecureAllocInstall()
+}
+
+// SetSecureAllocator selects the allocator used for secret data from now on.
+// Memory allocated before is still freed correctly. It returns
+// ErrAllocatorUnsupported if the allocator is not available on this platform.
+func SetSecureAllocator(allocator SecureAllocator) error {
+	switch allocator {
+	case PlainAllocator, LockedAllocator:
+	default:
+		return &Error{Err: ErrAllocatorUnsupported, Msg: "unknown allocator"}
+	}
+	if !bool(C.CSecureAllocSetLocked(C.bool(allocator == LockedAllocator))) {
+		return &Error{
+			Err: ErrAllocatorUnsupported,
+			Msg: "locked memory is only supported on Linux",
+		}
+	}
+	return nil
+}
+
+// SetSecureAllocDebug enables or disables counting of every allocation made
+// for secret data, so that tests can check that all private keys were freed.
+// Counting makes each allocation take a lock.
+func SetSecureAllocDebug(enabled bool) {
+	C.CSecureAllocSetDebug(C.bool(enabled))
+}
+
+// SecureAllocatorStats returns statistics about the allocations made for
+// secret data
+func SecureAllocatorStats() SecureAllocStats {
+	return SecureAllocStats{
+		Live:         int(C.CSecureAllocLiveCount()),
+		LockFailures: int(C.CSecureAllocLockFailures()),
+	}
+}
+
+// Wipe overwrites b with zeros. Use it to clear secrets, such as serialized
+// private keys, once they are no longer needed.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
+
+// freeSecret wipes and frees a C buffer which held secret data
+func freeSecret(ptr unsafe.Pointer, size int) {
+	C.SecWipe(ptr, C.size_t(size))
+	C.free(ptr)
+}
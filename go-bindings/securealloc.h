@@ -0,0 +1,33 @@
+// Copyright 2019 Chia Network Inc
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//    http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+#ifndef GO_BINDINGS_SECUREALLOC_H_
+#define GO_BINDINGS_SECUREALLOC_H_
+#include <stdbool.h>
+#include <stddef.h>
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+void CSecureAllocInstall();
+
+bool CSecureAllocSetLocked(bool locked);
+void CSecureAllocSetDebug(bool enabled);
+
+size_t CSecureAllocLiveCount();
+size_t CSecureAllocLockFailures();
+
+#ifdef __cplusplus
+}
+#endif
+#endif  // GO_BINDINGS_SECUREALLOC_H_
@@ -0,0 +1,71 @@
+package blschia_test
+
+import (
+	"runtime"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestSecureAllocDebug(t *testing.T) {
+	bls.SetSecureAllocDebug(true)
+	defer bls.SetSecureAllocDebug(false)
+
+	live := bls.SecureAllocatorStats().Live
+	sk := bls.PrivateKeyFromSeed([]byte{1, 2, 3, 4, 5})
+	xprv := bls.ExtendedPrivateKeyFromSeed(xprvSeed)
+	child := xprv.PrivateChild(1)
+	if got := bls.SecureAllocatorStats().Live; got <= live {
+		t.Errorf("got %d live allocations, expected more than %d", got, live)
+	}
+
+	child.Free()
+	xprv.Free()
+	sk.Free()
+	if got := bls.SecureAllocatorStats().Live; got != live {
+		t.Errorf("got %d live allocations, expected %d", got, live)
+	}
+}
+
+func TestSecureAllocLocked(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		expectError(t, bls.SetSecureAllocator(bls.LockedAllocator), bls.ErrAllocatorUnsupported)
+		return
+	}
+
+	// Allocated before switching, freed after
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+
+	if err := bls.SetSecureAllocator(bls.LockedAllocator); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	live := bls.SecureAllocatorStats().Live
+	sk2, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	xprv := bls.ExtendedPrivateKeyFromSeed(xprvSeed)
+	if got := bls.SecureAllocatorStats().Live; got <= live {
+		t.Errorf("got %d live allocations, expected more than %d", got, live)
+	}
+
+	// Keys in locked memory work like any other
+	if !sk2.Equal(sk1) {
+		t.Error("sk2 should be equal to sk1")
+	}
+	sig := sk2.Sign(payload)
+	if !sig.Verify() {
+		t.Error("sig should verify")
+	}
+	sk1.Free()
+
+	// Allocated while locked, freed after switching back
+	if err := bls.SetSecureAllocator(bls.PlainAllocator); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	sk2.Free()
+	xprv.Free()
+	if got := bls.SecureAllocatorStats().Live; got != live {
+		t.Errorf("got %d live allocations, expected %d", got, live)
+	}
+
+	expectError(t, bls.SetSecureAllocator(bls.SecureAllocator(42)), bls.ErrAllocatorUnsupported)
+	sig.Free()
+}
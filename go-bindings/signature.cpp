@@ -13,10 +13,147 @@
 // limitations under the License.
 
 #include "signature.h"
+#include <algorithm>
+#include <cstring>
+#include <map>
+#include <set>
+#include <stdexcept>
 #include <vector>
 #include "bls.hpp"
+#include "curve.h"
 #include "error.h"
 
+namespace {
+
+typedef std::set<const uint8_t*, bls::Util::BytesCompare32> HashSet;
+
+// AggregateSignatures computes the same signature as Signature::Aggregate.
+// Signatures whose messages are signed in no other signature are simply
+// added, the others are multiplied by exponents derived from their keys,
+// which is done with one multi-scalar multiplication rather than one
+// exponentiation per signature.
+bls::Signature AggregateSignatures(const std::vector<bls::Signature>& sigs) {
+    std::vector<std::vector<bls::PublicKey> > pubKeys;
+    std::vector<std::vector<uint8_t*> > messageHashes;
+    for (const bls::Signature& sig : sigs) {
+        const bls::AggregationInfo& info = *sig.GetAggregationInfo();
+        if (info.Empty()) {
+            throw std::invalid_argument(
+                "Signature must include aggregation info.");
+        }
+        pubKeys.push_back(info.GetPubKeys());
+        messageHashes.push_back(info.GetMessageHashes());
+        if (pubKeys.back().empty() || messageHashes.back().empty()) {
+            throw std::length_error("AggregationInfo must have items");
+        }
+        if (pubKeys.back().size() != messageHashes.back().size()) {
+            throw std::length_error("Lengths of vectors must match.");
+        }
+    }
+
+    // Find the messages which appear in more than one signature
+    HashSet messagesSet;
+    HashSet collidingMessagesSet;
+    for (const std::vector<uint8_t*>& msgs : messageHashes) {
+        HashSet messagesSetLocal;
+        for (const uint8_t* msg : msgs) {
+            if (messagesSetLocal.count(msg) == 0 && messagesSet.count(msg)) {
+                collidingMessagesSet.insert(msg);
+            }
+            messagesSet.insert(msg);
+            messagesSetLocal.insert(msg);
+        }
+    }
+    std::vector<size_t> colliding;
+    std::vector<size_t> nonColliding;
+    for (size_t i = 0; i < sigs.size(); i++) {
+        bool groupCollides = false;
+        for (const uint8_t* msg : messageHashes[i]) {
+            if (collidingMessagesSet.count(msg)) {
+                groupCollides = true;
+                break;
+            }
+        }
+        if (groupCollides) {
+            colliding.push_back(i);
+        } else {
+            nonColliding.push_back(i);
+        }
+    }
+
+    g2_t aggregate;
+    g2_set_infty(aggregate);
+    std::vector<bls::AggregationInfo> infos;
+    if (!colliding.empty()) {
+        // The exponents are derived from all msg/pk pairs of the colliding
+        // signatures, sorted by message then key, and assigned to the
+        // signatures sorted by aggregation info
+        const size_t pkSize = bls::PublicKey::PUBLIC_KEY_SIZE;
+        const size_t sortKeySize = bls::BLS::MESSAGE_HASH_LEN + pkSize;
+        std::vector<uint8_t*> serPubKeys;
+        std::vector<uint8_t*> sortKeys;
+        for (size_t i : colliding) {
+            for (size_t j = 0; j < pubKeys[i].size(); j++) {
+                uint8_t *sortKey = new uint8_t[sortKeySize];
+                std::memcpy(sortKey, messageHashes[i][j],
+                    bls::BLS::MESSAGE_HASH_LEN);
+                pubKeys[i][j].Serialize(sortKey + bls::BLS::MESSAGE_HASH_LEN);
+                sortKeys.push_back(sortKey);
+                serPubKeys.push_back(sortKey + bls::BLS::MESSAGE_HASH_LEN);
+            }
+        }
+        std::vector<size_t> sortKeysSorted(sortKeys.size());
+        for (size_t i = 0; i < sortKeysSorted.size(); i++) {
+            sortKeysSorted[i] = i;
+        }
+        std::sort(sortKeysSorted.begin(), sortKeysSorted.end(),
+            [&sortKeys, sortKeySize](size_t a, size_t b) {
+                return memcmp(sortKeys[a], sortKeys[b], sortKeySize) < 0;
+            });
+        std::sort(colliding.begin(), colliding.end(),
+            [&sigs](size_t a, size_t b) {
+                return *sigs[a].GetAggregationInfo() <
+                    *sigs[b].GetAggregationInfo();
+            });
+
+        const size_t len = colliding.size();
+        bn_t *ts = new bn_t[len];
+        g2_t *points = new g2_t[len];
+        std::vector<uint8_t> scalars(len * SCALAR_SIZE);
+        for (size_t i = 0; i < len; i++) {
+            bn_new(ts[i]);
+        }
+        bls::BLS::HashPubKeys(ts, len, serPubKeys, sortKeysSorted);
+        for (size_t i = 0; i < len; i++) {
+            const bls::Signature& sig = sigs[colliding[i]];
+            ReadInsecureSignature(points[i], sig.GetInsecureSig());
+            bn_write_bin(scalars.data() + i * SCALAR_SIZE, SCALAR_SIZE, ts[i]);
+            bn_free(ts[i]);
+            infos.push_back(*sig.GetAggregationInfo());
+        }
+        G2MultiScalarMul(aggregate, points, scalars.data(), len);
+
+        delete[] points;
+        delete[] ts;
+        for (uint8_t *sortKey : sortKeys) {
+            delete[] sortKey;
+        }
+    }
+
+    g2_t point;
+    for (size_t i : nonColliding) {
+        ReadInsecureSignature(point, sigs[i].GetInsecureSig());
+        g2_add(aggregate, aggregate, point);
+        infos.push_back(*sigs[i].GetAggregationInfo());
+    }
+
+    return bls::Signature::FromInsecureSig(
+        bls::InsecureSignature::FromG2(&aggregate),
+        bls::AggregationInfo::MergeInfos(infos));
+}
+
+}  // namespace
+
 void* CInsecureSignatureSerialize(CInsecureSignature inPtr) {
     bls::InsecureSignature* sig = (bls::InsecureSignature*)inPtr;
 
@@ -36,7 +173,7 @@ int CInsecureSignatureSizeBytes() {
     return bls::InsecureSignature::SIGNATURE_SIZE;
 }
 
-CInsecureSignature CInsecureSignatureFromBytes(void *p, bool *didErr) {
+CInsecureSignature CInsecureSignatureFromBytes(void *p, char **errMsg) {
     bls::InsecureSignature* sigPtr;
     try {
         sigPtr = new bls::InsecureSignature(
@@ -44,8 +181,7 @@ CInsecureSignature CInsecureSignatureFromBytes(void *p, bool *didErr) {
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
     return sigPtr;
@@ -70,7 +206,7 @@ int CSignatureSizeBytes() {
     return bls::Signature::SIGNATURE_SIZE;
 }
 
-CSignature CSignatureFromBytes(void *p, bool *didErr) {
+CSignature CSignatureFromBytes(void *p, char **errMsg) {
     bls::Signature* sigPtr;
     try {
         sigPtr = new bls::Signature(
@@ -78,8 +214,7 @@ CSignature CSignatureFromBytes(void *p, bool *didErr) {
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
     return sigPtr;
@@ -92,8 +227,6 @@ bool CSignatureVerify(CSignature inPtr) {
     try {
         didVerify = sig->Verify();
     } catch (const std::exception& ex) {
-        // set err
-        gErrMsg = ex.what();
         didVerify = false;
     }
     return didVerify;
@@ -111,10 +244,11 @@ void CSignatureSetAggregationInfo(CSignature inPtr, CAggregationInfo aiPtr) {
 
 CAggregationInfo CSignatureGetAggregationInfo(CSignature inPtr) {
     bls::Signature* sig = (bls::Signature*)inPtr;
-    return (bls::AggregationInfo*)(sig->GetAggregationInfo());
+    // return a copy, as the signature owns its aggregation info
+    return new bls::AggregationInfo(*sig->GetAggregationInfo());
 }
 
-CSignature CSignatureAggregate(void **sigs, size_t len, bool *didErr) {
+CSignature CSignatureAggregate(void **sigs, size_t len, char **errMsg) {
     std::vector<bls::Signature> vecSigs;
     for (int i = 0 ; i < len ; i++) {
         bls::Signature* sig = (bls::Signature*)sigs[i];
@@ -123,12 +257,11 @@ CSignature CSignatureAggregate(void **sigs, size_t len, bool *didErr) {
 
     bls::Signature* sPtr;
     try {
-        bls::Signature s = bls::Signature::Aggregate(vecSigs);
+        bls::Signature s = AggregateSignatures(vecSigs);
         sPtr = new bls::Signature(s);
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
@@ -136,7 +269,7 @@ CSignature CSignatureAggregate(void **sigs, size_t len, bool *didErr) {
 }
 
 CSignature CSignatureDivideBy(CSignature inPtr, void **sigs, size_t len,
-    bool *didErr) {
+    char **errMsg) {
     bls::Signature* sig = (bls::Signature*)inPtr;
 
     std::vector<bls::Signature> vecSigs;
@@ -152,45 +285,63 @@ CSignature CSignatureDivideBy(CSignature inPtr, void **sigs, size_t len,
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
     return quotient;
 }
 
+// CInsecureSignatureVerify checks the same equation as
+// InsecureSignature::Verify,
+//   e(-g1, sig) * prod e(pk_i, H(hash_i)) == 1
+// but sums the keys of each distinct message hash first, so the multi-pairing
+// has one pair per distinct message plus one
 bool CInsecureSignatureVerify(CInsecureSignature inPtr, void **hashes,
     size_t numHashes, void **publicKeys, size_t numPublicKeys) {
+    if (numHashes != numPublicKeys || numHashes == 0) {
+        return false;
+    }
     bls::InsecureSignature *sig = (bls::InsecureSignature*)inPtr;
 
-    // build hashes vector
-    std::vector<const uint8_t*> vecHashes;
-    for (int i = 0; i < numHashes; i++) {
-        const uint8_t* msg = (const uint8_t*)hashes[i];
-        vecHashes.push_back(msg);
+    std::map<const uint8_t*, size_t, bls::Util::BytesCompare32> hashIndex;
+    for (size_t i = 0; i < numHashes; i++) {
+        const uint8_t *hash = static_cast<uint8_t*>(hashes[i]);
+        hashIndex.insert(std::make_pair(hash, hashIndex.size() + 1));
     }
 
-    // build pubkeys vector
-    std::vector<bls::PublicKey> vecPubKeys;
-    for (int i = 0; i < numPublicKeys; i++) {
-        bls::PublicKey* key = (bls::PublicKey*)publicKeys[i];
-        vecPubKeys.push_back(*key);
+    size_t len = hashIndex.size() + 1;
+    g1_t *pks = new g1_t[len];
+    g2_t *mappedHashes = new g2_t[len];
+    g1_get_gen(pks[0]);
+    g1_neg(pks[0], pks[0]);
+    ReadInsecureSignature(mappedHashes[0], *sig);
+    for (auto& entry : hashIndex) {
+        g1_set_infty(pks[entry.second]);
+        g2_map(mappedHashes[entry.second], const_cast<uint8_t*>(entry.first),
+            bls::BLS::MESSAGE_HASH_LEN, 0);
     }
 
-    bool didVerify;
-    try {
-        didVerify = sig->Verify(vecHashes, vecPubKeys);
-    } catch (const std::exception& ex) {
-        // set err
-        gErrMsg = ex.what();
-        didVerify = false;
+    g1_t pk;
+    for (size_t i = 0; i < numHashes; i++) {
+        size_t j = hashIndex[static_cast<uint8_t*>(hashes[i])];
+        bls::PublicKey* key = (bls::PublicKey*)publicKeys[i];
+        ReadPublicKey(pk, *key);
+        g1_add(pks[j], pks[j], pk);
     }
-    return didVerify;
+
+    gt_t result;
+    MultiPairing(result, pks, mappedHashes, len);
+    bool valid = gt_is_unity(result) && core_get()->code == STS_OK;
+    core_get()->code = STS_OK;
+
+    delete[] pks;
+    delete[] mappedHashes;
+    return valid;
 }
 
 CInsecureSignature CInsecureSignatureAggregate(void **signatures,
-    size_t numSignatures, bool *didErr) {
+    size_t numSignatures, char **errMsg) {
     // build the signatures vector
     std::vector<bls::InsecureSignature> vecSigs;
     for (int i = 0 ; i < numSignatures; i++) {
@@ -205,8 +356,7 @@ CInsecureSignature CInsecureSignatureAggregate(void **signatures,
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
@@ -214,7 +364,7 @@ CInsecureSignature CInsecureSignatureAggregate(void **signatures,
 }
 
 CInsecureSignature CInsecureSignatureDivideBy(CInsecureSignature inPtr,
-    void **signatures, size_t numSignatures, bool *didErr) {
+    void **signatures, size_t numSignatures, char **errMsg) {
     // build the signatures vector
     std::vector<bls::InsecureSignature> vecSigs;
     for (int i = 0 ; i < numSignatures; i++) {
@@ -231,8 +381,7 @@ CInsecureSignature CInsecureSignatureDivideBy(CInsecureSignature inPtr,
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
 
@@ -248,7 +397,7 @@ bool CInsecureSignatureIsEqual(CInsecureSignature aPtr,
 }
 
 CSignature CSignatureFromBytesWithAggregationInfo(void *p,
-    CAggregationInfo aiPtr, bool *didErr) {
+    CAggregationInfo aiPtr, char **errMsg) {
     bls::Signature* sigPtr;
     try {
         sigPtr = new bls::Signature(
@@ -257,8 +406,7 @@ CSignature CSignatureFromBytesWithAggregationInfo(void *p,
         );
     } catch (const std::exception& ex) {
         // set err
-        gErrMsg = ex.what();
-        *didErr = true;
+        SetErrorMsg(errMsg, ex);
         return nullptr;
     }
     return sigPtr;
@@ -296,3 +444,134 @@ bool CSignatureIsEqual(CSignature aPtr, CSignature bPtr) {
 
     return *a == *b;
 }
+
+void* CPrependSignatureSerialize(CPrependSignature inPtr) {
+    bls::PrependSignature* sig = (bls::PrependSignature*)inPtr;
+
+    uint8_t* buffer = static_cast<uint8_t*>(malloc(
+        bls::PrependSignature::SIGNATURE_SIZE));
+
+    sig->Serialize(buffer);
+    return static_cast<void*>(buffer);
+}
+
+void CPrependSignatureFree(CPrependSignature inPtr) {
+    bls::PrependSignature* sig = (bls::PrependSignature*)inPtr;
+    delete sig;
+}
+
+int CPrependSignatureSizeBytes() {
+    return bls::PrependSignature::SIGNATURE_SIZE;
+}
+
+CPrependSignature CPrependSignatureFromBytes(void *p, char **errMsg) {
+    bls::PrependSignature* sigPtr;
+    try {
+        sigPtr = new bls::PrependSignature(
+            bls::PrependSignature::FromBytes(static_cast<uint8_t*>(p))
+        );
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
+    return sigPtr;
+}
+
+CPrependSignature CPrependSignatureFromInsecureSig(CInsecureSignature inPtr) {
+    bls::PrependSignature *sig = new bls::PrependSignature(
+        bls::PrependSignature::FromInsecureSig(
+            *((bls::InsecureSignature *)inPtr))
+    );
+    return sig;
+}
+
+CInsecureSignature CPrependSignatureGetInsecureSig(CPrependSignature inPtr) {
+    bls::InsecureSignature *sig = new bls::InsecureSignature(
+        ((bls::PrependSignature *)inPtr)->GetInsecureSig()
+    );
+    return sig;
+}
+
+bool CPrependSignatureVerify(CPrependSignature inPtr, void **hashes,
+    size_t numHashes, void **publicKeys, size_t numPublicKeys) {
+    bls::PrependSignature *sig = (bls::PrependSignature*)inPtr;
+
+    // build hashes vector
+    std::vector<const uint8_t*> vecHashes;
+    for (int i = 0; i < numHashes; i++) {
+        const uint8_t* msg = (const uint8_t*)hashes[i];
+        vecHashes.push_back(msg);
+    }
+
+    // build pubkeys vector
+    std::vector<bls::PublicKey> vecPubKeys;
+    for (int i = 0; i < numPublicKeys; i++) {
+        bls::PublicKey* key = (bls::PublicKey*)publicKeys[i];
+        vecPubKeys.push_back(*key);
+    }
+
+    bool didVerify;
+    try {
+        didVerify = sig->Verify(vecHashes, vecPubKeys);
+    } catch (const std::exception& ex) {
+        didVerify = false;
+    }
+    return didVerify;
+}
+
+CPrependSignature CPrependSignatureAggregate(void **signatures,
+    size_t numSignatures, char **errMsg) {
+    // build the signatures vector
+    std::vector<bls::PrependSignature> vecSigs;
+    for (int i = 0 ; i < numSignatures; i++) {
+        bls::PrependSignature* sig = (bls::PrependSignature*)signatures[i];
+        vecSigs.push_back(*sig);
+    }
+
+    bls::PrependSignature *sig;
+    try {
+        sig = new bls::PrependSignature(
+            bls::PrependSignature::Aggregate(vecSigs)
+        );
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
+
+    return sig;
+}
+
+CPrependSignature CPrependSignatureDivideBy(CPrependSignature inPtr,
+    void **signatures, size_t numSignatures, char **errMsg) {
+    // build the signatures vector
+    std::vector<bls::PrependSignature> vecSigs;
+    for (int i = 0 ; i < numSignatures; i++) {
+        bls::PrependSignature* sig = (bls::PrependSignature*)signatures[i];
+        vecSigs.push_back(*sig);
+    }
+
+    bls::PrependSignature *sig = (bls::PrependSignature*)inPtr;
+    bls::PrependSignature* quotient;
+
+    try {
+        quotient = new bls::PrependSignature(
+            sig->DivideBy(vecSigs)
+        );
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
+
+    return quotient;
+}
+
+bool CPrependSignatureIsEqual(CPrependSignature aPtr,
+    CPrependSignature bPtr) {
+    bls::PrependSignature *a = (bls::PrependSignature*)aPtr;
+    bls::PrependSignature *b = (bls::PrependSignature*)bPtr;
+
+    return *a == *b;
+}
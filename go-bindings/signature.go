@@ -8,105 +8,310 @@ package blschia
 // #include "blschia.h"
 import "C"
 import (
-	"errors"
+	"fmt"
 	"runtime"
 	"unsafe"
 )
 
 // InsecureSignature represents an insecure BLS signature.
 type InsecureSignature struct {
+	*insecureSignature
+}
+
+// insecureSignature owns the C object. It is shared by all copies of the
+// InsecureSignature, so the finalizer only runs once none of them is reachable.
+type insecureSignature struct {
 	sig C.CInsecureSignature
 }
 
+func newInsecureSignature(ptr C.CInsecureSignature) InsecureSignature {
+	sig := &insecureSignature{sig: ptr}
+	runtime.SetFinalizer(sig, (*insecureSignature).free)
+	return InsecureSignature{sig}
+}
+
+func (sig *insecureSignature) free() {
+	if sig.sig != nil {
+		C.CInsecureSignatureFree(sig.sig)
+		sig.sig = nil
+	}
+}
+
 // Signature represents a BLS signature with aggregation info.
 type Signature struct {
+	*signature
+}
+
+// signature owns the C object. It is shared by all copies of the Signature, so
+// the finalizer only runs once none of them is reachable.
+type signature struct {
 	sig C.CSignature
 }
 
+func newSignature(ptr C.CSignature) Signature {
+	sig := &signature{sig: ptr}
+	runtime.SetFinalizer(sig, (*signature).free)
+	return Signature{sig}
+}
+
+func (sig *signature) free() {
+	if sig.sig != nil {
+		C.CSignatureFree(sig.sig)
+		sig.sig = nil
+	}
+}
+
+// PrependSignature represents a BLS signature generated using the prepend
+// method, which signs the signer's public key along with the message hash.
+type PrependSignature struct {
+	*prependSignature
+}
+
+// prependSignature owns the C object. It is shared by all copies of the
+// PrependSignature, so the finalizer only runs once none of them is reachable.
+type prependSignature struct {
+	sig C.CPrependSignature
+}
+
+func newPrependSignature(ptr C.CPrependSignature) PrependSignature {
+	sig := &prependSignature{sig: ptr}
+	runtime.SetFinalizer(sig, (*prependSignature).free)
+	return PrependSignature{sig}
+}
+
+func (sig *prependSignature) free() {
+	if sig.sig != nil {
+		C.CPrependSignatureFree(sig.sig)
+		sig.sig = nil
+	}
+}
+
 // InsecureSignatureFromBytes constructs a new insecure signature from bytes
 func InsecureSignatureFromBytes(data []byte) (InsecureSignature, error) {
+	if err := checkLength("insecure signature", data, int(C.CInsecureSignatureSizeBytes())); err != nil {
+		return InsecureSignature{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
 	defer C.free(cBytesPtr)
 
-	var sig InsecureSignature
-	var cDidErr C.bool
-	sig.sig = C.CInsecureSignatureFromBytes(cBytesPtr, &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return InsecureSignature{}, err
+	var cErrMsg *C.char
+	cSig := C.CInsecureSignatureFromBytes(cBytesPtr, &cErrMsg)
+	if cErrMsg != nil {
+		return InsecureSignature{}, errFromC(ErrInvalidSignature, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&sig, func(p *InsecureSignature) { p.Free() })
-	return sig, nil
+	return newInsecureSignature(cSig), nil
 }
 
 // Serialize returns the byte representation of the signature
 func (sig InsecureSignature) Serialize() []byte {
+	defer runtime.KeepAlive(sig)
 	ptr := C.CInsecureSignatureSerialize(sig.sig)
 	defer C.free(ptr)
 	return C.GoBytes(ptr, C.CInsecureSignatureSizeBytes())
 }
 
-// Free releases memory allocated by the signature
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize. The zero value can't be encoded, so it returns an
+// ErrZeroValue error.
+func (sig InsecureSignature) MarshalBinary() ([]byte, error) {
+	if sig.insecureSignature == nil {
+		return nil, errZeroValue("InsecureSignature")
+	}
+	return sig.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by InsecureSignatureFromBytes.
+func (sig *InsecureSignature) UnmarshalBinary(data []byte) error {
+	parsed, err := InsecureSignatureFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*sig = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the signature as hex.
+func (sig InsecureSignature) MarshalText() ([]byte, error) {
+	data, err := sig.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return marshalHex(data), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// signature.
+func (sig *InsecureSignature) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return sig.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the signature as a hex string.
+// The zero value is encoded as null.
+func (sig InsecureSignature) MarshalJSON() ([]byte, error) {
+	if sig.insecureSignature == nil {
+		return jsonNull, nil
+	}
+	return marshalJSON(sig.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the signature unchanged.
+func (sig *InsecureSignature) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return sig.UnmarshalText(text)
+}
+
+// Free releases memory allocated by the signature. All copies of the signature
+// share that memory, so none of them may be used afterwards. Calling Free again
+// is a no-op.
 func (sig InsecureSignature) Free() {
-	C.CInsecureSignatureFree(sig.sig)
+	if sig.insecureSignature == nil {
+		return
+	}
+	runtime.SetFinalizer(sig.insecureSignature, nil)
+	sig.free()
 }
 
 // SignatureFromBytes creates a new Signature object from the raw bytes
 func SignatureFromBytes(data []byte) (Signature, error) {
+	if err := checkLength("signature", data, int(C.CSignatureSizeBytes())); err != nil {
+		return Signature{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
 	defer C.free(cBytesPtr)
 
-	var sig Signature
-	var cDidErr C.bool
-	sig.sig = C.CSignatureFromBytes(cBytesPtr, &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return Signature{}, err
+	var cErrMsg *C.char
+	cSig := C.CSignatureFromBytes(cBytesPtr, &cErrMsg)
+	if cErrMsg != nil {
+		return Signature{}, errFromC(ErrInvalidSignature, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&sig, func(p *Signature) { p.Free() })
-	return sig, nil
+	return newSignature(cSig), nil
 }
 
 // Serialize returns the byte representation of the signature
 func (sig Signature) Serialize() []byte {
+	defer runtime.KeepAlive(sig)
 	ptr := C.CSignatureSerialize(sig.sig)
 	defer C.free(ptr)
 	return C.GoBytes(ptr, C.CSignatureSizeBytes())
 }
 
-// Free releases memory allocated by the signature
+// MarshalBinary implements encoding.BinaryMarshaler. It returns the same bytes
+// as Serialize. The zero value can't be encoded, so it returns an
+// ErrZeroValue error.
+func (sig Signature) MarshalBinary() ([]byte, error) {
+	if sig.signature == nil {
+		return nil, errZeroValue("Signature")
+	}
+	return sig.Serialize(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data is validated
+// by SignatureFromBytes. The aggregation info is not part of the encoding, so
+// it must be set with SetAggregationInfo before the signature can be verified.
+func (sig *Signature) UnmarshalBinary(data []byte) error {
+	parsed, err := SignatureFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*sig = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the signature as hex.
+func (sig Signature) MarshalText() ([]byte, error) {
+	data, err := sig.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return marshalHex(data), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a hex encoded
+// signature.
+func (sig *Signature) UnmarshalText(text []byte) error {
+	data, err := unmarshalHex(text)
+	if err != nil {
+		return err
+	}
+	return sig.UnmarshalBinary(data)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the signature as a hex string.
+// The zero value is encoded as null.
+func (sig Signature) MarshalJSON() ([]byte, error) {
+	if sig.signature == nil {
+		return jsonNull, nil
+	}
+	return marshalJSON(sig.Serialize())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a hex string. A null
+// leaves the signature unchanged.
+func (sig *Signature) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSON(data)
+	if err != nil || text == nil {
+		return err
+	}
+	return sig.UnmarshalText(text)
+}
+
+// Free releases memory allocated by the signature. All copies of the signature
+// share that memory, so none of them may be used afterwards. Calling Free again
+// is a no-op.
 func (sig Signature) Free() {
-	C.CSignatureFree(sig.sig)
+	if sig.signature == nil {
+		return
+	}
+	runtime.SetFinalizer(sig.signature, nil)
+	sig.free()
 }
 
 // Verify a single or aggregate signature
 func (sig Signature) Verify() bool {
+	defer runtime.KeepAlive(sig)
 	return bool(C.CSignatureVerify(sig.sig))
 }
 
 // SetAggregationInfo sets the aggregation information on this signature, which
 // describes how this signature was generated, and how it should be verified.
 func (sig Signature) SetAggregationInfo(ai AggregationInfo) {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(ai)
 	C.CSignatureSetAggregationInfo(sig.sig, ai.ai)
 }
 
-// GetAggregationInfo returns the aggregation info on this signature.
+// GetAggregationInfo returns a copy of the aggregation info on this
+// signature. The copy is owned by the caller, and is unaffected by later
+// changes to (or freeing of) the signature.
 func (sig Signature) GetAggregationInfo() AggregationInfo {
-	var ai AggregationInfo
-	ai.ai = C.CSignatureGetAggregationInfo(sig.sig)
-	// nothing is newly allocated here, so no need to set a finalizer
-	return ai
+	defer runtime.KeepAlive(sig)
+	return newAggregationInfo(C.CSignatureGetAggregationInfo(sig.sig))
 }
 
 // SignatureAggregate aggregates many signatures using the secure aggregation
 // method.
 func SignatureAggregate(signatures []Signature) (Signature, error) {
+	defer runtime.KeepAlive(signatures)
+
+	if len(signatures) == 0 {
+		return Signature{}, &Error{Err: ErrEmptyAggregation, Msg: "no signatures given"}
+	}
+
 	// Get a C pointer to an array of signatures
 	cSigArrPtr := C.AllocPtrArray(C.size_t(len(signatures)))
 	defer C.FreePtrArray(cSigArrPtr)
@@ -117,17 +322,13 @@ func SignatureAggregate(signatures []Signature) (Signature, error) {
 		C.SetPtrArray(cSigArrPtr, unsafe.Pointer(sig.sig), C.int(i))
 	}
 
-	var sig Signature
-	var cDidErr C.bool
-	sig.sig = C.CSignatureAggregate(cSigArrPtr, C.size_t(len(signatures)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return Signature{}, err
+	var cErrMsg *C.char
+	cSig := C.CSignatureAggregate(cSigArrPtr, C.size_t(len(signatures)), &cErrMsg)
+	if cErrMsg != nil {
+		return Signature{}, errFromC(ErrInvalidSignature, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&sig, func(p *Signature) { p.Free() })
-	return sig, nil
+	return newSignature(cSig), nil
 }
 
 // DivideBy divides the aggregate signature (this) by a list of signatures.
@@ -135,6 +336,9 @@ func SignatureAggregate(signatures []Signature) (Signature, error) {
 // These divisors can be single or aggregate signatures, but all msg/pk pairs
 // in these signatures must be distinct and unique.
 func (sig Signature) DivideBy(signatures []Signature) (Signature, error) {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(signatures)
+
 	if len(signatures) == 0 {
 		return sig, nil
 	}
@@ -148,21 +352,20 @@ func (sig Signature) DivideBy(signatures []Signature) (Signature, error) {
 		C.SetPtrArray(cSigArrPtr, unsafe.Pointer(sig.sig), C.int(i))
 	}
 
-	var quo Signature
-	var cDidErr C.bool
-	quo.sig = C.CSignatureDivideBy(sig.sig, cSigArrPtr, C.size_t(len(signatures)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return Signature{}, err
+	var cErrMsg *C.char
+	cSig := C.CSignatureDivideBy(sig.sig, cSigArrPtr, C.size_t(len(signatures)), &cErrMsg)
+	if cErrMsg != nil {
+		return Signature{}, errDivideBy(cErrMsg)
 	}
 
-	runtime.SetFinalizer(&quo, func(p *Signature) { p.Free() })
-	return quo, nil
+	return newSignature(cSig), nil
 }
 
 // DivideBy insecurely divides signatures
 func (sig InsecureSignature) DivideBy(signatures []InsecureSignature) (InsecureSignature, error) {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(signatures)
+
 	if len(signatures) == 0 {
 		return sig, nil
 	}
@@ -176,28 +379,32 @@ func (sig InsecureSignature) DivideBy(signatures []InsecureSignature) (InsecureS
 		C.SetPtrArray(cSigArrPtr, unsafe.Pointer(sig.sig), C.int(i))
 	}
 
-	var quo InsecureSignature
-	var cDidErr C.bool
-	quo.sig = C.CInsecureSignatureDivideBy(sig.sig, cSigArrPtr, C.size_t(len(signatures)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return InsecureSignature{}, err
+	var cErrMsg *C.char
+	cSig := C.CInsecureSignatureDivideBy(sig.sig, cSigArrPtr, C.size_t(len(signatures)), &cErrMsg)
+	if cErrMsg != nil {
+		return InsecureSignature{}, errFromC(ErrInvalidSignature, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&quo, func(p *InsecureSignature) { p.Free() })
-	return quo, nil
+	return newInsecureSignature(cSig), nil
 }
 
-// Verify a single or aggregate signature
+// Verify a single or aggregate signature. Hashes which are not
+// MessageHashSize bytes long fail verification.
 //
 // This verification method is insecure in regard to the rogue public key
 // attack
 func (sig InsecureSignature) Verify(hashes [][]byte, publicKeys []PublicKey) bool {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(publicKeys)
+
 	if (len(hashes) != len(publicKeys)) || len(hashes) == 0 {
-		// panic("hashes and pubKeys vectors must be of same size and non-empty")
 		return false
 	}
+	for _, hash := range hashes {
+		if len(hash) != MessageHashSize {
+			return false
+		}
+	}
 
 	// Get a C pointer to an array of message hashes
 	cNumHashes := C.size_t(len(hashes))
@@ -225,6 +432,12 @@ func (sig InsecureSignature) Verify(hashes [][]byte, publicKeys []PublicKey) boo
 
 // InsecureSignatureAggregate insecurely aggregates signatures
 func InsecureSignatureAggregate(signatures []InsecureSignature) (InsecureSignature, error) {
+	defer runtime.KeepAlive(signatures)
+
+	if len(signatures) == 0 {
+		return InsecureSignature{}, &Error{Err: ErrEmptyAggregation, Msg: "no signatures given"}
+	}
+
 	// Get a C pointer to an array of signatures
 	cSigArrPtr := C.AllocPtrArray(C.size_t(len(signatures)))
 	defer C.FreePtrArray(cSigArrPtr)
@@ -234,71 +447,255 @@ func InsecureSignatureAggregate(signatures []InsecureSignature) (InsecureSignatu
 		C.SetPtrArray(cSigArrPtr, unsafe.Pointer(sig.sig), C.int(i))
 	}
 
-	var sig InsecureSignature
-	var cDidErr C.bool
-	sig.sig = C.CInsecureSignatureAggregate(cSigArrPtr, C.size_t(len(signatures)), &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
-		return InsecureSignature{}, err
+	var cErrMsg *C.char
+	cSig := C.CInsecureSignatureAggregate(cSigArrPtr, C.size_t(len(signatures)), &cErrMsg)
+	if cErrMsg != nil {
+		return InsecureSignature{}, errFromC(ErrInvalidSignature, cErrMsg)
 	}
 
-	runtime.SetFinalizer(&sig, func(p *InsecureSignature) { p.Free() })
-	return sig, nil
+	return newInsecureSignature(cSig), nil
 }
 
 // Equal tests if one InsecureSignature object is equal to another
 func (sig InsecureSignature) Equal(other InsecureSignature) bool {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(other)
 	return bool(C.CInsecureSignatureIsEqual(sig.sig, other.sig))
 }
 
 // Equal tests if one Signature object is equal to another
 func (sig Signature) Equal(other Signature) bool {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(other)
 	return bool(C.CSignatureIsEqual(sig.sig, other.sig))
 }
 
 // SignatureFromBytesWithAggregationInfo creates a new Signature object from
 // the raw bytes and aggregation info
 func SignatureFromBytesWithAggregationInfo(data []byte, ai AggregationInfo) (Signature, error) {
+	defer runtime.KeepAlive(ai)
+
+	if err := checkLength("signature", data, int(C.CSignatureSizeBytes())); err != nil {
+		return Signature{}, err
+	}
+
 	// Get a C pointer to bytes
 	cBytesPtr := C.CBytes(data)
 	defer C.free(cBytesPtr)
 
-	var sig Signature
-	var cDidErr C.bool
-	sig.sig = C.CSignatureFromBytesWithAggregationInfo(cBytesPtr, ai.ai, &cDidErr)
-	if bool(cDidErr) {
-		cErrMsg := C.GetLastErrorMsg()
-		err := errors.New(C.GoString(cErrMsg))
+	var cErrMsg *C.char
+	cSig := C.CSignatureFromBytesWithAggregationInfo(cBytesPtr, ai.ai, &cErrMsg)
+	if cErrMsg != nil {
+		return Signature{}, errFromC(ErrInvalidSignature, cErrMsg)
+	}
+
+	return newSignature(cSig), nil
+}
+
+// SerializeWithAggregationInfo returns the byte representation of the
+// signature followed by that of its aggregation info, so that the receiver can
+// verify it. Use SignatureFromBytesWithEmbeddedAggregationInfo to parse it.
+func (sig Signature) SerializeWithAggregationInfo() []byte {
+	ai := sig.GetAggregationInfo()
+	defer ai.Free()
+	return append(sig.Serialize(), ai.Serialize()...)
+}
+
+// SignatureFromBytesWithEmbeddedAggregationInfo parses a signature written by
+// SerializeWithAggregationInfo, which carries its aggregation info after the
+// signature bytes. This differs from SignatureFromBytesWithAggregationInfo,
+// which takes the aggregation info as a separate argument. The embedded one is
+// validated by AggregationInfoFromBytes.
+func SignatureFromBytesWithEmbeddedAggregationInfo(data []byte) (Signature, error) {
+	sigLen := int(C.CSignatureSizeBytes())
+	if len(data) < sigLen {
+		return Signature{}, &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("signature with aggregation info must be at least %d bytes, got %d", sigLen, len(data)),
+		}
+	}
+
+	ai, err := AggregationInfoFromBytes(data[sigLen:])
+	if err != nil {
 		return Signature{}, err
 	}
+	defer ai.Free()
 
-	runtime.SetFinalizer(&sig, func(p *Signature) { p.Free() })
-	return sig, nil
+	return SignatureFromBytesWithAggregationInfo(data[:sigLen], ai)
 }
 
 // SignatureFromInsecureSig constructs a signature from an insecure signature
 // (but has no aggregation info)
 func SignatureFromInsecureSig(isig InsecureSignature) Signature {
-	var sig Signature
-	sig.sig = C.CSignatureFromInsecureSig(isig.sig)
-	runtime.SetFinalizer(&sig, func(p *Signature) { p.Free() })
-	return sig
+	defer runtime.KeepAlive(isig)
+	return newSignature(C.CSignatureFromInsecureSig(isig.sig))
 }
 
 // SignatureFromInsecureSigWithAggregationInfo constructs a secure signature
 // from an insecure signature and aggregation info
 func SignatureFromInsecureSigWithAggregationInfo(isig InsecureSignature, ai AggregationInfo) Signature {
-	var sig Signature
-	sig.sig = C.CSignatureFromInsecureSigWithAggregationInfo(isig.sig, ai.ai)
-	runtime.SetFinalizer(&sig, func(p *Signature) { p.Free() })
-	return sig
+	defer runtime.KeepAlive(isig)
+	defer runtime.KeepAlive(ai)
+	return newSignature(C.CSignatureFromInsecureSigWithAggregationInfo(isig.sig, ai.ai))
 }
 
 // GetInsecureSig returns an insecure signature from the secure variant
 func (sig Signature) GetInsecureSig() InsecureSignature {
-	var isig InsecureSignature
-	isig.sig = C.CSignatureGetInsecureSig(sig.sig)
-	runtime.SetFinalizer(&isig, func(p *InsecureSignature) { p.Free() })
-	return isig
+	defer runtime.KeepAlive(sig)
+	return newInsecureSignature(C.CSignatureGetInsecureSig(sig.sig))
+}
+
+// PrependSignatureFromBytes constructs a new prepend signature from bytes
+func PrependSignatureFromBytes(data []byte) (PrependSignature, error) {
+	if err := checkLength("prepend signature", data, int(C.CPrependSignatureSizeBytes())); err != nil {
+		return PrependSignature{}, err
+	}
+
+	// Get a C pointer to bytes
+	cBytesPtr := C.CBytes(data)
+	defer C.free(cBytesPtr)
+
+	var cErrMsg *C.char
+	cSig := C.CPrependSignatureFromBytes(cBytesPtr, &cErrMsg)
+	if cErrMsg != nil {
+		return PrependSignature{}, errFromC(ErrInvalidSignature, cErrMsg)
+	}
+
+	return newPrependSignature(cSig), nil
+}
+
+// PrependSignatureFromInsecureSig constructs a prepend signature from an
+// insecure signature
+func PrependSignatureFromInsecureSig(isig InsecureSignature) PrependSignature {
+	defer runtime.KeepAlive(isig)
+	return newPrependSignature(C.CPrependSignatureFromInsecureSig(isig.sig))
+}
+
+// Serialize returns the byte representation of the signature
+func (sig PrependSignature) Serialize() []byte {
+	defer runtime.KeepAlive(sig)
+	ptr := C.CPrependSignatureSerialize(sig.sig)
+	defer C.free(ptr)
+	return C.GoBytes(ptr, C.CPrependSignatureSizeBytes())
+}
+
+// Free releases memory allocated by the signature. All copies of the signature
+// share that memory, so none of them may be used afterwards. Calling Free again
+// is a no-op.
+func (sig PrependSignature) Free() {
+	if sig.prependSignature == nil {
+		return
+	}
+	runtime.SetFinalizer(sig.prependSignature, nil)
+	sig.free()
+}
+
+// GetInsecureSig returns an insecure signature from the prepend variant
+func (sig PrependSignature) GetInsecureSig() InsecureSignature {
+	defer runtime.KeepAlive(sig)
+	return newInsecureSignature(C.CPrependSignatureGetInsecureSig(sig.sig))
+}
+
+// Verify a single or aggregate prepend signature
+//
+// The hashes are the message hashes, and the public keys are prepended to
+// them before verification. Hashes which are not MessageHashSize bytes long
+// fail verification.
+func (sig PrependSignature) Verify(hashes [][]byte, publicKeys []PublicKey) bool {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(publicKeys)
+
+	if (len(hashes) != len(publicKeys)) || len(hashes) == 0 {
+		return false
+	}
+	for _, hash := range hashes {
+		if len(hash) != MessageHashSize {
+			return false
+		}
+	}
+
+	// Get a C pointer to an array of message hashes
+	cNumHashes := C.size_t(len(hashes))
+	cHashesPtr := C.AllocPtrArray(cNumHashes)
+	defer C.FreePtrArray(cHashesPtr)
+	// Loop thru each message and add the key C ptr to the array of ptrs at index
+	for i, hash := range hashes {
+		cBytesPtr := C.CBytes(hash)
+		defer C.free(cBytesPtr)
+		C.SetPtrArray(cHashesPtr, cBytesPtr, C.int(i))
+	}
+
+	// Get a C pointer to an array of public keys
+	cNumPublicKeys := C.size_t(len(publicKeys))
+	cPublicKeysPtr := C.AllocPtrArray(cNumPublicKeys)
+	defer C.FreePtrArray(cPublicKeysPtr)
+	// Loop thru each key and add the key C ptr to the array of ptrs at index
+	for i, key := range publicKeys {
+		C.SetPtrArray(cPublicKeysPtr, unsafe.Pointer(key.pk), C.int(i))
+	}
+
+	return bool(C.CPrependSignatureVerify(sig.sig, cHashesPtr, cNumHashes,
+		cPublicKeysPtr, cNumPublicKeys))
+}
+
+// PrependSignatureAggregate aggregates prepend signatures using the simple
+// aggregation method
+func PrependSignatureAggregate(signatures []PrependSignature) (PrependSignature, error) {
+	defer runtime.KeepAlive(signatures)
+
+	if len(signatures) == 0 {
+		return PrependSignature{}, &Error{Err: ErrEmptyAggregation, Msg: "no signatures given"}
+	}
+
+	// Get a C pointer to an array of signatures
+	cSigArrPtr := C.AllocPtrArray(C.size_t(len(signatures)))
+	defer C.FreePtrArray(cSigArrPtr)
+	// Loop thru each sig and add the pointer to it, to the C pointer array at
+	// the given index.
+	for i, sig := range signatures {
+		C.SetPtrArray(cSigArrPtr, unsafe.Pointer(sig.sig), C.int(i))
+	}
+
+	var cErrMsg *C.char
+	cSig := C.CPrependSignatureAggregate(cSigArrPtr, C.size_t(len(signatures)), &cErrMsg)
+	if cErrMsg != nil {
+		return PrependSignature{}, errFromC(ErrInvalidSignature, cErrMsg)
+	}
+
+	return newPrependSignature(cSig), nil
+}
+
+// DivideBy divides the aggregate prepend signature (this) by a list of
+// prepend signatures.
+func (sig PrependSignature) DivideBy(signatures []PrependSignature) (PrependSignature, error) {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(signatures)
+
+	if len(signatures) == 0 {
+		return sig, nil
+	}
+
+	// Get a C pointer to an array of signatures
+	cSigArrPtr := C.AllocPtrArray(C.size_t(len(signatures)))
+	defer C.FreePtrArray(cSigArrPtr)
+	// Loop thru each sig and add the pointer to it, to the C pointer array at
+	// the given index.
+	for i, sig := range signatures {
+		C.SetPtrArray(cSigArrPtr, unsafe.Pointer(sig.sig), C.int(i))
+	}
+
+	var cErrMsg *C.char
+	cSig := C.CPrependSignatureDivideBy(sig.sig, cSigArrPtr, C.size_t(len(signatures)), &cErrMsg)
+	if cErrMsg != nil {
+		return PrependSignature{}, errFromC(ErrInvalidSignature, cErrMsg)
+	}
+
+	return newPrependSignature(cSig), nil
+}
+
+// Equal tests if one PrependSignature object is equal to another
+func (sig PrependSignature) Equal(other PrependSignature) bool {
+	defer runtime.KeepAlive(sig)
+	defer runtime.KeepAlive(other)
+	return bool(C.CPrependSignatureIsEqual(sig.sig, other.sig))
 }
@@ -25,7 +25,7 @@ extern "C" {
 
 typedef void* CInsecureSignature;
 
-CInsecureSignature CInsecureSignatureFromBytes(void *p, bool *didErr);
+CInsecureSignature CInsecureSignatureFromBytes(void *p, char **errMsg);
 
 void* CInsecureSignatureSerialize(CInsecureSignature inPtr);
 void CInsecureSignatureFree(CInsecureSignature inPtr);
@@ -35,10 +35,10 @@ bool CInsecureSignatureVerify(CInsecureSignature inPtr, void **hashes,
     size_t numHashes, void **publicKeys, size_t numPublicKeys);
 
 CInsecureSignature CInsecureSignatureAggregate(void **signatures,
-    size_t numSignatures, bool *didErr);
+    size_t numSignatures, char **errMsg);
 
 CInsecureSignature CInsecureSignatureDivideBy(CInsecureSignature inPtr,
-    void **signatures, size_t numSignatures, bool *didErr);
+    void **signatures, size_t numSignatures, char **errMsg);
 
 bool CInsecureSignatureIsEqual(CInsecureSignature aPtr,
     CInsecureSignature bPtr);
@@ -47,10 +47,10 @@ bool CInsecureSignatureIsEqual(CInsecureSignature aPtr,
 
 typedef void* CSignature;
 
-CSignature CSignatureFromBytes(void *p, bool *didErr);
+CSignature CSignatureFromBytes(void *p, char **errMsg);
 
 CSignature CSignatureFromBytesWithAggregationInfo(void *p,
-    CAggregationInfo aiPtr, bool *didErr);
+    CAggregationInfo aiPtr, char **errMsg);
 CSignature CSignatureFromInsecureSig(CInsecureSignature inPtr);
 
 CSignature CSignatureFromInsecureSigWithAggregationInfo(
@@ -67,13 +67,38 @@ void CSignatureSetAggregationInfo(CSignature inPtr, CAggregationInfo aiPtr);
 
 CAggregationInfo CSignatureGetAggregationInfo(CSignature inPtr);
 
-CSignature CSignatureAggregate(void **sigs, size_t len, bool *didErr);
+CSignature CSignatureAggregate(void **sigs, size_t len, char **errMsg);
 
 CSignature CSignatureDivideBy(CSignature inPtr, void **sigs, size_t len,
-    bool *didErr);
+    char **errMsg);
 
 bool CSignatureIsEqual(CSignature aPtr, CSignature bPtr);
 
+// -- PrependSignature
+
+typedef void* CPrependSignature;
+
+CPrependSignature CPrependSignatureFromBytes(void *p, char **errMsg);
+
+CPrependSignature CPrependSignatureFromInsecureSig(CInsecureSignature inPtr);
+CInsecureSignature CPrependSignatureGetInsecureSig(CPrependSignature inPtr);
+
+void* CPrependSignatureSerialize(CPrependSignature inPtr);
+void CPrependSignatureFree(CPrependSignature inPtr);
+int CPrependSignatureSizeBytes();
+
+bool CPrependSignatureVerify(CPrependSignature inPtr, void **hashes,
+    size_t numHashes, void **publicKeys, size_t numPublicKeys);
+
+CPrependSignature CPrependSignatureAggregate(void **signatures,
+    size_t numSignatures, char **errMsg);
+
+CPrependSignature CPrependSignatureDivideBy(CPrependSignature inPtr,
+    void **signatures, size_t numSignatures, char **errMsg);
+
+bool CPrependSignatureIsEqual(CPrependSignature aPtr,
+    CPrependSignature bPtr);
+
 #ifdef __cplusplus
 }
 #endif
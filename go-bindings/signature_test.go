@@ -46,6 +46,9 @@ func TestSignature(t *testing.T) {
 	if !insecureSig2.Verify([][]byte{mh}, []bls.PublicKey{sk2.PublicKey()}) {
 		t.Error("insecureSig2 should verify")
 	}
+	if insecureSig1.Verify([][]byte{mh[:31]}, []bls.PublicKey{sk1.PublicKey()}) {
+		t.Error("insecureSig1 should not verify with a short hash")
+	}
 
 	ai1 := sig1.GetAggregationInfo()
 	sig3, _ := bls.SignatureFromBytes(sig1Bytes)
@@ -170,3 +173,102 @@ func TestSignatureDivision(t *testing.T) {
 		t.Errorf("got %v, expected %v", quotInsBytes, insecureSig2Bytes)
 	}
 }
+
+func TestPrependSignature(t *testing.T) {
+	m1 := []byte{1, 2, 3, 40}
+	m2 := []byte{5, 6, 70, 201}
+
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, true)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, true)
+
+	sig1 := sk1.SignPrepend(m1)
+	sig2, err := sk2.SignPrependPrehashed(Sha256(m2))
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if sig1.Equal(sig2) {
+		t.Error("sig1 should NOT be equal to sig2")
+	}
+	if !sig2.Verify([][]byte{Sha256(m2)}, []bls.PublicKey{sk2.PublicKey()}) {
+		t.Error("sig2 should verify")
+	}
+	_, err = sk2.SignPrependPrehashed(Sha256(m2)[:31])
+	expectError(t, err, bls.ErrLengthMismatch)
+	if sig2.Verify([][]byte{Sha256(m2)[:31]}, []bls.PublicKey{sk2.PublicKey()}) {
+		t.Error("sig2 should not verify with a short hash")
+	}
+
+	sig3, err := bls.PrependSignatureFromBytes(sig1.Serialize())
+	if err != nil {
+		t.Errorf("got unexpected error: %v", err.Error())
+	}
+	if !sig1.Equal(sig3) {
+		t.Error("sig1 should be equal to sig3")
+	}
+
+	// The second bit marks a prepend signature, so regular signature bytes
+	// must be rejected
+	_, err = bls.PrependSignatureFromBytes(sig1Bytes)
+	if err == nil {
+		t.Error("did not get expected error")
+	}
+
+	insecureSig1 := sig1.GetInsecureSig()
+	if bytes.Equal(insecureSig1.Serialize(), sig1.Serialize()) {
+		t.Error("insecure serialization should not carry the prepend bit")
+	}
+	sig4 := bls.PrependSignatureFromInsecureSig(insecureSig1)
+	if !sig4.Equal(sig1) {
+		t.Error("sig4 should be equal to sig1")
+	}
+
+	aggSig, _ := bls.PrependSignatureAggregate([]bls.PrependSignature{sig1, sig2})
+	quot, err := aggSig.DivideBy([]bls.PrependSignature{sig1})
+	if err != nil {
+		t.Errorf("got unexpected error: %v", err.Error())
+	}
+	if !quot.Equal(sig2) {
+		t.Error("quot should be equal to sig2")
+	}
+
+	sig4.Free()
+	insecureSig1.Free()
+	sig3.Free()
+	sig2.Free()
+	sig1.Free()
+}
+
+func TestSignatureAggregationInfoOwnership(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, true)
+	sig1 := sk1.Sign(payload)
+
+	// The aggregation info is a copy, which outlives the signature
+	ai := sig1.GetAggregationInfo()
+	sig1.Free()
+	collectGarbage()
+
+	pks := ai.GetPubKeys()
+	if len(pks) != 1 || !bytes.Equal(pks[0].Serialize(), pk1Bytes) {
+		t.Errorf("got %v, expected [%v]", pks, pk1Bytes)
+	}
+
+	// Changing the copy doesn't change the signature
+	sig2 := sk1.Sign(payload)
+	ai2 := sig2.GetAggregationInfo()
+	err := ai2.RemoveEntries([][]byte{Sha256(payload)}, pks)
+	if err != nil {
+		t.Errorf("got unexpected error: %v", err.Error())
+	}
+	if !ai2.Empty() {
+		t.Error("ai2 should be empty")
+	}
+	if !sig2.Verify() {
+		t.Error("sig2 should verify")
+	}
+
+	ai2.Free()
+	sig2.Free()
+	ai.Free()
+	ai.Free()
+	sk1.Free()
+}
@@ -15,6 +15,7 @@
 #include "threshold.h"
 #include <vector>
 #include "bls.hpp"
+#include "error.h"
 
 CPrivateKey CThresholdCreate(
     void **commitments,
@@ -55,26 +56,30 @@ CPrivateKey CThresholdCreate(
     return key;
 }
 
-void** CThresholdLagrangeCoeffsAtZero(size_t *players, size_t T) {
+uint8_t* CThresholdLagrangeCoeffsAtZero(size_t *players, size_t T,
+    char **errMsg) {
     bn_t *coeffs = new bn_t[T];
     try {
         bls::Threshold::LagrangeCoeffsAtZero(coeffs, players, T);
-    } catch (const std::exception& e) {
+    } catch (const std::exception& ex) {
         delete[] coeffs;
-        throw e;
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
     }
 
     // these use Fr (field element w/RFieldModulus, or `n`), which is used by
     // private key and occupy same size bytes
-    uint8_t **buffer = static_cast<uint8_t**>(
+    uint8_t *buffer = static_cast<uint8_t*>(
         malloc(bls::PrivateKey::PRIVATE_KEY_SIZE * T));
 
     for (int i = 0; i < T; ++i) {
-        bn_write_bin(buffer[i], bls::PrivateKey::PRIVATE_KEY_SIZE, coeffs[i]);
+        bn_write_bin(&buffer[i * bls::PrivateKey::PRIVATE_KEY_SIZE],
+            bls::PrivateKey::PRIVATE_KEY_SIZE, coeffs[i]);
     }
 
     delete[] coeffs;
-    return reinterpret_cast<void**>(buffer);
+    return buffer;
 }
 
 void* CThresholdInterpolateAtZero(size_t *X, CBigNum *Y, size_t T) {
@@ -94,7 +99,7 @@ void* CThresholdInterpolateAtZero(size_t *X, CBigNum *Y, size_t T) {
 }
 
 bool CThresholdVerifySecretFragment(size_t player, CPrivateKey secretFragment,
-    void ** commitments, size_t numCommitments, size_t T) {
+    void ** commitments, size_t numCommitments, size_t T, char **errMsg) {
 
     // build commitments vector
     std::vector<bls::PublicKey> vecCommitments;
@@ -105,24 +110,37 @@ bool CThresholdVerifySecretFragment(size_t player, CPrivateKey secretFragment,
 
     bls::PrivateKey* key = (bls::PrivateKey*)secretFragment;
 
-    return bls::Threshold::VerifySecretFragment(player, *key, vecCommitments,
-        T);
+    try {
+        return bls::Threshold::VerifySecretFragment(player, *key,
+            vecCommitments, T);
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return false;
+    }
 }
 
 CInsecureSignature CThresholdSignWithCoefficient(CPrivateKey skPtr, void *msg,
-    size_t len, size_t player, size_t *players, size_t T) {
+    size_t len, size_t player, size_t *players, size_t T, char **errMsg) {
     bls::PrivateKey *key = (bls::PrivateKey *)skPtr;
 
-    bls::InsecureSignature *sig = new bls::InsecureSignature(
-        bls::Threshold::SignWithCoefficient(*key, static_cast<uint8_t*>(msg),
-            len, player, players, T)
-    );
+    bls::InsecureSignature *sig;
+    try {
+        sig = new bls::InsecureSignature(
+            bls::Threshold::SignWithCoefficient(*key,
+                static_cast<uint8_t*>(msg), len, player, players, T)
+        );
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
 
     return sig;
 }
 
 CInsecureSignature CThresholdAggregateUnitSigs(void **sigs, size_t numSigs,
-    void *msg, size_t len, size_t *players, size_t T) {
+    void *msg, size_t len, size_t *players, size_t T, char **errMsg) {
     // build signatures vector
     std::vector<bls::InsecureSignature> vecSignatures;
     for (int i = 0 ; i < numSigs; i++) {
@@ -130,10 +148,17 @@ CInsecureSignature CThresholdAggregateUnitSigs(void **sigs, size_t numSigs,
         vecSignatures.push_back(*sig);
     }
 
-    bls::InsecureSignature *sig = new bls::InsecureSignature(
-        bls::Threshold::AggregateUnitSigs(vecSignatures,
-            static_cast<uint8_t*>(msg), len, players, T)
-    );
+    bls::InsecureSignature *sig;
+    try {
+        sig = new bls::InsecureSignature(
+            bls::Threshold::AggregateUnitSigs(vecSignatures,
+                static_cast<uint8_t*>(msg), len, players, T)
+        );
+    } catch (const std::exception& ex) {
+        // set err
+        SetErrorMsg(errMsg, ex);
+        return nullptr;
+    }
 
     return sig;
 }
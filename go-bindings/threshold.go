@@ -8,17 +8,19 @@ package blschia
 // #include "blschia.h"
 import "C"
 import (
-	"math/big"
+	"fmt"
 	"runtime"
 	"unsafe"
 )
 
 // ThresholdCreate constructs a PrivateKey with associated data suitable for a
 // threshold signature scheme
-func ThresholdCreate(T, N int) (PrivateKey, []PublicKey, []PrivateKey) {
-	if (T < 1) || (T > N) {
-		panic("threshold parameter T must be between 1 and N")
-		// err not panic?
+func ThresholdCreate(T, N int) (PrivateKey, []PublicKey, []PrivateKey, error) {
+	if T < 1 || T > N {
+		return PrivateKey{}, nil, nil, &Error{
+			Err: ErrInvalidThreshold,
+			Msg: fmt.Sprintf("T must be between 1 and N, got T=%d, N=%d", T, N),
+		}
 	}
 
 	// There are T polynomials / commitments
@@ -29,35 +31,25 @@ func ThresholdCreate(T, N int) (PrivateKey, []PublicKey, []PrivateKey) {
 	secretFragmentsPtr := C.AllocPtrArray(C.size_t(N))
 	defer C.FreePtrArray(secretFragmentsPtr)
 
-	var sk PrivateKey
-	sk.sk = C.CThresholdCreate(commitmentsPtr, secretFragmentsPtr, C.size_t(T), C.size_t(N))
-	runtime.SetFinalizer(&sk, func(p *PrivateKey) { p.Free() })
+	sk := newPrivateKey(C.CThresholdCreate(commitmentsPtr, secretFragmentsPtr, C.size_t(T), C.size_t(N)))
 
-	// Loop thru each commitment and get the value (copy bytes) and create a
-	// new PublicKey object
+	// Loop thru each commitment and take ownership of the PublicKey object
+	// allocated for it
 	commitments := make([]PublicKey, T)
 	for i := 0; i < T; i++ {
 		ptr := C.GetPtrAtIndex(commitmentsPtr, C.int(i))
-		defer C.free(ptr)
-		var tempPk PublicKey
-		tempPk.pk = C.CPublicKey(ptr)
-		pkBytes := tempPk.Serialize()
-		commitments[i], _ = PublicKeyFromBytes(pkBytes)
+		commitments[i] = newPublicKey(C.CPublicKey(ptr))
 	}
 
-	// Loop thru each fragment and get the value (copy bytes) and create a new
-	// PrivateKey object
+	// Loop thru each fragment and take ownership of the PrivateKey object
+	// allocated for it
 	secretFragments := make([]PrivateKey, N)
 	for i := 0; i < N; i++ {
 		ptr := C.GetPtrAtIndex(secretFragmentsPtr, C.int(i))
-		defer C.free(ptr)
-		var tempSk PrivateKey
-		tempSk.sk = C.CPrivateKey(ptr)
-		skBytes := tempSk.Serialize()
-		secretFragments[i], _ = PrivateKeyFromBytes(skBytes, true)
+		secretFragments[i] = newPrivateKey(C.CPrivateKey(ptr))
 	}
 
-	return sk, commitments, secretFragments
+	return sk, commitments, secretFragments, nil
 }
 
 // ThresholdLagrangeCoeffsAtZero returns lagrange coefficients of a polynomial
@@ -66,7 +58,11 @@ func ThresholdCreate(T, N int) (PrivateKey, []PublicKey, []PrivateKey) {
 // If we have T points (players[i], P(players[i])), it interpolates to a degree
 // T-1 polynomial P.  The returned coefficients are such that P(0) = sum_i
 // res[i] * P(players[i]).
-func ThresholdLagrangeCoeffsAtZero(players []int, T int) []*big.Int {
+func ThresholdLagrangeCoeffsAtZero(players []int, T int) ([]Fr, error) {
+	if err := checkPlayers(players, T); err != nil {
+		return nil, err
+	}
+
 	// Get a C pointer to players array
 	cPlayersPtr := C.AllocIntPtr(C.size_t(len(players)))
 	defer C.FreeIntPtr(cPlayersPtr)
@@ -74,24 +70,42 @@ func ThresholdLagrangeCoeffsAtZero(players []int, T int) []*big.Int {
 		C.SetIntPtrVal(cPlayersPtr, C.size_t(value), C.int(i))
 	}
 
-	// returns a **, or a *unsafe.Pointer
-	arrPtr := C.CThresholdLagrangeCoeffsAtZero(cPlayersPtr, C.size_t(T))
-	defer C.FreePtrArray(arrPtr)
+	var cErrMsg *C.char
+	arrPtr := C.CThresholdLagrangeCoeffsAtZero(cPlayersPtr, C.size_t(T), &cErrMsg)
+	if cErrMsg != nil {
+		return nil, errFromC(ErrInvalidPlayers, cErrMsg)
+	}
+	defer C.free(unsafe.Pointer(arrPtr))
 
-	res := make([]*big.Int, T)
+	res := make([]Fr, T)
 	for i := 0; i < T; i++ {
-		// get the singular pointer at index
-		ptr := C.GetPtrAtIndex(arrPtr, C.int(i))
-		val := C.GoBytes(ptr, C.CPrivateKeySizeBytes())
-		res[i] = new(big.Int).SetBytes(val)
+		// get the address of the coefficient at index
+		ptr := C.GetAddressAtIndex(arrPtr, C.int(i*FrSize))
+		copy(res[i].value[:], C.GoBytes(ptr, C.int(FrSize)))
 	}
 
-	return res
+	return res, nil
 }
 
 // ThresholdVerifySecretFragment returns true iff the secretFragment from the
 // given player matches their given commitment to a polynomial.
-func ThresholdVerifySecretFragment(player int, secretFragment PrivateKey, commitments []PublicKey, T int) bool {
+func ThresholdVerifySecretFragment(player int, secretFragment PrivateKey, commitments []PublicKey, T int) (bool, error) {
+	defer runtime.KeepAlive(secretFragment)
+	defer runtime.KeepAlive(commitments)
+
+	if T < 1 {
+		return false, errThreshold(T)
+	}
+	if player < 1 {
+		return false, errPlayer(player)
+	}
+	if len(commitments) != T {
+		return false, &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("expected %d commitments, got %d", T, len(commitments)),
+		}
+	}
+
 	// Get a C pointer to an array of public keys
 	commitmentsPtr := C.AllocPtrArray(C.size_t(len(commitments)))
 	defer C.FreePtrArray(commitmentsPtr)
@@ -101,21 +115,38 @@ func ThresholdVerifySecretFragment(player int, secretFragment PrivateKey, commit
 		C.SetPtrArray(commitmentsPtr, unsafe.Pointer(key.pk), C.int(i))
 	}
 
+	var cErrMsg *C.char
 	val := C.CThresholdVerifySecretFragment(
 		C.size_t(player),
 		secretFragment.sk,
 		commitmentsPtr,
 		C.size_t(len(commitments)),
 		C.size_t(T),
+		&cErrMsg,
 	)
-	return bool(val)
+	if cErrMsg != nil {
+		return false, errFromC(ErrInvalidPlayers, cErrMsg)
+	}
+	return bool(val), nil
 }
 
 // ThresholdSignWithCoefficient signs a message with lagrange coefficients.
 //
 // The T signatures signed this way (with the same parameters players and T)
 // can be multiplied together to create a final signature for that message.
-func ThresholdSignWithCoefficient(sk PrivateKey, message []byte, player int, players []int, T int) InsecureSignature {
+func ThresholdSignWithCoefficient(sk PrivateKey, message []byte, player int, players []int, T int) (InsecureSignature, error) {
+	defer runtime.KeepAlive(sk)
+
+	if err := checkPlayers(players, T); err != nil {
+		return InsecureSignature{}, err
+	}
+	if !containsPlayer(players, player) {
+		return InsecureSignature{}, &Error{
+			Err: ErrInvalidPlayers,
+			Msg: fmt.Sprintf("player %d is not one of the signers", player),
+		}
+	}
+
 	// Get a C pointer to bytes
 	cMessagePtr := C.CBytes(message)
 	defer C.free(cMessagePtr)
@@ -127,18 +158,33 @@ func ThresholdSignWithCoefficient(sk PrivateKey, message []byte, player int, pla
 		C.SetIntPtrVal(cPlayersPtr, C.size_t(value), C.int(i))
 	}
 
-	var sig InsecureSignature
-	sig.sig = C.CThresholdSignWithCoefficient(sk.sk, cMessagePtr,
-		C.size_t(len(message)), C.size_t(player), cPlayersPtr, C.size_t(T))
-	runtime.SetFinalizer(&sig, func(p *InsecureSignature) { p.Free() })
+	var cErrMsg *C.char
+	cSig := C.CThresholdSignWithCoefficient(sk.sk, cMessagePtr,
+		C.size_t(len(message)), C.size_t(player), cPlayersPtr, C.size_t(T),
+		&cErrMsg)
+	if cErrMsg != nil {
+		return InsecureSignature{}, errFromC(ErrInvalidPlayers, cErrMsg)
+	}
 
-	return sig
+	return newInsecureSignature(cSig), nil
 }
 
 // ThresholdAggregateUnitSigs aggregates signatures (that have not been
 // multiplied by lagrange coefficients) into a final signature for the master
 // private key.
-func ThresholdAggregateUnitSigs(sigs []InsecureSignature, message []byte, players []int, T int) InsecureSignature {
+func ThresholdAggregateUnitSigs(sigs []InsecureSignature, message []byte, players []int, T int) (InsecureSignature, error) {
+	defer runtime.KeepAlive(sigs)
+
+	if err := checkPlayers(players, T); err != nil {
+		return InsecureSignature{}, err
+	}
+	if len(sigs) != T {
+		return InsecureSignature{}, &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("expected %d signatures, got %d", T, len(sigs)),
+		}
+	}
+
 	// Get a C pointer to bytes
 	cMessagePtr := C.CBytes(message)
 	defer C.free(cMessagePtr)
@@ -159,10 +205,66 @@ func ThresholdAggregateUnitSigs(sigs []InsecureSignature, message []byte, player
 		C.SetPtrArray(signaturesPtr, unsafe.Pointer(sig.sig), C.int(i))
 	}
 
-	var sig InsecureSignature
-	sig.sig = C.CThresholdAggregateUnitSigs(signaturesPtr, C.size_t(len(sigs)),
-		cMessagePtr, C.size_t(len(message)), cPlayersPtr, C.size_t(T))
-	runtime.SetFinalizer(&sig, func(p *InsecureSignature) { p.Free() })
+	var cErrMsg *C.char
+	cSig := C.CThresholdAggregateUnitSigs(signaturesPtr, C.size_t(len(sigs)),
+		cMessagePtr, C.size_t(len(message)), cPlayersPtr, C.size_t(T),
+		&cErrMsg)
+	if cErrMsg != nil {
+		return InsecureSignature{}, errFromC(ErrInvalidPlayers, cErrMsg)
+	}
+
+	return newInsecureSignature(cSig), nil
+}
 
-	return sig
+// checkPlayers makes sure players holds exactly T distinct, positive player
+// indices, as the C++ library doesn't check this before indexing into them.
+func checkPlayers(players []int, T int) error {
+	if T < 1 {
+		return errThreshold(T)
+	}
+	if len(players) != T {
+		return &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("expected %d players, got %d", T, len(players)),
+		}
+	}
+
+	seen := make(map[int]bool, len(players))
+	for _, player := range players {
+		if player < 1 {
+			return errPlayer(player)
+		}
+		if seen[player] {
+			return &Error{
+				Err: ErrInvalidPlayers,
+				Msg: fmt.Sprintf("duplicate player index %d", player),
+			}
+		}
+		seen[player] = true
+	}
+
+	return nil
+}
+
+func containsPlayer(players []int, player int) bool {
+	for _, p := range players {
+		if p == player {
+			return true
+		}
+	}
+	return false
+}
+
+func errThreshold(T int) error {
+	return &Error{
+		Err: ErrInvalidThreshold,
+		Msg: fmt.Sprintf("T must be a positive integer, got %d", T),
+	}
+}
+
+func errPlayer(player int) error {
+	return &Error{
+		Err: ErrInvalidPlayers,
+		Msg: fmt.Sprintf("player index must be positive, got %d", player),
+	}
 }
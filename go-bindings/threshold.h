@@ -26,18 +26,19 @@ typedef void* CBigNum;
 CPrivateKey CThresholdCreate(void **commitments, void **secretFragments,
     size_t T, size_t N);
 
-void** CThresholdLagrangeCoeffsAtZero(size_t *players, size_t T);
+uint8_t* CThresholdLagrangeCoeffsAtZero(size_t *players, size_t T,
+    char **errMsg);
 
 void* CThresholdInterpolateAtZero(size_t *X, CBigNum *Y, size_t T);
 
 bool CThresholdVerifySecretFragment(size_t player, CPrivateKey secretFragment,
-    void **commitments, size_t numCommitments, size_t T);
+    void **commitments, size_t numCommitments, size_t T, char **errMsg);
 
 CInsecureSignature CThresholdSignWithCoefficient(CPrivateKey skPtr, void *msg,
-    size_t len, size_t player, size_t *players, size_t T);
+    size_t len, size_t player, size_t *players, size_t T, char **errMsg);
 
 CInsecureSignature CThresholdAggregateUnitSigs(void **sigs, size_t numSigs,
-    void *msg, size_t len, size_t *players, size_t T);
+    void *msg, size_t len, size_t *players, size_t T, char **errMsg);
 
 
 // C helper funcs
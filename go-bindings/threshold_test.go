@@ -1,6 +1,7 @@
 package blschia_test
 
 import (
+	"errors"
 	"testing"
 
 	bls "github.com/nmarley/bls-signatures/go-bindings"
@@ -18,7 +19,10 @@ func TestThreshold(t *testing.T) {
 
 	// Step 1 : ThresholdCreate
 	for player := 0; player < N; player++ {
-		sk, commits, frags := bls.ThresholdCreate(T, N)
+		sk, commits, frags, err := bls.ThresholdCreate(T, N)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err.Error())
+		}
 		for j, frag := range frags {
 			fragments[j] = append(fragments[j], frag)
 		}
@@ -29,13 +33,15 @@ func TestThreshold(t *testing.T) {
 	// Step 2 : ThresholdVerifySecretFragment
 	for source := 1; source <= N; source++ {
 		for target := 1; target <= N; target++ {
-			didVerify := bls.ThresholdVerifySecretFragment(
+			didVerify, err := bls.ThresholdVerifySecretFragment(
 				target,
 				fragments[target-1][source-1],
 				commitments[source-1],
 				T,
 			)
-
+			if err != nil {
+				t.Errorf("got unexpected error: %v", err.Error())
+			}
 			if !didVerify {
 				t.Error("threshold fragment did not verify")
 			}
@@ -85,8 +91,14 @@ func TestThreshold(t *testing.T) {
 	players := []int{1, 3}
 	// As we have verified the coefficients through the commitments given,
 	// using InsecureSignature is okay.
-	sigShareC1 := bls.ThresholdSignWithCoefficient(secretShares[0], msg, 1, players, T)
-	sigShareC3 := bls.ThresholdSignWithCoefficient(secretShares[2], msg, 3, players, T)
+	sigShareC1, err := bls.ThresholdSignWithCoefficient(secretShares[0], msg, 1, players, T)
+	if err != nil {
+		t.Errorf("got unexpected error: %v", err.Error())
+	}
+	sigShareC3, err := bls.ThresholdSignWithCoefficient(secretShares[2], msg, 3, players, T)
+	if err != nil {
+		t.Errorf("got unexpected error: %v", err.Error())
+	}
 	signature, _ := bls.InsecureSignatureAggregate([]bls.InsecureSignature{
 		sigShareC1, sigShareC3,
 	})
@@ -100,13 +112,111 @@ func TestThreshold(t *testing.T) {
 	// players 1 and 3 sign
 	sigShareU1 := secretShares[0].SignInsecure(msg)
 	sigShareU3 := secretShares[2].SignInsecure(msg)
-	signature2 := bls.ThresholdAggregateUnitSigs(
+	signature2, err := bls.ThresholdAggregateUnitSigs(
 		[]bls.InsecureSignature{sigShareU1, sigShareU3},
 		msg,
 		players,
 		T,
 	)
+	if err != nil {
+		t.Errorf("got unexpected error: %v", err.Error())
+	}
 	if !signature2.Verify([][]byte{hash}, []bls.PublicKey{masterPubKey}) {
 		t.Error("signature2 did not verify")
 	}
 }
+
+func TestThresholdLagrangeCoeffsAtZero(t *testing.T) {
+	// For players 1 and 2, P(0) = 2*P(1) - P(2)
+	expected := []bls.Fr{
+		bls.FrFromUint64(2),
+		bls.FrFromUint64(1).Neg(),
+	}
+
+	coeffs, err := bls.ThresholdLagrangeCoeffsAtZero([]int{1, 2}, 2)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err.Error())
+	}
+	if len(coeffs) != len(expected) {
+		t.Fatalf("got %v, expected %v", coeffs, expected)
+	}
+	for i := range coeffs {
+		if coeffs[i] != expected[i] {
+			t.Errorf("got %x, expected %x", coeffs[i].Serialize(), expected[i].Serialize())
+		}
+	}
+}
+
+func TestThresholdInvalidParams(t *testing.T) {
+	for _, tc := range []struct{ T, N int }{{0, 3}, {4, 3}, {-1, 3}, {1, 0}} {
+		_, _, _, err := bls.ThresholdCreate(tc.T, tc.N)
+		if !errors.Is(err, bls.ErrInvalidThreshold) {
+			t.Errorf("T=%d, N=%d: got %v, expected %v", tc.T, tc.N, err, bls.ErrInvalidThreshold)
+		}
+	}
+
+	sk, commitments, fragments, _ := bls.ThresholdCreate(2, 3)
+	msg := []byte{1, 2, 3}
+
+	for _, tc := range []struct {
+		players []int
+		T       int
+		target  error
+	}{
+		{[]int{1, 2}, 0, bls.ErrInvalidThreshold},
+		{[]int{1, 2, 3}, 2, bls.ErrLengthMismatch},
+		{[]int{1}, 2, bls.ErrLengthMismatch},
+		{[]int{1, 1}, 2, bls.ErrInvalidPlayers},
+		{[]int{0, 1}, 2, bls.ErrInvalidPlayers},
+		{[]int{-1, 1}, 2, bls.ErrInvalidPlayers},
+	} {
+		_, err := bls.ThresholdLagrangeCoeffsAtZero(tc.players, tc.T)
+		if !errors.Is(err, tc.target) {
+			t.Errorf("%v, T=%d: got %v, expected %v", tc.players, tc.T, err, tc.target)
+		}
+		_, err = bls.ThresholdSignWithCoefficient(fragments[0], msg, 1, tc.players, tc.T)
+		if !errors.Is(err, tc.target) {
+			t.Errorf("%v, T=%d: got %v, expected %v", tc.players, tc.T, err, tc.target)
+		}
+		sigs := []bls.InsecureSignature{fragments[0].SignInsecure(msg), fragments[1].SignInsecure(msg)}
+		_, err = bls.ThresholdAggregateUnitSigs(sigs, msg, tc.players, tc.T)
+		if !errors.Is(err, tc.target) {
+			t.Errorf("%v, T=%d: got %v, expected %v", tc.players, tc.T, err, tc.target)
+		}
+	}
+
+	// The signing player must be one of the players
+	_, err := bls.ThresholdSignWithCoefficient(fragments[2], msg, 3, []int{1, 2}, 2)
+	if !errors.Is(err, bls.ErrInvalidPlayers) {
+		t.Errorf("got %v, expected %v", err, bls.ErrInvalidPlayers)
+	}
+
+	// There must be one signature per player
+	sig := fragments[0].SignInsecure(msg)
+	_, err = bls.ThresholdAggregateUnitSigs([]bls.InsecureSignature{sig}, msg, []int{1, 2}, 2)
+	if !errors.Is(err, bls.ErrLengthMismatch) {
+		t.Errorf("got %v, expected %v", err, bls.ErrLengthMismatch)
+	}
+
+	_, err = bls.ThresholdVerifySecretFragment(0, fragments[0], commitments, 2)
+	if !errors.Is(err, bls.ErrInvalidPlayers) {
+		t.Errorf("got %v, expected %v", err, bls.ErrInvalidPlayers)
+	}
+	_, err = bls.ThresholdVerifySecretFragment(1, fragments[0], commitments[:1], 2)
+	if !errors.Is(err, bls.ErrLengthMismatch) {
+		t.Errorf("got %v, expected %v", err, bls.ErrLengthMismatch)
+	}
+	_, err = bls.ThresholdVerifySecretFragment(1, fragments[0], commitments, 0)
+	if !errors.Is(err, bls.ErrInvalidThreshold) {
+		t.Errorf("got %v, expected %v", err, bls.ErrInvalidThreshold)
+	}
+
+	sig.Free()
+	for _, frag := range fragments {
+		frag.Free()
+	}
+	for _, commitment := range commitments {
+		commitment.Free()
+	}
+	sk.Free()
+}
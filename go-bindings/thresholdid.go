@@ -0,0 +1,184 @@
+package blschia
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// The ID-based threshold functions identify the members of a group by
+// arbitrary 32-byte IDs, such as the hash of their registration transaction,
+// instead of the indices 1..N used by ThresholdCreate and
+// ThresholdSignWithCoefficient. The share of a member is the secret
+// polynomial evaluated at the SHA-256 hash of their ID reduced mod r, so
+// members can join or leave without renumbering the others.
+
+// ThresholdIDSize is the size in bytes of the IDs of the ID-based threshold
+// functions
+const ThresholdIDSize = 32
+
+// ThresholdPrivateKeyShare returns the private key share of the member with
+// the given ID. sks holds the coefficients of the secret polynomial, starting
+// with the master private key, so T is len(sks).
+func ThresholdPrivateKeyShare(sks []PrivateKey, id []byte) (PrivateKey, error) {
+	if len(sks) == 0 {
+		return PrivateKey{}, errThreshold(0)
+	}
+	x, err := thresholdIDScalar(id)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+
+	// Horner's method
+	var share Fr
+	for i := len(sks) - 1; i >= 0; i-- {
+		share = share.Mul(x).Add(sks[i].Fr())
+	}
+	return PrivateKeyFromFr(share), nil
+}
+
+// ThresholdPublicKeyShare returns the public key share of the member with the
+// given ID, which matches its private key share. pks holds the commitments to
+// the coefficients of the secret polynomial, starting with the master public
+// key.
+func ThresholdPublicKeyShare(pks []PublicKey, id []byte) (PublicKey, error) {
+	if len(pks) == 0 {
+		return PublicKey{}, errThreshold(0)
+	}
+	x, err := thresholdIDScalar(id)
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	points := make([]G1Element, len(pks))
+	powers := make([]Fr, len(pks))
+	power := FrFromUint64(1)
+	for i, pk := range pks {
+		points[i] = pk.G1Element()
+		powers[i] = power
+		power = power.Mul(x)
+	}
+	share, err := G1MultiScalarMul(points, powers)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return share.PublicKey(), nil
+}
+
+// ThresholdPrivateKeyRecover recovers the master private key from the private
+// key shares of T members, where sks[i] is the share of ids[i].
+func ThresholdPrivateKeyRecover(sks []PrivateKey, ids [][]byte) (PrivateKey, error) {
+	coeffs, err := thresholdIDCoeffs(len(sks), ids)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+
+	var sk Fr
+	for i, share := range sks {
+		sk = sk.Add(share.Fr().Mul(coeffs[i]))
+	}
+	return PrivateKeyFromFr(sk), nil
+}
+
+// ThresholdPublicKeyRecover recovers the master public key from the public
+// key shares of T members, where pks[i] is the share of ids[i].
+func ThresholdPublicKeyRecover(pks []PublicKey, ids [][]byte) (PublicKey, error) {
+	coeffs, err := thresholdIDCoeffs(len(pks), ids)
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	points := make([]G1Element, len(pks))
+	for i, pk := range pks {
+		points[i] = pk.G1Element()
+	}
+	pk, err := G1MultiScalarMul(points, coeffs)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return pk.PublicKey(), nil
+}
+
+// ThresholdSignatureRecover recovers the signature of the master private key
+// from the signatures of T members on the same message, where sigs[i] was
+// made by the private key share of ids[i] with SignInsecure.
+func ThresholdSignatureRecover(sigs []InsecureSignature, ids [][]byte) (InsecureSignature, error) {
+	coeffs, err := thresholdIDCoeffs(len(sigs), ids)
+	if err != nil {
+		return InsecureSignature{}, err
+	}
+
+	points := make([]G2Element, len(sigs))
+	for i, sig := range sigs {
+		if points[i], err = sig.G2Element(); err != nil {
+			return InsecureSignature{}, err
+		}
+	}
+	sig, err := G2MultiScalarMul(points, coeffs)
+	if err != nil {
+		return InsecureSignature{}, err
+	}
+	return sig.InsecureSignature(), nil
+}
+
+// thresholdIDScalar returns the point at which the share of id is evaluated
+func thresholdIDScalar(id []byte) (Fr, error) {
+	if err := checkLength("ID", id, ThresholdIDSize); err != nil {
+		return Fr{}, err
+	}
+	hash := sha256.Sum256(id)
+	x := frFromWideBytes(hash[:])
+	if x.IsZero() {
+		// This has a negligible probability, but the share would be the
+		// master private key
+		return Fr{}, &Error{
+			Err: ErrInvalidPlayers,
+			Msg: fmt.Sprintf("ID %x maps to zero", id),
+		}
+	}
+	return x, nil
+}
+
+// thresholdIDCoeffs returns the Lagrange coefficients at zero for the points
+// of ids, after checking that there are n distinct ones.
+func thresholdIDCoeffs(n int, ids [][]byte) ([]Fr, error) {
+	if len(ids) == 0 {
+		return nil, errThreshold(0)
+	}
+	if n != len(ids) {
+		return nil, &Error{
+			Err: ErrLengthMismatch,
+			Msg: fmt.Sprintf("expected %d shares, got %d", len(ids), n),
+		}
+	}
+
+	xs := make([]Fr, len(ids))
+	seen := make(map[Fr]bool, len(ids))
+	for i, id := range ids {
+		x, err := thresholdIDScalar(id)
+		if err != nil {
+			return nil, err
+		}
+		if seen[x] {
+			return nil, &Error{
+				Err: ErrInvalidPlayers,
+				Msg: fmt.Sprintf("duplicate ID %x", id),
+			}
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	// coeffs[i] is the product of x_j / (x_j - x_i) for all j != i
+	coeffs := make([]Fr, len(xs))
+	for i := range xs {
+		num, den := FrFromUint64(1), FrFromUint64(1)
+		for j := range xs {
+			if j != i {
+				num = num.Mul(xs[j])
+				den = den.Mul(xs[j].Sub(xs[i]))
+			}
+		}
+		coeffs[i] = num.Mul(den.Inverse())
+	}
+	return coeffs, nil
+}
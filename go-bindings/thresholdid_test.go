@@ -0,0 +1,114 @@
+package blschia_test
+
+import (
+	"bytes"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestThresholdID(t *testing.T) {
+	T := 3
+	N := 5
+
+	sks := make([]bls.PrivateKey, T)
+	pks := make([]bls.PublicKey, T)
+	for i := range sks {
+		sks[i] = bls.PrivateKeyFromSeed([]byte{byte(i), 1, 2, 3})
+		pks[i] = sks[i].PublicKey()
+	}
+
+	ids := make([][]byte, N)
+	skShares := make([]bls.PrivateKey, N)
+	pkShares := make([]bls.PublicKey, N)
+	for i := range ids {
+		ids[i] = Sha256([]byte{byte(i)})
+		sk, err := bls.ThresholdPrivateKeyShare(sks, ids[i])
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		pk, err := bls.ThresholdPublicKeyShare(pks, ids[i])
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if got := sk.PublicKey(); !got.Equal(pk) {
+			t.Errorf("got %x, expected %x", got.Serialize(), pk.Serialize())
+		}
+		skShares[i] = sk
+		pkShares[i] = pk
+	}
+
+	msg := []byte{1, 2, 3}
+	expectedSig := sks[0].SignInsecure(msg)
+
+	// Any T members can recover the master keys and signature, in any order
+	for _, members := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}} {
+		var memberIDs [][]byte
+		var memberSks []bls.PrivateKey
+		var memberPks []bls.PublicKey
+		var memberSigs []bls.InsecureSignature
+		for _, i := range members {
+			memberIDs = append(memberIDs, ids[i])
+			memberSks = append(memberSks, skShares[i])
+			memberPks = append(memberPks, pkShares[i])
+			memberSigs = append(memberSigs, skShares[i].SignInsecure(msg))
+		}
+
+		sk, err := bls.ThresholdPrivateKeyRecover(memberSks, memberIDs)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if !sk.Equal(sks[0]) {
+			t.Errorf("members %v: got %x, expected %x", members, sk.Serialize(), sks[0].Serialize())
+		}
+
+		pk, err := bls.ThresholdPublicKeyRecover(memberPks, memberIDs)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if !pk.Equal(pks[0]) {
+			t.Errorf("members %v: got %x, expected %x", members, pk.Serialize(), pks[0].Serialize())
+		}
+
+		sig, err := bls.ThresholdSignatureRecover(memberSigs, memberIDs)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if !sig.Equal(expectedSig) {
+			t.Errorf("members %v: got %x, expected %x", members, sig.Serialize(), expectedSig.Serialize())
+		}
+		if !sig.Verify([][]byte{Sha256(msg)}, []bls.PublicKey{pks[0]}) {
+			t.Errorf("members %v: signature did not verify", members)
+		}
+	}
+
+	// T-1 members can't
+	sk, err := bls.ThresholdPrivateKeyRecover(skShares[:T-1], ids[:T-1])
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if sk.Equal(sks[0]) {
+		t.Error("T-1 shares should not recover the master private key")
+	}
+}
+
+func TestThresholdIDInvalid(t *testing.T) {
+	sk, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	pk, _ := bls.PublicKeyFromBytes(pk1Bytes)
+	id1 := Sha256([]byte{1})
+	id2 := Sha256([]byte{2})
+
+	_, err := bls.ThresholdPrivateKeyShare(nil, id1)
+	expectError(t, err, bls.ErrInvalidThreshold)
+	_, err = bls.ThresholdPublicKeyShare([]bls.PublicKey{pk}, id1[:31])
+	expectError(t, err, bls.ErrLengthMismatch)
+
+	_, err = bls.ThresholdPrivateKeyRecover(nil, nil)
+	expectError(t, err, bls.ErrInvalidThreshold)
+	_, err = bls.ThresholdPrivateKeyRecover([]bls.PrivateKey{sk}, [][]byte{id1, id2})
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.ThresholdPublicKeyRecover([]bls.PublicKey{pk, pk}, [][]byte{id1, bytes.Repeat(id1[:1], 33)})
+	expectError(t, err, bls.ErrLengthMismatch)
+	_, err = bls.ThresholdSignatureRecover([]bls.InsecureSignature{sk.SignInsecure(payload), sk.SignInsecure(payload)}, [][]byte{id1, id1})
+	expectError(t, err, bls.ErrInvalidPlayers)
+}
@@ -2,6 +2,7 @@ package blschia_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"testing"
 
@@ -109,6 +110,55 @@ func TestVectorSignaturesVerify(t *testing.T) {
 	}
 }
 
+// Implement test for test vectors for Signatures#aggregate
+func TestVectorSignaturesAggregate(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sk2, _ := bls.PrivateKeyFromBytes(sk2Bytes, false)
+
+	aggregate := func(sigs ...bls.Signature) bls.Signature {
+		t.Helper()
+		agg, err := bls.SignatureAggregate(sigs)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !agg.Verify() {
+			t.Error("aggregate did not verify")
+		}
+		return agg
+	}
+	check := func(sig bls.Signature, expected string) {
+		t.Helper()
+		if got := hex.EncodeToString(sig.Serialize()); got != expected {
+			t.Errorf("got %s, expected %s", got, expected)
+		}
+	}
+
+	// Both keys sign the same message, so the signatures are aggregated
+	// securely
+	aggSig := aggregate(sk1.Sign(payload), sk2.Sign(payload))
+	check(aggSig, "0a638495c1403b25be391ed44c0ab013390026b5892c796a85ede46310ff7d0e"+
+		"0671f86ebe0e8f56bee80f28eb6d999c0a418c5fc52debac8fc338784cd32b76"+
+		"338d629dc2b4045a5833a357809795ef55ee3e9bee532edfc1d9c443bf5bc658")
+
+	// Distinct messages are aggregated simply
+	aggSig2 := aggregate(sk1.Sign([]byte{1, 2, 3}), sk1.Sign([]byte{1, 2, 3, 4}), sk2.Sign([]byte{1, 2}))
+	check(aggSig2, "8b11daf73cd05f2fe27809b74a7b4c65b1bb79cc1066bdf839d96b97e073c1a6"+
+		"35d2ec048e0801b4a208118fdbbb63a516bab8755cc8d850862eeaa099540cd8"+
+		"3621ff9db97b4ada857ef54c50715486217bd2ecb4517e05ab49380c041e159b")
+
+	// Aggregates of aggregates
+	sigL := aggregate(sk1.Sign([]byte{1, 2, 3, 40}), sk2.Sign([]byte{5, 6, 70, 201}))
+	sigR := aggregate(sk2.Sign([]byte{1, 2, 3, 40}), sk1.Sign([]byte{9, 10, 11, 12, 13}),
+		sk1.Sign([]byte{1, 2, 3, 40}))
+	sigFinal := aggregate(sigL, sigR, sk1.Sign([]byte{15, 63, 244, 92, 0, 1}))
+	check(sigFinal, "07969958fbf82e65bd13ba0749990764cac81cf10d923af9fdd2723f1e3910c3"+
+		"fdb874a67f9d511bb7e4920f8c01232b12e2fb5e64a7c2d177a475dab5c3729c"+
+		"a1f580301ccdef809c57a8846890265d195b694fa414a2a3aa55c32837fddd80")
+
+	sk1.Free()
+	sk2.Free()
+}
+
 // Implement test for test vector for HDKeys
 func TestVectorHDKeys(t *testing.T) {
 	seed := []byte{1, 50, 6, 244, 24, 199, 1, 25}
@@ -406,6 +456,88 @@ func TestVectorAggregation2(t *testing.T) {
 	}
 }
 
+// Implement test for test vector for Prepend Signatures
+func TestVectorPrependSignatures(t *testing.T) {
+	m1 := []byte{7, 8, 9}
+	m2 := []byte{10, 11, 12}
+
+	sk1 := bls.PrivateKeyFromSeed([]byte{1, 2, 3, 4, 5})
+	sk2 := bls.PrivateKeyFromSeed([]byte{1, 2, 3, 4, 5, 6})
+
+	sig9 := sk1.SignPrepend(m1)
+	sig9Bytes := sig9.Serialize()
+	sig9Expected := []byte{
+		0xd2, 0x13, 0x5a, 0xd3, 0x58, 0x40, 0x5d, 0x9f,
+		0x2d, 0x4e, 0x68, 0xdc, 0x25, 0x3d, 0x64, 0xb6,
+		0x04, 0x9a, 0x82, 0x17, 0x97, 0x81, 0x7c, 0xff,
+		0xa5, 0xaa, 0x80, 0x40, 0x86, 0xa8, 0xfb, 0x7b,
+		0x13, 0x51, 0x75, 0xbb, 0x71, 0x83, 0x75, 0x0e,
+		0x3a, 0xa1, 0x95, 0x13, 0xdb, 0x15, 0x52, 0x18,
+		0x0f, 0x0b, 0x0f, 0xfd, 0x51, 0x3c, 0x32, 0x2f,
+		0x1c, 0x0c, 0x30, 0xa0, 0xa9, 0xc1, 0x79, 0xf6,
+		0xe2, 0x75, 0xe0, 0x10, 0x9d, 0x4d, 0xb7, 0xfa,
+		0x3e, 0x09, 0x69, 0x41, 0x90, 0x94, 0x7b, 0x17,
+		0xd8, 0x90, 0xf3, 0xd5, 0x8f, 0xe0, 0xb1, 0x86,
+		0x6e, 0xc4, 0xd4, 0xf5, 0xa5, 0x9b, 0x16, 0xed,
+	}
+	if !bytes.Equal(sig9Bytes, sig9Expected) {
+		t.Errorf("got %v, expected %v", sig9Bytes, sig9Expected)
+	}
+
+	sig10 := sk2.SignPrepend(m2)
+	sig10Bytes := sig10.Serialize()
+	sig10Expected := []byte{
+		0xcc, 0x58, 0xc9, 0x82, 0xf9, 0xee, 0x58, 0x17,
+		0xd4, 0xfb, 0xf2, 0x2d, 0x52, 0x9c, 0xfc, 0x67,
+		0x92, 0xb0, 0xfd, 0xcf, 0x2d, 0x2a, 0x80, 0x01,
+		0x68, 0x67, 0x55, 0x86, 0x8e, 0x10, 0xeb, 0x32,
+		0xb4, 0x0e, 0x46, 0x4e, 0x7f, 0xbf, 0xe3, 0x01,
+		0x75, 0xa9, 0x62, 0xf1, 0x97, 0x20, 0x26, 0xf2,
+		0x08, 0x7f, 0x04, 0x95, 0xba, 0x6e, 0x29, 0x3a,
+		0xc3, 0xcf, 0x27, 0x17, 0x62, 0xcd, 0x69, 0x79,
+		0xb9, 0x41, 0x3a, 0xdc, 0x0b, 0xa7, 0xdf, 0x15,
+		0x3c, 0xf1, 0xf3, 0xfa, 0xab, 0x6b, 0x89, 0x34,
+		0x04, 0xc2, 0xe6, 0xd6, 0x33, 0x51, 0xe4, 0x8c,
+		0xd5, 0x4e, 0x06, 0xe4, 0x49, 0x96, 0x5f, 0x08,
+	}
+	if !bytes.Equal(sig10Bytes, sig10Expected) {
+		t.Errorf("got %v, expected %v", sig10Bytes, sig10Expected)
+	}
+
+	aggSig, err := bls.PrependSignatureAggregate([]bls.PrependSignature{sig9, sig9, sig10})
+	if err != nil {
+		t.Errorf("got unexpected error: %v", err.Error())
+	}
+	aggSigBytes := aggSig.Serialize()
+	aggSigExpected := []byte{
+		0xc3, 0x70, 0x77, 0x68, 0x4e, 0x73, 0x5e, 0x62,
+		0xe3, 0xf1, 0xfd, 0x17, 0x77, 0x2a, 0x23, 0x6b,
+		0x41, 0x15, 0xd4, 0xb5, 0x81, 0x38, 0x77, 0x33,
+		0xd3, 0xb9, 0x7c, 0xab, 0x08, 0xb9, 0x09, 0x18,
+		0xc7, 0xe9, 0x1c, 0x23, 0x38, 0x0c, 0x93, 0xe5,
+		0x4b, 0xe3, 0x45, 0x54, 0x40, 0x26, 0xf9, 0x35,
+		0x05, 0xd4, 0x1e, 0x60, 0x00, 0x39, 0x2b, 0x82,
+		0xab, 0x3c, 0x8a, 0xf1, 0xb2, 0xe3, 0x95, 0x4b,
+		0x0e, 0xf3, 0xf6, 0x2c, 0x52, 0xfc, 0x89, 0xf9,
+		0x9e, 0x64, 0x6f, 0xf5, 0x46, 0x88, 0x11, 0x20,
+		0x39, 0x6c, 0x44, 0x98, 0x56, 0x42, 0x8e, 0x67,
+		0x21, 0x78, 0xe5, 0xe0, 0xe1, 0x4e, 0xc8, 0x94,
+	}
+	if !bytes.Equal(aggSigBytes, aggSigExpected) {
+		t.Errorf("got %v, expected %v", aggSigBytes, aggSigExpected)
+	}
+
+	pk1 := sk1.PublicKey()
+	pk2 := sk2.PublicKey()
+	hashes := [][]byte{Sha256(m1), Sha256(m1), Sha256(m2)}
+	if !aggSig.Verify(hashes, []bls.PublicKey{pk1, pk1, pk2}) {
+		t.Errorf("aggSig did not verify")
+	}
+	if aggSig.Verify(hashes, []bls.PublicKey{pk1, pk2, pk2}) {
+		t.Errorf("aggSig should not have verified")
+	}
+}
+
 // Values either defined in or derived from test vectors and re-used multiple
 // times
 var sk1Bytes = []byte{
@@ -0,0 +1,191 @@
+package blschia
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of an asynchronous verification
+type Result struct {
+	// Valid reports whether the signature verified
+	Valid bool
+	// Err is set when the signature was not verified at all, because the
+	// context was done or the Verifier was closed
+	Err error
+}
+
+// VerifierMetrics is a snapshot of the state of a Verifier
+type VerifierMetrics struct {
+	// Workers is the number of worker goroutines
+	Workers int
+	// QueueDepth is the number of verifications waiting for a worker
+	QueueDepth int
+	// InFlight is the number of verifications being performed
+	InFlight int
+	// Completed is the number of verifications performed
+	Completed uint64
+	// Cancelled is the number of queued verifications which were dropped
+	// because their context was done before a worker picked them up
+	Cancelled uint64
+	// QueueTime is the total time completed verifications spent queued
+	QueueTime time.Duration
+	// VerifyTime is the total time spent performing verifications
+	VerifyTime time.Duration
+}
+
+// AverageLatency returns the mean time from queueing a verification to its
+// result, or zero if none were completed.
+func (m VerifierMetrics) AverageLatency() time.Duration {
+	if m.Completed == 0 {
+		return 0
+	}
+	return (m.QueueTime + m.VerifyTime) / time.Duration(m.Completed)
+}
+
+// Verifier verifies signatures on a fixed pool of worker goroutines. Pairing
+// checks are CPU bound and each one holds an OS thread for its cgo call, so
+// bounding the number of workers bounds the number of threads.
+type Verifier struct {
+	workers int
+	jobs    chan *verifyJob
+	wg      sync.WaitGroup
+
+	// mu guards closed, and is held for reading while sending to jobs so
+	// that Close can't close the channel under a sender
+	mu     sync.RWMutex
+	closed bool
+
+	metricsMu sync.Mutex
+	metrics   VerifierMetrics
+}
+
+type verifyJob struct {
+	ctx      context.Context
+	verify   func() bool
+	result   chan Result
+	enqueued time.Time
+}
+
+// NewVerifier starts a Verifier with the given number of workers, which
+// defaults to the number of CPUs when not positive. Up to queueSize
+// verifications may wait for a worker before VerifyAsync blocks.
+func NewVerifier(workers, queueSize int) *Verifier {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	v := &Verifier{
+		workers: workers,
+		jobs:    make(chan *verifyJob, queueSize),
+	}
+	v.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go v.work()
+	}
+	return v
+}
+
+// VerifyAsync queues sig to be verified against its aggregation info. The
+// returned channel receives exactly one Result and is then closed.
+//
+// If the queue is full, VerifyAsync blocks until there is room or ctx is
+// done. Verifications whose ctx is done before a worker picks them up are
+// dropped, and their Result holds the context's error.
+func (v *Verifier) VerifyAsync(ctx context.Context, sig Signature) <-chan Result {
+	return v.submit(ctx, sig.Verify)
+}
+
+// VerifyInsecureAsync queues sig to be verified against the message hashes
+// and public keys, like InsecureSignature.Verify. It behaves like
+// VerifyAsync otherwise.
+func (v *Verifier) VerifyInsecureAsync(ctx context.Context, sig InsecureSignature, hashes [][]byte, publicKeys []PublicKey) <-chan Result {
+	return v.submit(ctx, func() bool {
+		return sig.Verify(hashes, publicKeys)
+	})
+}
+
+// Close stops accepting verifications and waits for the queued ones to
+// complete. Later calls to VerifyAsync return ErrVerifierClosed. Calling Close
+// again is a no-op.
+func (v *Verifier) Close() {
+	v.mu.Lock()
+	if !v.closed {
+		v.closed = true
+		close(v.jobs)
+	}
+	v.mu.Unlock()
+	v.wg.Wait()
+}
+
+// Metrics returns a snapshot of the Verifier's metrics
+func (v *Verifier) Metrics() VerifierMetrics {
+	v.metricsMu.Lock()
+	defer v.metricsMu.Unlock()
+	m := v.metrics
+	m.Workers = v.workers
+	m.QueueDepth = len(v.jobs)
+	return m
+}
+
+func (v *Verifier) submit(ctx context.Context, verify func() bool) <-chan Result {
+	job := &verifyJob{
+		ctx:      ctx,
+		verify:   verify,
+		result:   make(chan Result, 1),
+		enqueued: time.Now(),
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.closed {
+		job.finish(Result{Err: ErrVerifierClosed})
+		return job.result
+	}
+	select {
+	case v.jobs <- job:
+	case <-ctx.Done():
+		job.finish(Result{Err: ctx.Err()})
+	}
+	return job.result
+}
+
+func (v *Verifier) work() {
+	defer v.wg.Done()
+	for job := range v.jobs {
+		if err := job.ctx.Err(); err != nil {
+			v.metricsMu.Lock()
+			v.metrics.Cancelled++
+			v.metricsMu.Unlock()
+			job.finish(Result{Err: err})
+			continue
+		}
+
+		v.metricsMu.Lock()
+		v.metrics.InFlight++
+		v.metricsMu.Unlock()
+
+		start := time.Now()
+		valid := job.verify()
+		end := time.Now()
+
+		v.metricsMu.Lock()
+		v.metrics.InFlight--
+		v.metrics.Completed++
+		v.metrics.QueueTime += start.Sub(job.enqueued)
+		v.metrics.VerifyTime += end.Sub(start)
+		v.metricsMu.Unlock()
+
+		job.finish(Result{Valid: valid})
+	}
+}
+
+func (job *verifyJob) finish(result Result) {
+	job.result <- result
+	close(job.result)
+}
@@ -0,0 +1,134 @@
+package blschia_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bls "github.com/nmarley/bls-signatures/go-bindings"
+)
+
+func TestVerifier(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	pk1 := sk1.PublicKey()
+	pk2, _ := bls.PublicKeyFromBytes(pk2Bytes)
+
+	v := bls.NewVerifier(4, 16)
+	defer v.Close()
+
+	const numSigs = 32
+	results := make([]<-chan bls.Result, numSigs)
+	for i := range results {
+		msg := []byte{byte(i)}
+		if i%2 == 0 {
+			results[i] = v.VerifyAsync(context.Background(), sk1.Sign(msg))
+		} else {
+			// Odd signatures are checked against the wrong key
+			results[i] = v.VerifyInsecureAsync(context.Background(),
+				sk1.SignInsecure(msg), [][]byte{Sha256(msg)}, []bls.PublicKey{pk2})
+		}
+	}
+	for i, ch := range results {
+		res := <-ch
+		if res.Err != nil {
+			t.Fatalf("got unexpected error: %v", res.Err)
+		}
+		if res.Valid != (i%2 == 0) {
+			t.Errorf("signature %d: got %v, expected %v", i, res.Valid, i%2 == 0)
+		}
+		if _, ok := <-ch; ok {
+			t.Error("expected the result channel to be closed")
+		}
+	}
+
+	m := v.Metrics()
+	if m.Workers != 4 {
+		t.Errorf("got %d workers, expected 4", m.Workers)
+	}
+	if m.Completed != numSigs || m.QueueDepth != 0 || m.InFlight != 0 {
+		t.Errorf("got %+v, expected %d completed and nothing pending", m, numSigs)
+	}
+	if m.AverageLatency() <= 0 {
+		t.Errorf("got average latency %v, expected it to be positive", m.AverageLatency())
+	}
+
+	insecureSig := sk1.SignInsecure(payload)
+	res := <-v.VerifyInsecureAsync(context.Background(), insecureSig,
+		[][]byte{Sha256(payload)}, []bls.PublicKey{pk1})
+	if !res.Valid {
+		t.Error("insecureSig should verify")
+	}
+
+	sk1.Free()
+}
+
+func TestVerifierCancel(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sig := sk1.Sign(payload)
+
+	v := bls.NewVerifier(1, 64)
+	defer v.Close()
+
+	// Already cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res := <-v.VerifyAsync(ctx, sig)
+	if !errors.Is(res.Err, context.Canceled) || res.Valid {
+		t.Errorf("got %+v, expected context.Canceled", res)
+	}
+
+	// Cancelled while queued behind the first verification
+	ctx, cancel = context.WithCancel(context.Background())
+	results := make([]<-chan bls.Result, 32)
+	for i := range results {
+		results[i] = v.VerifyAsync(ctx, sig)
+	}
+	<-results[0]
+	cancel()
+
+	numCancelled := 0
+	for _, ch := range results[1:] {
+		res := <-ch
+		if res.Err != nil {
+			if !errors.Is(res.Err, context.Canceled) {
+				t.Errorf("got %v, expected context.Canceled", res.Err)
+			}
+			numCancelled++
+		} else if !res.Valid {
+			t.Error("sig should verify")
+		}
+	}
+	if numCancelled == 0 {
+		t.Error("expected queued verifications to be cancelled")
+	}
+	if m := v.Metrics(); m.Cancelled == 0 || m.Completed+m.Cancelled > uint64(len(results))+1 {
+		t.Errorf("got %+v, expected some cancelled verifications", m)
+	}
+
+	sig.Free()
+	sk1.Free()
+}
+
+func TestVerifierClose(t *testing.T) {
+	sk1, _ := bls.PrivateKeyFromBytes(sk1Bytes, false)
+	sig := sk1.Sign(payload)
+
+	v := bls.NewVerifier(0, 0)
+	if m := v.Metrics(); m.Workers < 1 {
+		t.Errorf("got %d workers, expected at least 1", m.Workers)
+	}
+
+	// Verifications queued before Close still complete
+	pending := v.VerifyAsync(context.Background(), sig)
+	v.Close()
+	if res := <-pending; res.Err != nil || !res.Valid {
+		t.Errorf("got %+v, expected a valid signature", res)
+	}
+
+	res := <-v.VerifyAsync(context.Background(), sig)
+	expectError(t, res.Err, bls.ErrVerifierClosed)
+	v.Close()
+
+	sig.Free()
+	sk1.Free()
+}